@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/models"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrTokenRevoked = errors.New("token sudah logout atau sesinya dicabut")
+
+	// Hasil cek Redis disimpan sebentar di memori agar tidak setiap request ke Redis
+	sessionChecks = newLocalCache()
+)
+
+func denylistKey(jti string) string {
+	return "auth:denylist:" + jti
+}
+
+func tokenVersionKey(userID uuid.UUID) string {
+	return "auth:token_version:" + userID.String()
+}
+
+func checkCacheTTL() time.Duration {
+	return time.Duration(cfg.TokenCheckCacheSec) * time.Second
+}
+
+// CheckSession menolak token yang jti-nya sudah logout atau versinya lebih lama dari
+// TokenVersion user. Hasilnya di-cache lokal selama TOKEN_CHECK_CACHE_SECONDS, sehingga
+// pencabutan dari instance lain paling lambat berlaku setelah selang itu.
+func CheckSession(session Session) error {
+	denied, err := isDenylisted(session)
+	if err != nil {
+		return err
+	}
+	if denied {
+		return ErrTokenRevoked
+	}
+
+	version, err := currentTokenVersion(session.UserID)
+	if err != nil {
+		return err
+	}
+	if session.Version != version {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// Logout memasukkan jti ke denylist Redis sampai token kedaluwarsa
+func Logout(session Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := cache.RedisClient.Set(context.Background(), denylistKey(session.JTI), 1, ttl).Err(); err != nil {
+		return err
+	}
+	sessionChecks.set(denylistKey(session.JTI), 1, session.ExpiresAt)
+	return nil
+}
+
+// SetTokenVersion menyebarkan TokenVersion baru ke Redis setelah disimpan di database
+func SetTokenVersion(userID uuid.UUID, version int) error {
+	if err := cache.RedisClient.Set(context.Background(), tokenVersionKey(userID), version, 0).Err(); err != nil {
+		return err
+	}
+	sessionChecks.set(tokenVersionKey(userID), int64(version), time.Now().Add(checkCacheTTL()))
+	return nil
+}
+
+func isDenylisted(session Session) (bool, error) {
+	key := denylistKey(session.JTI)
+	if value, ok := sessionChecks.get(key); ok {
+		return value == 1, nil
+	}
+
+	exists, err := cache.RedisClient.Exists(context.Background(), key).Result()
+	if err != nil {
+		return false, err
+	}
+
+	// Token yang sudah logout tidak akan aktif lagi, jadi boleh di-cache sampai kedaluwarsa
+	until := time.Now().Add(checkCacheTTL())
+	if exists == 1 {
+		until = session.ExpiresAt
+	}
+	sessionChecks.set(key, exists, until)
+	return exists == 1, nil
+}
+
+// currentTokenVersion membaca versi dari cache lokal, lalu Redis, lalu database.
+// User yang sudah dihapus menghasilkan error sehingga tokennya ditolak.
+func currentTokenVersion(userID uuid.UUID) (int, error) {
+	key := tokenVersionKey(userID)
+	if value, ok := sessionChecks.get(key); ok {
+		return int(value), nil
+	}
+
+	version, err := cache.RedisClient.Get(context.Background(), key).Int()
+	if errors.Is(err, redis.Nil) {
+		var user models.User
+		if err := db.DB.Select("token_version").First(&user, "id = ?", userID).Error; err != nil {
+			return 0, err
+		}
+		version = user.TokenVersion
+		// SetNX agar tidak menimpa versi yang baru saja dinaikkan instance lain. Jika kalah,
+		// versi dari database di atas mungkin sudah basi, jadi yang dipakai adalah isi Redis.
+		set, err := cache.RedisClient.SetNX(context.Background(), key, strconv.Itoa(version), 0).Result()
+		if err != nil {
+			return 0, err
+		}
+		if !set {
+			if version, err = cache.RedisClient.Get(context.Background(), key).Int(); err != nil {
+				return 0, err
+			}
+		}
+	} else if err != nil {
+		return 0, err
+	}
+
+	sessionChecks.set(key, int64(version), time.Now().Add(checkCacheTTL()))
+	return version, nil
+}
+
+type localCacheEntry struct {
+	value int64
+	until time.Time
+}
+
+// localCache adalah map dengan masa berlaku per entri. Entri kedaluwarsa dibersihkan
+// paling sering sekali per menit saat ada entri baru.
+type localCache struct {
+	mu        sync.Mutex
+	entries   map[string]localCacheEntry
+	lastSweep time.Time
+}
+
+func newLocalCache() *localCache {
+	return &localCache{entries: make(map[string]localCacheEntry), lastSweep: time.Now()}
+}
+
+func (c *localCache) get(key string) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !time.Now().Before(entry.until) {
+		return 0, false
+	}
+	return entry.value, true
+}
+
+func (c *localCache) set(key string, value int64, until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastSweep) > time.Minute {
+		for k, entry := range c.entries {
+			if !now.Before(entry.until) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[key] = localCacheEntry{value: value, until: until}
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalCacheExpiresEntries(t *testing.T) {
+	c := newLocalCache()
+	c.set("version", 3, time.Now().Add(50*time.Millisecond))
+
+	value, ok := c.get("version")
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), value)
+
+	// Setelah masa berlakunya lewat, nilai harus dibaca ulang dari Redis
+	time.Sleep(60 * time.Millisecond)
+	_, ok = c.get("version")
+	assert.False(t, ok)
+
+	// Entri kedaluwarsa dibersihkan saat ada entri baru setelah lebih dari satu menit
+	c.lastSweep = time.Now().Add(-2 * time.Minute)
+	c.set("other", 1, time.Now().Add(time.Minute))
+	assert.NotContains(t, c.entries, "version")
+	assert.Contains(t, c.entries, "other")
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"aro-shop/config"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	cfg       = config.LoadConfig()
+	jwtSecret = []byte(cfg.JWTSecret)
+
+	ErrMissingNotBefore = errors.New("token tidak memiliki klaim nbf")
+	ErrMissingSession   = errors.New("token tidak memiliki klaim jti atau ver")
+)
+
+// AccessTokenTTL adalah masa berlaku access token
+func AccessTokenTTL() time.Duration {
+	return time.Duration(cfg.AccessTokenTTLMin) * time.Minute
+}
+
+// RefreshTokenTTL adalah masa berlaku satu keluarga refresh token sejak login
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(cfg.RefreshTokenTTLDays) * 24 * time.Hour
+}
+
+// IssueAccessToken membuat access token HS256 berumur pendek untuk user. version adalah
+// TokenVersion user saat token dibuat; token ditolak setelah versinya dinaikkan.
+func IssueAccessToken(userID uuid.UUID, role string, version int, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(AccessTokenTTL())
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"jti":     uuid.NewString(),
+		"user_id": userID,
+		"role":    role,
+		"ver":     version,
+		"iss":     cfg.JWTIssuer,
+		"aud":     cfg.JWTAudience,
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	})
+
+	tokenString, err := token.SignedString(jwtSecret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
+// ParseAccessToken memverifikasi tanda tangan serta klaim exp, nbf, iss, dan aud.
+// Token lama tanpa exp, nbf, jti, atau ver ditolak. Status logout dan pencabutan sesi
+// diperiksa terpisah oleh CheckSession.
+func ParseAccessToken(tokenString string) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(cfg.JWTIssuer),
+		jwt.WithAudience(cfg.JWTAudience),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// jwt hanya memeriksa nbf jika klaimnya ada, jadi keberadaannya dicek di sini
+	if nbf, err := token.Claims.GetNotBefore(); err != nil || nbf == nil {
+		return nil, ErrMissingNotBefore
+	}
+	if _, err := SessionFromToken(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Session adalah klaim access token yang dipakai untuk logout dan pencabutan sesi
+type Session struct {
+	JTI       string
+	UserID    uuid.UUID
+	Version   int
+	ExpiresAt time.Time
+}
+
+// SessionFromToken membaca klaim jti, user_id, ver, dan exp dari token yang sudah diverifikasi
+func SessionFromToken(token *jwt.Token) (Session, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Session{}, ErrMissingSession
+	}
+
+	jti, _ := claims["jti"].(string)
+	version, hasVersion := claims["ver"].(float64)
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if jti == "" || !hasVersion || err != nil {
+		return Session{}, ErrMissingSession
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return Session{}, ErrMissingSession
+	}
+	return Session{JTI: jti, UserID: userID, Version: int(version), ExpiresAt: expiresAt.Time}, nil
+}
+
+// NewRefreshToken membuat refresh token acak. Hanya hash yang disimpan di database,
+// token aslinya dikirim sekali ke klien.
+func NewRefreshToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken menghasilkan hash SHA-256 yang dipakai untuk mencari refresh token.
+// Token sudah acak 256 bit sehingga tidak perlu bcrypt.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,115 @@
+package barcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+type Type string
+
+const (
+	TypeCode128 Type = "code128"
+	TypeEAN13   Type = "ean13"
+
+	// Quiet zone minimal di kiri dan kanan barcode (dalam modul)
+	QuietZone = 10
+)
+
+var ErrUnsupportedType = errors.New("tipe barcode tidak didukung")
+
+// Barcode adalah hasil encoding yang siap dirender ke PNG, SVG, atau PDF
+type Barcode struct {
+	Type    Type
+	Content string
+	Modules []bool
+}
+
+func Encode(t Type, content string) (*Barcode, error) {
+	var (
+		modules []bool
+		err     error
+	)
+
+	switch t {
+	case TypeCode128:
+		modules, err = EncodeCode128(content)
+	case TypeEAN13:
+		content, err = NormalizeEAN13(content)
+		if err == nil {
+			modules, err = EncodeEAN13(content)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Barcode{Type: t, Content: content, Modules: modules}, nil
+}
+
+// Bars mengembalikan posisi awal dan lebar setiap bar hitam (dalam modul)
+func (b *Barcode) Bars() [][2]int {
+	var bars [][2]int
+	for i := 0; i < len(b.Modules); i++ {
+		if !b.Modules[i] {
+			continue
+		}
+		start := i
+		for i+1 < len(b.Modules) && b.Modules[i+1] {
+			i++
+		}
+		bars = append(bars, [2]int{start, i - start + 1})
+	}
+	return bars
+}
+
+// PNG merender barcode menjadi gambar PNG hitam-putih.
+// scale = lebar satu modul dalam pixel, height = tinggi bar dalam pixel.
+func (b *Barcode) PNG(scale, height int) ([]byte, error) {
+	width := (len(b.Modules) + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, width, height))
+
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for _, bar := range b.Bars() {
+		x0 := (bar[0] + QuietZone) * scale
+		x1 := x0 + bar[1]*scale
+		for x := x0; x < x1; x++ {
+			for y := 0; y < height; y++ {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG merender barcode menjadi SVG beserta teks kode di bawahnya
+func (b *Barcode) SVG(scale, height int) []byte {
+	const textHeight = 14
+	width := (len(b.Modules) + 2*QuietZone) * scale
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height+textHeight, width, height+textHeight)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="#fff"/>`)
+	for _, bar := range b.Bars() {
+		fmt.Fprintf(&sb, `<rect x="%d" y="0" width="%d" height="%d" fill="#000"/>`, (bar[0]+QuietZone)*scale, bar[1]*scale, height)
+	}
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" font-family="monospace" font-size="12" text-anchor="middle">%s</text>`, width/2, height+textHeight-2, html.EscapeString(b.Content))
+	sb.WriteString(`</svg>`)
+
+	return []byte(sb.String())
+}
@@ -0,0 +1,108 @@
+package barcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Pola lebar bar/spasi Code128, index = nilai simbol (0 - 106)
+var code128Patterns = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeB  = 100
+	code128CodeC  = 99
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+var ErrInvalidCode128 = errors.New("code128 hanya mendukung karakter ASCII 32-126")
+
+// EncodeCode128 mengubah teks menjadi deretan modul (true = bar hitam).
+// Rangkaian digit yang panjang otomatis memakai Code Set C agar barcode lebih pendek.
+func EncodeCode128(content string) ([]bool, error) {
+	if content == "" {
+		return nil, ErrInvalidCode128
+	}
+	for _, r := range content {
+		if r < 32 || r > 126 {
+			return nil, fmt.Errorf("%w: karakter %q", ErrInvalidCode128, r)
+		}
+	}
+
+	var symbols []int
+	codeSet := 0
+	for i := 0; i < len(content); {
+		// Pindah ke Code C hanya jika digit genap >= 4, sisa digit ganjil dikirim lewat Code B
+		run := digitRun(content, i)
+		if run >= 2 && (codeSet == code128CodeC || run >= 4 && run%2 == 0) {
+			if codeSet != code128CodeC {
+				symbols = append(symbols, switchTo(codeSet, code128CodeC))
+				codeSet = code128CodeC
+			}
+			symbols = append(symbols, int(content[i]-'0')*10+int(content[i+1]-'0'))
+			i += 2
+			continue
+		}
+
+		if codeSet != code128CodeB {
+			symbols = append(symbols, switchTo(codeSet, code128CodeB))
+			codeSet = code128CodeB
+		}
+		symbols = append(symbols, int(content[i])-32)
+		i++
+	}
+
+	// Checksum: start + sum(nilai * posisi) mod 103
+	checksum := symbols[0]
+	for i := 1; i < len(symbols); i++ {
+		checksum += symbols[i] * i
+	}
+	symbols = append(symbols, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, s := range symbols {
+		modules = appendWidths(modules, code128Patterns[s])
+	}
+	return modules, nil
+}
+
+func switchTo(current, target int) int {
+	if current == 0 {
+		if target == code128CodeC {
+			return code128StartC
+		}
+		return code128StartB
+	}
+	return target
+}
+
+func digitRun(s string, from int) int {
+	n := 0
+	for i := from; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		n++
+	}
+	return n
+}
+
+// appendWidths menerjemahkan pola lebar (bar, spasi, bar, ...) menjadi modul
+func appendWidths(modules []bool, widths string) []bool {
+	for i, w := range widths {
+		for j := 0; j < int(w-'0'); j++ {
+			modules = append(modules, i%2 == 0)
+		}
+	}
+	return modules
+}
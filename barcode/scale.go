@@ -0,0 +1,55 @@
+package barcode
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Mode barcode timbangan: nilai 5 digit berisi berat (gram) atau harga total
+type ScaleMode string
+
+const (
+	ScaleModeWeight ScaleMode = "weight"
+	ScaleModePrice  ScaleMode = "price"
+)
+
+var ErrNotScaleBarcode = errors.New("bukan barcode timbangan (EAN-13 berawalan 2)")
+
+// ScaleBarcode adalah hasil parsing barcode in-store dari timbangan dengan format
+// 2F IIIII VVVVV C: prefix 2x, 5 digit kode barang (PLU), 5 digit nilai, check digit
+type ScaleBarcode struct {
+	Prefix   string    `json:"prefix"`
+	ItemCode string    `json:"item_code"`
+	Value    int64     `json:"value"`
+	Mode     ScaleMode `json:"mode"`
+}
+
+// IsScaleBarcode mengecek apakah kode adalah EAN-13 dengan prefix 20-29
+func IsScaleBarcode(code string) bool {
+	if len(code) != 13 || code[0] != '2' {
+		return false
+	}
+	_, err := strconv.ParseUint(code, 10, 64)
+	return err == nil
+}
+
+func ParseScaleBarcode(code string, mode ScaleMode) (*ScaleBarcode, error) {
+	if !IsScaleBarcode(code) {
+		return nil, ErrNotScaleBarcode
+	}
+	if _, err := NormalizeEAN13(code); err != nil {
+		return nil, err
+	}
+
+	value, _ := strconv.ParseInt(code[7:12], 10, 64)
+	if mode != ScaleModePrice {
+		mode = ScaleModeWeight
+	}
+
+	return &ScaleBarcode{
+		Prefix:   code[:2],
+		ItemCode: code[2:7],
+		Value:    value,
+		Mode:     mode,
+	}, nil
+}
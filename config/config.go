@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -21,6 +22,30 @@ type Config struct {
 	REDISPass   string
 	REDISdb     string
 	TESTMode    string
+
+	JWTIssuer           string
+	JWTAudience         string
+	AccessTokenTTLMin   int
+	RefreshTokenTTLDays int
+	TokenCheckCacheSec  int
+
+	ScaleBarcodeMode            string
+	LowStockThreshold           int
+	Timezone                    string
+	MaxUploadSizeMB             int
+	AdjustmentApprovalThreshold int
+	ReorderSalesWindowDays      int
+	ReorderLeadTimeDays         int
+
+	StorageDriver      string
+	S3Endpoint         string
+	S3Region           string
+	S3Bucket           string
+	S3AccessKey        string
+	S3SecretKey        string
+	S3UsePathStyle     bool
+	S3ReadMode         string
+	S3PresignExpiryMin int
 }
 
 func LoadConfig() Config {
@@ -41,6 +66,43 @@ func LoadConfig() Config {
 		REDISPass:   getEnv("REDIS_PASS", ""),
 		REDISdb:     getEnv("REDIS_DB", "0"),
 		TESTMode:    getEnv("TEST_MODE", "true"),
+
+		// klaim iss dan aud yang ditulis saat login dan diwajibkan oleh JWTMiddleware
+		JWTIssuer:   getEnv("JWT_ISSUER", "aro-shop"),
+		JWTAudience: getEnv("JWT_AUDIENCE", "aro-shop-api"),
+		// masa berlaku access token, setelahnya klien memakai refresh token
+		AccessTokenTTLMin: getEnvInt("ACCESS_TOKEN_TTL_MINUTES", 15),
+		// masa berlaku refresh token sejak login, rotasi tidak memperpanjang batas ini
+		RefreshTokenTTLDays: getEnvInt("REFRESH_TOKEN_TTL_DAYS", 30),
+		// lama hasil cek logout dan versi token disimpan di memori sebelum ditanyakan lagi ke Redis
+		TokenCheckCacheSec: getEnvInt("TOKEN_CHECK_CACHE_SECONDS", 10),
+
+		// weight = 5 digit nilai berisi berat dalam gram, price = harga total
+		ScaleBarcodeMode: getEnv("SCALE_BARCODE_MODE", "weight"),
+		// batas stok untuk filter ?low_stock=true pada listing produk
+		LowStockThreshold: getEnvInt("LOW_STOCK_THRESHOLD", 5),
+		// zona waktu lokal toko, dipakai untuk jadwal perubahan harga
+		Timezone: getEnv("APP_TIMEZONE", "Asia/Jakarta"),
+		// batas ukuran file gambar produk yang diupload
+		MaxUploadSizeMB: getEnvInt("MAX_UPLOAD_SIZE_MB", 5),
+		// penyesuaian stok di atas jumlah ini harus disetujui superAdmin
+		AdjustmentApprovalThreshold: getEnvInt("STOCK_ADJUSTMENT_APPROVAL_THRESHOLD", 10),
+		// rentang hari penjualan untuk menghitung rata-rata penjualan harian pada saran reorder
+		ReorderSalesWindowDays: getEnvInt("REORDER_SALES_WINDOW_DAYS", 30),
+		// lead time untuk produk yang belum pernah dipesan ke supplier mana pun
+		ReorderLeadTimeDays: getEnvInt("REORDER_DEFAULT_LEAD_TIME_DAYS", 7),
+
+		// local = disk (public/uploads), s3 = object storage kompatibel S3 (AWS, MinIO, dll)
+		StorageDriver:  getEnv("STORAGE_DRIVER", "local"),
+		S3Endpoint:     getEnv("S3_ENDPOINT", ""),
+		S3Region:       getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:       getEnv("S3_BUCKET", ""),
+		S3AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:    getEnv("S3_SECRET_KEY", ""),
+		S3UsePathStyle: getEnv("S3_USE_PATH_STYLE", "true") == "true",
+		// presign = redirect ke URL presigned, proxy = file dialirkan lewat aplikasi
+		S3ReadMode:         getEnv("S3_READ_MODE", "presign"),
+		S3PresignExpiryMin: getEnvInt("S3_PRESIGN_EXPIRY_MINUTES", 15),
 	}
 	return config
 }
@@ -51,3 +113,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
@@ -10,21 +10,77 @@ func Migrate() {
 
 	log.Println("Starting database migration...")
 
+	// Relasi produk-kategori dulu memakai ON DELETE CASCADE; constraint lama dihapus
+	// agar AutoMigrate membuatnya ulang dengan ON DELETE RESTRICT
+	DB.Exec("ALTER TABLE products DROP CONSTRAINT IF EXISTS fk_products_category")
+
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.RefreshToken{},
 		&models.Product{},
+		&models.ProductOptionType{},
+		&models.ProductOptionValue{},
+		&models.ProductVariant{},
+		&models.ModifierGroup{},
+		&models.Modifier{},
+		&models.BundleComponent{},
 		&models.PaymentMethod{},
 		&models.Transaction{},
 		&models.TransactionItem{},
+		&models.TransactionItemModifier{},
+		&models.TransactionItemComponent{},
 		&models.Payment{},
 		&models.Category{},
 		&models.Notification{},
+		&models.ProductPriceHistory{},
+		&models.ScheduledPriceChange{},
+		&models.StockMovement{},
+		&models.StockAdjustment{},
+		&models.Supplier{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderLine{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptLine{},
+		&models.Stocktake{},
+		&models.StocktakeItem{},
+		&models.StocktakeCount{},
+		&models.Location{},
+		&models.LocationStock{},
+		&models.StockTransfer{},
+		&models.StockTransferLine{},
+		&models.StockLot{},
+		&models.StockLotMovement{},
 	)
 
 	// Menambahkan index dengan B-Tree di PostgreSQL
 	DB.Exec("CREATE INDEX idx_product_category_id ON products USING btree (category_id)")
 	DB.Exec("CREATE INDEX idx_product_name ON products USING btree (name)")
 	DB.Exec("CREATE INDEX idx_product_price ON products USING btree (price)")
+	// SKU unik, tapi produk lama boleh belum punya SKU
+	DB.Exec("CREATE UNIQUE INDEX idx_product_sku_unique ON products USING btree (sku) WHERE sku <> ''")
+
+	// Full-text search: kolom tsvector yang di-generate dari nama, SKU, dan deskripsi
+	// (konfigurasi 'simple' karena nama produk campuran bahasa Indonesia dan Inggris),
+	// plus index trigram untuk pencarian yang toleran salah ketik
+	DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm")
+	DB.Exec(`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(sku, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(description, '')), 'C')
+	) STORED`)
+	DB.Exec("CREATE INDEX idx_products_search_vector ON products USING gin (search_vector)")
+	DB.Exec("CREATE INDEX idx_products_name_trgm ON products USING gin (name gin_trgm_ops)")
+
+	// Kategori lama belum punya slug: isi dari nama, nama yang sama diberi akhiran angka
+	DB.Exec(`UPDATE categories c SET slug = s.slug FROM (
+		SELECT id, CASE WHEN n > 1 THEN base || '-' || n ELSE base END AS slug
+		FROM (
+			SELECT id, base, row_number() OVER (PARTITION BY base ORDER BY created_at) AS n
+			FROM (SELECT id, created_at, trim(both '-' from regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g')) AS base FROM categories WHERE slug = '') b
+		) numbered
+	) s WHERE c.id = s.id`)
+	DB.Exec("CREATE UNIQUE INDEX idx_categories_slug_unique ON categories USING btree (slug) WHERE slug <> ''")
+	DB.Exec("CREATE INDEX idx_categories_parent_sort ON categories USING btree (parent_id, sort_order)")
 
 	// Index pada Email (sudah unik, tapi tetap bisa eksplisit)
 	DB.Exec("CREATE UNIQUE INDEX idx_users_email ON users USING btree (email)")
@@ -40,6 +96,40 @@ func Migrate() {
 	// Index pada Date untuk pencarian transaksi berdasarkan tanggal
 	DB.Exec("CREATE INDEX idx_transactions_date ON transactions USING btree (date)")
 
+	// Ledger stok bersifat append-only, koreksi dilakukan dengan movement baru
+	DB.Exec(`CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'stock_movements bersifat append-only';
+		END;
+		$$ LANGUAGE plpgsql`)
+	DB.Exec("DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements")
+	DB.Exec(`CREATE TRIGGER trg_stock_movements_append_only BEFORE UPDATE OR DELETE ON stock_movements
+		FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only()`)
+
+	// Hanya satu sesi stock opname per lokasi yang boleh open agar penyesuaian tidak diposting dua kali
+	DB.Exec("CREATE UNIQUE INDEX idx_stocktakes_single_open_location ON stocktakes USING btree (location_id) WHERE status = 'open'")
+
+	// Stok per lokasi: satu baris per lokasi dan produk/varian, varian kosong dianggap sama
+	DB.Exec(`CREATE UNIQUE INDEX idx_location_stocks_item ON location_stocks USING btree
+		(location_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'))`)
+	DB.Exec("CREATE UNIQUE INDEX idx_locations_single_default ON locations USING btree (is_default) WHERE is_default AND deleted_at IS NULL")
+
+	// Database lama belum punya lokasi: buat lokasi default lalu pindahkan stok global ke sana
+	DB.Exec(`INSERT INTO locations (name, code, is_default, created_at, updated_at)
+		SELECT 'Toko Utama', 'MAIN', true, now(), now()
+		WHERE NOT EXISTS (SELECT 1 FROM locations)`)
+	DB.Exec(`INSERT INTO location_stocks (location_id, product_id, variant_id, quantity, updated_at)
+		SELECT l.id, p.id, NULL, p.stock, now() FROM products p, locations l
+		WHERE l.is_default AND l.deleted_at IS NULL AND p.type <> 'bundle' AND p.stock <> 0
+			AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM location_stocks ls WHERE ls.product_id = p.id)`)
+	DB.Exec(`INSERT INTO location_stocks (location_id, product_id, variant_id, quantity, updated_at)
+		SELECT l.id, v.product_id, v.id, v.stock, now() FROM product_variants v, locations l
+		WHERE l.is_default AND l.deleted_at IS NULL AND v.stock <> 0
+			AND NOT EXISTS (SELECT 1 FROM location_stocks ls WHERE ls.variant_id = v.id)`)
+	DB.Exec(`UPDATE stocktakes SET location_id = (SELECT id FROM locations WHERE is_default AND deleted_at IS NULL LIMIT 1)
+		WHERE location_id IS NULL`)
+
 	if err != nil {
 		log.Fatal("Migration failed : ", err)
 	}
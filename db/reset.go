@@ -12,14 +12,42 @@ func ResetDB() {
 
 	// DROP all tables
 	err := DB.Migrator().DropTable(
+		&models.TransactionItemModifier{},
+		&models.TransactionItemComponent{},
 		&models.TransactionItem{},
 		&models.Transaction{},
 		&models.Payment{},
 		&models.PaymentMethod{},
+		&models.StockLotMovement{},
+		&models.StockLot{},
+		&models.StockTransferLine{},
+		&models.StockTransfer{},
+		&models.LocationStock{},
+		&models.StocktakeCount{},
+		&models.StocktakeItem{},
+		&models.Stocktake{},
+		&models.GoodsReceiptLine{},
+		&models.GoodsReceipt{},
+		&models.PurchaseOrderLine{},
+		&models.PurchaseOrder{},
+		&models.Supplier{},
+		&models.StockAdjustment{},
+		&models.StockMovement{},
+		&models.ScheduledPriceChange{},
+		&models.ProductPriceHistory{},
+		&models.BundleComponent{},
+		&models.Modifier{},
+		&models.ModifierGroup{},
+		"product_variant_option_values",
+		&models.ProductVariant{},
+		&models.ProductOptionValue{},
+		&models.ProductOptionType{},
 		&models.Product{},
 		&models.Category{},
 		&models.Notification{},
+		&models.RefreshToken{},
 		&models.User{},
+		&models.Location{},
 	)
 
 	if err != nil {
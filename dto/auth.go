@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
@@ -23,3 +25,23 @@ type ChangePasswordRequest struct {
 	NewPassword     string `json:"new_password" validate:"required,min=6"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
 }
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse dikembalikan saat login dan refresh. Token adalah access token
+// berumur ExpiresIn detik; RefreshToken hanya bisa dipakai sekali.
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// LogoutRequest boleh kosong; refresh_token diisi agar sesi refresh ikut dicabut
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"aro-shop/models"
+
+	"github.com/google/uuid"
+)
+
+type BundleRequest struct {
+	Components []BundleComponentRequest `json:"components" validate:"required,min=1,dive"`
+}
+
+type BundleComponentRequest struct {
+	ProductID uuid.UUID       `json:"product_id" validate:"required"`
+	Quantity  models.Quantity `json:"quantity" validate:"required,gt=0"`
+}
+
+type BundleComponentResponse struct {
+	ProductID uuid.UUID       `json:"product_id"`
+	Name      string          `json:"name"`
+	Price     float64         `json:"price"`
+	Quantity  models.Quantity `json:"quantity"`
+	Stock     models.Quantity `json:"stock"`
+}
+
+type BundleRevenueComponent struct {
+	ProductID uuid.UUID       `json:"product_id"`
+	Name      string          `json:"name"`
+	Quantity  models.Quantity `json:"quantity"`
+	Revenue   float64         `json:"revenue"`
+}
+
+type BundleRevenueReport struct {
+	BundleID   uuid.UUID                `json:"bundle_id"`
+	BundleName string                   `json:"bundle_name"`
+	Revenue    float64                  `json:"revenue"`
+	Components []BundleRevenueComponent `json:"components"`
+}
@@ -0,0 +1,164 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCategoryAmbiguous    = errors.New("nama kategori dipakai lebih dari satu kategori")
+	ErrCategoryPathNotFound = errors.New("path kategori tidak ditemukan")
+)
+
+// CategoryRequest dipakai untuk membuat dan mengubah kategori.
+// parent_id berupa string agar "" bisa dipakai untuk memindahkan kategori ke root.
+type CategoryRequest struct {
+	Name      string  `json:"name" validate:"required,max=255"`
+	ParentID  *string `json:"parent_id"`
+	Slug      *string `json:"slug" validate:"omitempty,max=255"`
+	SortOrder *int    `json:"sort_order"`
+}
+
+type CategoryTreeNode struct {
+	ID            uuid.UUID           `json:"id"`
+	Name          string              `json:"name"`
+	Slug          string              `json:"slug"`
+	ParentID      *uuid.UUID          `json:"parent_id"`
+	SortOrder     int                 `json:"sort_order"`
+	Products      []ProductResponse   `json:"products,omitempty"`
+	TotalProducts int                 `json:"total_products,omitempty"`
+	Children      []*CategoryTreeNode `json:"children,omitempty"`
+}
+
+// BuildCategoryTree menyusun daftar kategori datar menjadi pohon. Kategori yang induknya
+// tidak ada di daftar diperlakukan sebagai root. Selain root, dikembalikan juga index node
+// per id agar pemanggil bisa menempelkan data tambahan (misalnya produk).
+func BuildCategoryTree(categories []models.Category) ([]*CategoryTreeNode, map[uuid.UUID]*CategoryTreeNode) {
+	nodes := make(map[uuid.UUID]*CategoryTreeNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryTreeNode{
+			ID:        category.ID,
+			Name:      category.Name,
+			Slug:      category.Slug,
+			ParentID:  category.ParentID,
+			SortOrder: category.SortOrder,
+		}
+	}
+
+	var roots []*CategoryTreeNode
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok && parent != node {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	sortCategoryNodes(roots)
+	return roots, nodes
+}
+
+func sortCategoryNodes(nodes []*CategoryTreeNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].SortOrder != nodes[j].SortOrder {
+			return nodes[i].SortOrder < nodes[j].SortOrder
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	for _, node := range nodes {
+		sortCategoryNodes(node.Children)
+	}
+}
+
+// CategoryLookup mencari kategori dari teks bebas (misalnya kolom category di CSV) tanpa
+// membedakan huruf besar/kecil: nama jika hanya dipakai satu kategori, lalu slug, lalu path
+// "Induk/Anak". Nama yang sama di bawah induk berbeda tidak ditebak, melainkan ErrCategoryAmbiguous.
+type CategoryLookup struct {
+	byName map[string][]uuid.UUID
+	bySlug map[string]uuid.UUID
+	byPath map[string]uuid.UUID
+	// references menyimpan nama, path, dan slug asli tiap kategori untuk Reference
+	references map[uuid.UUID][]string
+}
+
+func NewCategoryLookup(categories []models.Category) CategoryLookup {
+	lookup := CategoryLookup{
+		byName:     make(map[string][]uuid.UUID),
+		bySlug:     make(map[string]uuid.UUID),
+		byPath:     make(map[string]uuid.UUID),
+		references: make(map[uuid.UUID][]string),
+	}
+	_, nodes := BuildCategoryTree(categories)
+
+	paths := make(map[uuid.UUID]string)
+	var addPaths func(node *CategoryTreeNode, prefix string)
+	addPaths = func(node *CategoryTreeNode, prefix string) {
+		path := prefix + strings.TrimSpace(node.Name)
+		lookup.byPath[strings.ToLower(path)] = node.ID
+		paths[node.ID] = path
+		for _, child := range node.Children {
+			addPaths(child, path+"/")
+		}
+	}
+
+	for _, category := range categories {
+		name := strings.ToLower(strings.TrimSpace(category.Name))
+		lookup.byName[name] = append(lookup.byName[name], category.ID)
+		if category.Slug != "" {
+			lookup.bySlug[strings.ToLower(category.Slug)] = category.ID
+		}
+		if category.ParentID == nil || nodes[*category.ParentID] == nil {
+			addPaths(nodes[category.ID], "")
+		}
+	}
+	for _, category := range categories {
+		lookup.references[category.ID] = []string{category.Name, paths[category.ID], category.Slug}
+	}
+	return lookup
+}
+
+// Reference mengembalikan teks yang di-resolve Resolve kembali ke kategori id: nama jika
+// unik, lalu path "Induk/Anak", lalu slug. Dipakai export agar hasilnya bisa diimpor ulang.
+func (l CategoryLookup) Reference(id uuid.UUID) string {
+	for _, candidate := range l.references[id] {
+		if resolved, found, _ := l.Resolve(candidate); found && resolved == id {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Resolve mengembalikan ID kategori dan true jika ditemukan. false tanpa error berarti
+// kategori belum ada dan boleh dibuat sebagai kategori root dengan nama tersebut.
+func (l CategoryLookup) Resolve(value string) (uuid.UUID, bool, error) {
+	key := strings.ToLower(strings.TrimSpace(value))
+
+	switch ids := l.byName[key]; {
+	case len(ids) == 1:
+		return ids[0], true, nil
+	case len(ids) > 1:
+		return uuid.Nil, false, ErrCategoryAmbiguous
+	}
+	if id, ok := l.bySlug[key]; ok {
+		return id, true, nil
+	}
+
+	if strings.Contains(key, "/") {
+		parts := strings.Split(key, "/")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if id, ok := l.byPath[strings.Join(parts, "/")]; ok {
+			return id, true, nil
+		}
+		return uuid.Nil, false, ErrCategoryPathNotFound
+	}
+	return uuid.Nil, false, nil
+}
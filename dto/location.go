@@ -0,0 +1,133 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LocationRequest struct {
+	Name      string `json:"name" validate:"required,max=255"`
+	Code      string `json:"code" validate:"required,max=20,alphanum"`
+	Address   string `json:"address" validate:"max=1000"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// UserLocationRequest menempatkan user di sebuah outlet, null berarti lokasi default
+type UserLocationRequest struct {
+	LocationID *uuid.UUID `json:"location_id"`
+}
+
+// StockTransferRequest mengirim barang dari satu lokasi ke lokasi lain. Stok lokasi asal
+// langsung berkurang dan transfer berstatus in_transit sampai diterima.
+type StockTransferRequest struct {
+	FromLocationID uuid.UUID                  `json:"from_location_id" validate:"required"`
+	ToLocationID   uuid.UUID                  `json:"to_location_id" validate:"required,nefield=FromLocationID"`
+	Note           string                     `json:"note" validate:"max=1000"`
+	Lines          []StockTransferLineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+type StockTransferLineRequest struct {
+	ProductID uuid.UUID       `json:"product_id" validate:"required"`
+	VariantID *uuid.UUID      `json:"variant_id"`
+	Quantity  models.Quantity `json:"quantity" validate:"required,gt=0"`
+}
+
+type StockTransferLineResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	ProductID   uuid.UUID       `json:"product_id"`
+	ProductName string          `json:"product_name"`
+	SKU         string          `json:"sku"`
+	VariantID   *uuid.UUID      `json:"variant_id"`
+	VariantName string          `json:"variant_name,omitempty"`
+	Unit        string          `json:"unit"`
+	Quantity    models.Quantity `json:"quantity"`
+}
+
+type StockTransferResponse struct {
+	ID             uuid.UUID                   `json:"id"`
+	FromLocationID uuid.UUID                   `json:"from_location_id"`
+	FromLocation   string                      `json:"from_location"`
+	ToLocationID   uuid.UUID                   `json:"to_location_id"`
+	ToLocation     string                      `json:"to_location"`
+	Status         string                      `json:"status"`
+	Note           string                      `json:"note"`
+	CreatedBy      *uuid.UUID                  `json:"created_by"`
+	ReceivedBy     *uuid.UUID                  `json:"received_by"`
+	ReceivedAt     *time.Time                  `json:"received_at"`
+	Lines          []StockTransferLineResponse `json:"lines"`
+	CreatedAt      time.Time                   `json:"created_at"`
+	UpdatedAt      time.Time                   `json:"updated_at"`
+}
+
+func ConvertToStockTransferResponse(transfer models.StockTransfer) StockTransferResponse {
+	response := StockTransferResponse{
+		ID:             transfer.ID,
+		FromLocationID: transfer.FromLocationID,
+		FromLocation:   transfer.FromLocation.Name,
+		ToLocationID:   transfer.ToLocationID,
+		ToLocation:     transfer.ToLocation.Name,
+		Status:         transfer.Status,
+		Note:           transfer.Note,
+		CreatedBy:      transfer.CreatedBy,
+		ReceivedBy:     transfer.ReceivedBy,
+		ReceivedAt:     transfer.ReceivedAt,
+		Lines:          make([]StockTransferLineResponse, 0, len(transfer.Lines)),
+		CreatedAt:      transfer.CreatedAt,
+		UpdatedAt:      transfer.UpdatedAt,
+	}
+	for _, line := range transfer.Lines {
+		lineResponse := StockTransferLineResponse{
+			ID:          line.ID,
+			ProductID:   line.ProductID,
+			ProductName: line.Product.Name,
+			SKU:         line.Product.SKU,
+			VariantID:   line.VariantID,
+			Unit:        line.Product.Unit,
+			Quantity:    line.Quantity,
+		}
+		if line.Variant != nil {
+			lineResponse.VariantName = line.Variant.Name()
+			if line.Variant.SKU != "" {
+				lineResponse.SKU = line.Variant.SKU
+			}
+		}
+		response.Lines = append(response.Lines, lineResponse)
+	}
+	return response
+}
+
+// LocationStockKey menunjuk stok lokal satu produk, VariantID uuid.Nil untuk produk tanpa varian
+type LocationStockKey struct {
+	ProductID uuid.UUID
+	VariantID uuid.UUID
+}
+
+// LocationStockLevels adalah stok di satu lokasi, item yang tidak ada dianggap nol
+type LocationStockLevels map[LocationStockKey]models.Quantity
+
+// ApplyTo mengisi location_stock pada response produk dan variannya. Ketersediaan bundle
+// dihitung dari stok komponen di lokasi tersebut.
+func (levels LocationStockLevels) ApplyTo(response *ProductResponse, product models.Product) {
+	var stock models.Quantity
+	switch {
+	case product.IsBundle():
+		components := make([]models.BundleComponent, len(product.BundleComponents))
+		for i, component := range product.BundleComponents {
+			component.Component.Stock = levels[LocationStockKey{ProductID: component.ComponentID}]
+			components[i] = component
+		}
+		product.BundleComponents = components
+		stock = product.BundleAvailability()
+	case len(response.Variants) > 0:
+		for i := range response.Variants {
+			variantStock := levels[LocationStockKey{ProductID: product.ID, VariantID: response.Variants[i].ID}]
+			response.Variants[i].LocationStock = &variantStock
+			stock += variantStock
+		}
+	default:
+		stock = levels[LocationStockKey{ProductID: product.ID}]
+	}
+	response.LocationStock = &stock
+}
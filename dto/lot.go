@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockLotResponse adalah satu lot beserta produk dan lokasinya. DaysLeft negatif
+// berarti lot sudah kedaluwarsa sekian hari, nil jika lot tidak punya tanggal kedaluwarsa.
+type StockLotResponse struct {
+	ID           uuid.UUID       `json:"id"`
+	LotNumber    string          `json:"lot_number"`
+	ProductID    uuid.UUID       `json:"product_id"`
+	ProductName  string          `json:"product_name"`
+	SKU          string          `json:"sku"`
+	VariantID    *uuid.UUID      `json:"variant_id"`
+	VariantName  string          `json:"variant_name,omitempty"`
+	LocationID   uuid.UUID       `json:"location_id"`
+	LocationName string          `json:"location_name"`
+	ExpiresAt    *time.Time      `json:"expires_at"`
+	DaysLeft     *int            `json:"days_left"`
+	Expired      bool            `json:"expired"`
+	Quantity     models.Quantity `json:"quantity"`
+	Unit         string          `json:"unit"`
+	Value        float64         `json:"value"` // sisa lot dikali harga pokok produk
+}
+
+// ConvertToStockLotResponse membutuhkan Product, Variant.OptionValues, dan Location sudah di-preload
+func ConvertToStockLotResponse(lot models.StockLot, today time.Time) StockLotResponse {
+	response := StockLotResponse{
+		ID:           lot.ID,
+		LotNumber:    lot.LotNumber,
+		ProductID:    lot.ProductID,
+		ProductName:  lot.Product.Name,
+		SKU:          lot.Product.SKU,
+		VariantID:    lot.VariantID,
+		LocationID:   lot.LocationID,
+		LocationName: lot.Location.Name,
+		ExpiresAt:    lot.ExpiresAt,
+		Expired:      lot.ExpiredOn(today),
+		Quantity:     lot.Quantity,
+		Unit:         lot.Product.Unit,
+		Value:        math.Round(lot.Product.CostPrice*lot.Quantity.Float64()*100) / 100,
+	}
+	if lot.Variant != nil {
+		response.VariantName = lot.Variant.Name()
+		if lot.Variant.SKU != "" {
+			response.SKU = lot.Variant.SKU
+		}
+	}
+	if lot.ExpiresAt != nil {
+		days := int(models.LotDate(*lot.ExpiresAt).Sub(models.LotDate(today)).Hours() / 24)
+		response.DaysLeft = &days
+	}
+	return response
+}
+
+// ExpiringLotReport adalah daftar lot yang kedaluwarsa dalam Days hari ke depan,
+// termasuk lot yang sudah kedaluwarsa tetapi masih tersisa di stok
+type ExpiringLotReport struct {
+	Days         int                `json:"days"`
+	Date         string             `json:"date"`
+	TotalLots    int                `json:"total_lots"`
+	ExpiredLots  int                `json:"expired_lots"`
+	TotalValue   float64            `json:"total_value"`
+	ExpiredValue float64            `json:"expired_value"`
+	Lots         []StockLotResponse `json:"lots"`
+}
+
+func BuildExpiringLotReport(days int, today time.Time, lots []StockLotResponse) ExpiringLotReport {
+	report := ExpiringLotReport{
+		Days:      days,
+		Date:      today.Format("2006-01-02"),
+		TotalLots: len(lots),
+		Lots:      lots,
+	}
+	for _, lot := range lots {
+		report.TotalValue += lot.Value
+		if lot.Expired {
+			report.ExpiredLots++
+			report.ExpiredValue += lot.Value
+		}
+	}
+	report.TotalValue = math.Round(report.TotalValue*100) / 100
+	report.ExpiredValue = math.Round(report.ExpiredValue*100) / 100
+	return report
+}
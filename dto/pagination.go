@@ -0,0 +1,85 @@
+package dto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("cursor tidak valid")
+
+// Cursor menandai posisi baris terakhir (atau pertama, untuk halaman sebelumnya) pada
+// keyset pagination berurutan (created_at, id). Ke client dikirim sebagai string opaque.
+// ID disimpan mentah karena tipe primary key berbeda per tabel (uuid atau angka).
+type Cursor struct {
+	CreatedAt time.Time       `json:"t"`
+	ID        json.RawMessage `json:"i"`
+	Prev      bool            `json:"p,omitempty"`
+}
+
+func NewCursor(createdAt time.Time, id interface{}, prev bool) Cursor {
+	raw, _ := json.Marshal(id)
+	return Cursor{CreatedAt: createdAt, ID: raw, Prev: prev}
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.ID) == 0 || cursor.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// CursorParams adalah opsi pagination dari query string. Mode cursor aktif jika parameter
+// cursor ada (boleh kosong untuk halaman pertama), selain itu tetap memakai page/offset.
+type CursorParams struct {
+	Enabled      bool
+	Cursor       *Cursor
+	IncludeTotal bool
+}
+
+func ParseCursorParams(values map[string][]string) (CursorParams, ErrorDetails) {
+	var (
+		params       CursorParams
+		errorDetails = make(ErrorDetails)
+	)
+
+	if raw, ok := values["cursor"]; ok {
+		params.Enabled = true
+		if len(raw) > 0 && raw[0] != "" {
+			cursor, err := DecodeCursor(raw[0])
+			if err != nil {
+				errorDetails["cursor"] = "Invalid cursor"
+			}
+			params.Cursor = cursor
+		}
+	}
+
+	if raw := values["include_total"]; len(raw) > 0 && raw[0] != "" {
+		includeTotal, err := strconv.ParseBool(raw[0])
+		if err != nil {
+			errorDetails["include_total"] = "Must be true or false"
+		}
+		params.IncludeTotal = includeTotal
+	}
+
+	return params, errorDetails
+}
+
+type CursorPagination struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+	Total      *int64  `json:"total,omitempty"`
+}
@@ -0,0 +1,109 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PriceHistoryResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	ProductID     uuid.UUID  `json:"product_id"`
+	VariantID     *uuid.UUID `json:"variant_id"`
+	OldPrice      float64    `json:"old_price"`
+	NewPrice      float64    `json:"new_price"`
+	Difference    float64    `json:"difference"`
+	Source        string     `json:"source"`
+	ChangedBy     *uuid.UUID `json:"changed_by"`
+	ChangedByName string     `json:"changed_by_name"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func ConvertToPriceHistoryResponse(history models.ProductPriceHistory) PriceHistoryResponse {
+	response := PriceHistoryResponse{
+		ID:         history.ID,
+		ProductID:  history.ProductID,
+		VariantID:  history.VariantID,
+		OldPrice:   history.OldPrice,
+		NewPrice:   history.NewPrice,
+		Difference: math.Round((history.NewPrice-history.OldPrice)*100) / 100,
+		Source:     history.Source,
+		ChangedBy:  history.ChangedBy,
+		CreatedAt:  history.CreatedAt,
+	}
+	if history.User != nil {
+		response.ChangedByName = history.User.Name
+	}
+	return response
+}
+
+// ScheduledPriceRequest menjadwalkan harga baru. effective_at tanpa zona waktu
+// (contoh "2026-10-26 00:00") dibaca sebagai waktu lokal toko.
+type ScheduledPriceRequest struct {
+	VariantID   *uuid.UUID `json:"variant_id"`
+	Price       float64    `json:"price" validate:"required,gt=0"`
+	EffectiveAt string     `json:"effective_at" validate:"required"`
+}
+
+var ErrInvalidEffectiveAt = errors.New("format effective_at harus YYYY-MM-DD HH:MM atau RFC3339")
+
+// localTimeLayouts adalah format waktu tanpa zona yang diterima untuk jadwal harga
+var localTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseEffectiveAt membaca waktu berlaku. Waktu dengan offset (RFC3339) dipakai apa adanya,
+// selain itu dianggap waktu lokal pada loc. Tanggal saja berarti pukul 00:00.
+func ParseEffectiveAt(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalidEffectiveAt
+}
+
+type ScheduledPriceResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	ProductID   uuid.UUID  `json:"product_id"`
+	VariantID   *uuid.UUID `json:"variant_id"`
+	Price       float64    `json:"price"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	Status      string     `json:"status"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+	AppliedAt   *time.Time `json:"applied_at"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ConvertToScheduledPriceResponse menampilkan waktu dalam zona waktu lokal toko
+func ConvertToScheduledPriceResponse(schedule models.ScheduledPriceChange, loc *time.Location) ScheduledPriceResponse {
+	response := ScheduledPriceResponse{
+		ID:          schedule.ID,
+		ProductID:   schedule.ProductID,
+		VariantID:   schedule.VariantID,
+		Price:       schedule.Price,
+		EffectiveAt: schedule.EffectiveAt.In(loc),
+		Status:      schedule.Status,
+		CreatedBy:   schedule.CreatedBy,
+		Error:       schedule.Error,
+		CreatedAt:   schedule.CreatedAt.In(loc),
+	}
+	if schedule.AppliedAt != nil {
+		appliedAt := schedule.AppliedAt.In(loc)
+		response.AppliedAt = &appliedAt
+	}
+	return response
+}
@@ -9,38 +9,164 @@ import (
 )
 
 type ProductResponse struct {
-	ID          uuid.UUID       `json:"id"`
-	Name        string          `json:"name"`
-	Price       float64         `json:"price"`
-	Description string          `json:"description"`
-	Stock       int             `json:"stock"`
-	URLImage    string          `json:"url_image"`
-	Category    models.Category `json:"category"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID               uuid.UUID                 `json:"id"`
+	Name             string                    `json:"name"`
+	Type             string                    `json:"type"`
+	Price            float64                   `json:"price"`
+	Description      string                    `json:"description"`
+	SKU              string                    `json:"sku"`
+	Barcode          string                    `json:"barcode"`
+	Stock            models.Quantity           `json:"stock"`
+	LocationStock    *models.Quantity          `json:"location_stock,omitempty"` // stok di ?location_id=
+	Unit             string                    `json:"unit"`
+	Precision        int                       `json:"precision"`
+	PLU              string                    `json:"plu,omitempty"`
+	TrackLots        bool                      `json:"track_lots"`
+	ReorderPoint     models.Quantity           `json:"reorder_point"`
+	ReorderQty       models.Quantity           `json:"reorder_qty"`
+	URLImage         string                    `json:"url_image"`
+	Images           ProductImages             `json:"images"`
+	Category         models.Category           `json:"category"`
+	OptionTypes      []OptionTypeResponse      `json:"option_types,omitempty"`
+	Variants         []ProductVariantResponse  `json:"variants,omitempty"`
+	ModifierGroups   []ModifierGroupResponse   `json:"modifier_groups,omitempty"`
+	BundleComponents []BundleComponentResponse `json:"bundle_components,omitempty"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	UpdatedAt        time.Time                 `json:"updated_at"`
+	DeletedAt        *time.Time                `json:"deleted_at,omitempty"`
+}
+
+// ProductImages berisi URL gambar produk untuk setiap ukuran. Gambar yang tidak
+// memiliki versi kecil (WebP atau URL eksternal) memakai URL asli untuk semua ukuran.
+type ProductImages struct {
+	Original  string `json:"original"`
+	Medium    string `json:"medium"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+func ConvertToProductImages(product models.Product) ProductImages {
+	images := ProductImages{
+		Original:  product.URLImage,
+		Medium:    product.ImageMediumURL,
+		Thumbnail: product.ImageThumbURL,
+	}
+	if images.Medium == "" {
+		images.Medium = images.Original
+	}
+	if images.Thumbnail == "" {
+		images.Thumbnail = images.Medium
+	}
+	return images
+}
+
+type LabelSheetRequest struct {
+	ProductIDs  []uuid.UUID `json:"product_ids"`
+	CategoryID  *uuid.UUID  `json:"category_id"`
+	BarcodeType string      `json:"barcode_type" validate:"omitempty,oneof=code128 ean13"`
+	Copies      int         `json:"copies" validate:"omitempty,min=1,max=100"`
+}
+
+// ProductSearchResult adalah satu hasil pencarian beserta skor relevansi dan potongan
+// teks yang cocok (teks sudah di-escape HTML, kata yang cocok dibungkus <mark>)
+type ProductSearchResult struct {
+	Product   ProductResponse        `json:"product"`
+	Rank      float64                `json:"rank"`
+	Highlight ProductSearchHighlight `json:"highlight"`
+}
+
+type ProductSearchHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ScanResponse adalah hasil scan barcode di kasir: produk beserta kuantitas yang
+// terbaca dari barcode (1 untuk barcode biasa, berat/jumlah untuk barcode timbangan)
+type ScanResponse struct {
+	Product   ProductResponse `json:"product"`
+	VariantID *uuid.UUID      `json:"variant_id,omitempty"`
+	Quantity  models.Quantity `json:"quantity"`
+	Unit      string          `json:"unit"`
+	Price     float64         `json:"price"`
+	SubTotal  float64         `json:"sub_total"`
+	Scale     bool            `json:"scale"`
 }
 
 type ProductRequest struct {
-	Name        string    `json:"name" validate:"required"`
-	Price       float64   `json:"price" validate:"required,gt=0"`
-	Description string    `json:"description"`
-	Stock       int       `json:"stock" validate:"required,gte=0"`
-	URLImage    string    `json:"url_image"`
-	CategoryID  uuid.UUID `json:"category_id" validate:"required"`
+	Name         string          `json:"name" validate:"required"`
+	Price        float64         `json:"price" validate:"required,gt=0"`
+	Description  string          `json:"description"`
+	SKU          string          `json:"sku" validate:"max=64"`
+	Barcode      string          `json:"barcode" validate:"max=64"`
+	Stock        models.Quantity `json:"stock" validate:"gte=0"` // stok awal, setelah dibuat read-only
+	Unit         string          `json:"unit" validate:"omitempty,oneof=pcs kg g l m"`
+	Precision    int             `json:"precision" validate:"gte=0,lte=3"`
+	PLU          string          `json:"plu" validate:"omitempty,len=5,number"`
+	TrackLots    bool            `json:"track_lots"`
+	ReorderPoint models.Quantity `json:"reorder_point" validate:"gte=0"`
+	ReorderQty   models.Quantity `json:"reorder_qty" validate:"gte=0"`
+	URLImage     string          `json:"url_image"`
+	CategoryID   uuid.UUID       `json:"category_id" validate:"required"`
 }
 
 func ConvertToProductResponse(product models.Product) ProductResponse {
-	return ProductResponse{
-		ID:          product.ID,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		Stock:       product.Stock,
-		URLImage:    product.URLImage,
-		Category:    product.Category,
-		CreatedAt:   product.CreatedAt,
-		UpdatedAt:   product.UpdatedAt,
+	response := ProductResponse{
+		ID:           product.ID,
+		Name:         product.Name,
+		Type:         product.Type,
+		Description:  product.Description,
+		SKU:          product.SKU,
+		Barcode:      product.Barcode,
+		Price:        product.Price,
+		Stock:        product.Stock,
+		Unit:         product.Unit,
+		Precision:    product.Precision,
+		PLU:          product.PLU,
+		TrackLots:    product.TrackLots,
+		ReorderPoint: product.ReorderPoint,
+		ReorderQty:   product.ReorderQty,
+		URLImage:     product.URLImage,
+		Images:       ConvertToProductImages(product),
+		Category:     product.Category,
+		CreatedAt:    product.CreatedAt,
+		UpdatedAt:    product.UpdatedAt,
+	}
+
+	if product.DeletedAt.Valid {
+		response.DeletedAt = &product.DeletedAt.Time
 	}
+
+	for _, optionType := range product.OptionTypes {
+		response.OptionTypes = append(response.OptionTypes, ConvertToOptionTypeResponse(optionType))
+	}
+
+	for _, group := range product.ModifierGroups {
+		response.ModifierGroups = append(response.ModifierGroups, ConvertToModifierGroupResponse(group))
+	}
+
+	// Produk dengan varian: stok induk adalah total stok seluruh varian
+	if len(product.Variants) > 0 {
+		response.Stock = 0
+		for _, variant := range product.Variants {
+			response.Variants = append(response.Variants, ConvertToVariantResponse(variant, product.Price))
+			response.Stock += variant.Stock
+		}
+	}
+
+	// Ketersediaan bundle dihitung dari stok komponen
+	if product.IsBundle() {
+		for _, component := range product.BundleComponents {
+			response.BundleComponents = append(response.BundleComponents, BundleComponentResponse{
+				ProductID: component.ComponentID,
+				Name:      component.Component.Name,
+				Price:     component.Component.Price,
+				Quantity:  component.Quantity,
+				Stock:     component.Component.Stock,
+			})
+		}
+		response.Stock = product.BundleAvailability()
+	}
+
+	return response
 }
 
 var Validate = validator.New()
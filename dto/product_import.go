@@ -0,0 +1,195 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status job import produk yang diproses di background
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// productImportColumns memetakan nama kolom CSV (beserta alias) ke field import
+var productImportColumns = map[string]string{
+	"name":          "name",
+	"sku":           "sku",
+	"price":         "price",
+	"stock":         "stock",
+	"category":      "category",
+	"category_name": "category",
+	"image_url":     "image_url",
+	"url_image":     "image_url",
+}
+
+type ProductImportRow struct {
+	Row      int             `json:"row"`
+	Name     string          `json:"name"`
+	SKU      string          `json:"sku"`
+	Price    float64         `json:"price"`
+	Stock    models.Quantity `json:"stock"`
+	Category string          `json:"category"`
+	ImageURL string          `json:"image_url"`
+	// StockSet membedakan kolom stok kosong (stok lama dipertahankan) dengan stok 0
+	StockSet bool `json:"-"`
+}
+
+// ImportRowError berisi seluruh kesalahan validasi untuk satu baris CSV.
+// Row mengikuti nomor baris di file (header adalah baris 1).
+type ImportRowError struct {
+	Row    int           `json:"row"`
+	Errors []ErrorDetail `json:"errors"`
+}
+
+type ProductImportReport struct {
+	DryRun             bool             `json:"dry_run"`
+	TotalRows          int              `json:"total_rows"`
+	ToCreate           int              `json:"to_create"`
+	ToUpdate           int              `json:"to_update"`
+	CategoriesToCreate []string         `json:"categories_to_create"`
+	Errors             []ImportRowError `json:"errors"`
+}
+
+type ImportJob struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	TotalRows  int        `json:"total_rows"`
+	Processed  int        `json:"processed"`
+	Created    int        `json:"created"`
+	Updated    int        `json:"updated"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+var ErrImportHeader = errors.New("header CSV harus memuat kolom name, sku, price, dan category")
+
+// ParseProductImportCSV membaca CSV produk dan memvalidasi setiap baris tanpa menyentuh
+// database. Baris yang valid dikembalikan bersama daftar kesalahan per baris; validasi yang
+// butuh database (kategori, SKU milik varian) dilakukan oleh pemanggil.
+func ParseProductImportCSV(r io.Reader) ([]ProductImportRow, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, ErrImportHeader
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := productImportColumns[name]; ok {
+			columns[field] = i
+		}
+	}
+	for _, required := range []string{"name", "sku", "price", "category"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, ErrImportHeader
+		}
+	}
+
+	var (
+		rows      []ProductImportRow
+		rowErrors []ImportRowError
+		seenSKU   = make(map[string]int)
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line := csvRecordLine(reader, err)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Errors: []ErrorDetail{{Field: "row", Message: err.Error()}}})
+			continue
+		}
+
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		// Baris kosong di akhir file spreadsheet dilewati
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		var (
+			row     = ProductImportRow{Row: line, Name: value("name"), SKU: value("sku"), Category: value("category"), ImageURL: value("image_url")}
+			details []ErrorDetail
+		)
+
+		if row.Name == "" {
+			details = append(details, ErrorDetail{Field: "name", Message: "Name is required"})
+		}
+		if row.SKU == "" {
+			details = append(details, ErrorDetail{Field: "sku", Message: "SKU is required"})
+		} else if len(row.SKU) > 64 {
+			details = append(details, ErrorDetail{Field: "sku", Message: "SKU must be at most 64 characters"})
+		} else if first, ok := seenSKU[row.SKU]; ok {
+			details = append(details, ErrorDetail{Field: "sku", Message: fmt.Sprintf("Duplicate SKU, already used on row %d", first)})
+		} else {
+			seenSKU[row.SKU] = line
+		}
+
+		if row.Category == "" {
+			details = append(details, ErrorDetail{Field: "category", Message: "Category is required"})
+		}
+
+		if price, err := strconv.ParseFloat(value("price"), 64); err != nil || price <= 0 {
+			details = append(details, ErrorDetail{Field: "price", Message: "Price must be a number greater than 0"})
+		} else {
+			row.Price = price
+		}
+
+		if raw := value("stock"); raw != "" {
+			stock, err := models.ParseQuantity(raw)
+			if err != nil || stock < 0 {
+				details = append(details, ErrorDetail{Field: "stock", Message: "Stock must be a non-negative number with at most 3 decimals"})
+			} else {
+				row.Stock = stock
+				row.StockSet = true
+			}
+		}
+
+		if row.ImageURL != "" {
+			if parsed, err := url.ParseRequestURI(row.ImageURL); err != nil || parsed.Host == "" {
+				details = append(details, ErrorDetail{Field: "image_url", Message: "Image URL must be a valid absolute URL"})
+			}
+		}
+
+		if len(details) > 0 {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Errors: details})
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// csvRecordLine mengembalikan nomor baris record terakhir di file. Baris kosong dilewati
+// oleh csv.Reader, jadi nomor baris tidak bisa dihitung dengan counter sendiri.
+func csvRecordLine(reader *csv.Reader, err error) int {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine
+	}
+	line, _ := reader.FieldPos(0)
+	return line
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"aro-shop/models"
+
+	"github.com/google/uuid"
+)
+
+type ModifierGroupRequest struct {
+	Name      string            `json:"name" validate:"required,max=100"`
+	MinSelect int               `json:"min_select" validate:"gte=0"`
+	MaxSelect int               `json:"max_select" validate:"gte=0"`
+	Modifiers []ModifierRequest `json:"modifiers" validate:"required,min=1,dive"`
+}
+
+type ModifierRequest struct {
+	Name       string  `json:"name" validate:"required,max=100"`
+	PriceDelta float64 `json:"price_delta" validate:"gte=0"`
+}
+
+type ModifierGroupResponse struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	MinSelect int                `json:"min_select"`
+	MaxSelect int                `json:"max_select"`
+	Modifiers []ModifierResponse `json:"modifiers"`
+}
+
+type ModifierResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	PriceDelta float64   `json:"price_delta"`
+}
+
+type TransactionItemModifierResponse struct {
+	ModifierID *uuid.UUID `json:"modifier_id"`
+	GroupName  string     `json:"group_name"`
+	Name       string     `json:"name"`
+	PriceDelta float64    `json:"price_delta"`
+}
+
+func ConvertToModifierGroupResponse(group models.ModifierGroup) ModifierGroupResponse {
+	response := ModifierGroupResponse{
+		ID:        group.ID,
+		Name:      group.Name,
+		MinSelect: group.MinSelect,
+		MaxSelect: group.MaxSelect,
+		Modifiers: []ModifierResponse{},
+	}
+	for _, modifier := range group.Modifiers {
+		response.Modifiers = append(response.Modifiers, ModifierResponse{ID: modifier.ID, Name: modifier.Name, PriceDelta: modifier.PriceDelta})
+	}
+	return response
+}
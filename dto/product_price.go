@@ -0,0 +1,164 @@
+package dto
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Jenis aturan perubahan harga massal
+const (
+	PriceRulePercent = "percent"
+	PriceRuleAmount  = "amount"
+	PriceRuleSet     = "set"
+)
+
+// PriceRule mengubah harga semua produk pada kategori (termasuk sub kategori) atau daftar
+// produk tertentu. percent: +10 berarti naik 10%, amount: tambah/kurang nominal, set: harga baru.
+type PriceRule struct {
+	CategoryID *uuid.UUID  `json:"category_id"`
+	ProductIDs []uuid.UUID `json:"product_ids"`
+	Type       string      `json:"type" validate:"required,oneof=percent amount set"`
+	Value      float64     `json:"value"`
+	RoundTo    float64     `json:"round_to" validate:"gte=0"`
+}
+
+// Apply menghitung harga baru, dibulatkan ke kelipatan RoundTo (misal 100 rupiah)
+// atau ke 2 angka desimal jika RoundTo kosong
+func (r PriceRule) Apply(price float64) float64 {
+	switch r.Type {
+	case PriceRulePercent:
+		price = price * (100 + r.Value) / 100
+	case PriceRuleAmount:
+		price += r.Value
+	case PriceRuleSet:
+		price = r.Value
+	}
+
+	// Jumlah langkah dibulatkan dulu ke 6 desimal agar galat float (misal 1.265 yang
+	// tersimpan sebagai 1.26499...) tidak menyebabkan pembulatan ke bawah
+	step := 0.01
+	if r.RoundTo > 0 {
+		step = r.RoundTo
+	}
+	steps := math.Round(price/step*1e6) / 1e6
+	return math.Round(math.Round(steps)*step*100) / 100
+}
+
+type BulkPriceRequest struct {
+	DryRun bool       `json:"dry_run"`
+	Rule   *PriceRule `json:"rule" validate:"required"`
+}
+
+type PriceChange struct {
+	ProductID  uuid.UUID  `json:"product_id"`
+	VariantID  *uuid.UUID `json:"variant_id,omitempty"`
+	SKU        string     `json:"sku"`
+	Name       string     `json:"name"`
+	OldPrice   float64    `json:"old_price"`
+	NewPrice   float64    `json:"new_price"`
+	Difference float64    `json:"difference"`
+}
+
+// BulkPriceReport adalah diff perubahan harga. RowErrors berisi kesalahan per baris file
+// daftar harga, Errors berisi kesalahan hasil aturan harga per produk.
+type BulkPriceReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Applied   bool             `json:"applied"`
+	Changes   []PriceChange    `json:"changes"`
+	Unchanged int              `json:"unchanged"`
+	RowErrors []ImportRowError `json:"row_errors,omitempty"`
+	Errors    []ErrorDetail    `json:"errors,omitempty"`
+}
+
+type PriceListRow struct {
+	Row   int     `json:"row"`
+	SKU   string  `json:"sku"`
+	Price float64 `json:"price"`
+}
+
+var ErrPriceListHeader = errors.New("header CSV harus memuat kolom sku dan price")
+
+// ParsePriceListCSV membaca file daftar harga berisi kolom sku dan price
+func ParsePriceListCSV(r io.Reader) ([]PriceListRow, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, ErrPriceListHeader
+	}
+
+	skuColumn, priceColumn := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "sku":
+			skuColumn = i
+		case "price":
+			priceColumn = i
+		}
+	}
+	if skuColumn < 0 || priceColumn < 0 {
+		return nil, nil, ErrPriceListHeader
+	}
+
+	var (
+		rows      []PriceListRow
+		rowErrors []ImportRowError
+		seenSKU   = make(map[string]int)
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line := csvRecordLine(reader, err)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Errors: []ErrorDetail{{Field: "row", Message: err.Error()}}})
+			continue
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		var (
+			row     = PriceListRow{Row: line}
+			details []ErrorDetail
+		)
+		if skuColumn < len(record) {
+			row.SKU = strings.TrimSpace(record[skuColumn])
+		}
+		if row.SKU == "" {
+			details = append(details, ErrorDetail{Field: "sku", Message: "SKU is required"})
+		} else if first, ok := seenSKU[row.SKU]; ok {
+			details = append(details, ErrorDetail{Field: "sku", Message: fmt.Sprintf("Duplicate SKU, already used on row %d", first)})
+		} else {
+			seenSKU[row.SKU] = line
+		}
+
+		raw := ""
+		if priceColumn < len(record) {
+			raw = strings.TrimSpace(record[priceColumn])
+		}
+		if price, err := strconv.ParseFloat(raw, 64); err != nil || price <= 0 {
+			details = append(details, ErrorDetail{Field: "price", Message: "Price must be a number greater than 0"})
+		} else {
+			row.Price = math.Round(price*100) / 100
+		}
+
+		if len(details) > 0 {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Errors: details})
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
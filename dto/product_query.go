@@ -0,0 +1,183 @@
+package dto
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// productStockSQL adalah stok yang ditampilkan di response: total stok varian untuk produk
+// bervarian dan jumlah paket yang bisa dibuat dari stok komponen untuk bundle
+const productStockSQL = `CASE
+	WHEN products.type = 'bundle' THEN COALESCE((SELECT MIN(TRUNC(comp.stock / bc.quantity))
+		FROM bundle_components bc JOIN products comp ON comp.id = bc.component_id
+		WHERE bc.bundle_id = products.id AND bc.quantity > 0), 0)
+	WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id)
+		THEN (SELECT SUM(v.stock) FROM product_variants v WHERE v.product_id = products.id)
+	ELSE products.stock
+END`
+
+// ProductSortFields memetakan nilai ?sort= ke klausa ORDER BY, id selalu dipakai
+// sebagai pemecah seri agar urutan (dan pagination) deterministik
+var ProductSortFields = map[string]string{
+	"price":       "price ASC, id ASC",
+	"-price":      "price DESC, id ASC",
+	"name":        "name ASC, id ASC",
+	"-created_at": "created_at DESC, id ASC",
+	"stock":       productStockSQL + " ASC, id ASC",
+}
+
+// ProductListQuery adalah opsi listing produk yang sudah divalidasi
+type ProductListQuery struct {
+	CategoryIDs  []uuid.UUID
+	Search       string
+	Sort         string
+	MinPrice     *float64
+	MaxPrice     *float64
+	InStock      bool
+	LowStock     bool
+	UpdatedSince *time.Time
+	Page         int
+	Limit        int
+	CursorParams
+}
+
+// ParseProductListQuery membaca dan memvalidasi query string listing produk.
+// category boleh diulang atau dipisah koma; sort yang tidak dikenal ditolak.
+func ParseProductListQuery(values map[string][]string) (ProductListQuery, ErrorDetails) {
+	var (
+		query        ProductListQuery
+		errorDetails = make(ErrorDetails)
+		get          = func(key string) string {
+			if v := values[key]; len(v) > 0 {
+				return strings.TrimSpace(v[0])
+			}
+			return ""
+		}
+	)
+
+	seen := make(map[uuid.UUID]bool)
+	for _, raw := range values["category"] {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := uuid.Parse(part)
+			if err != nil {
+				errorDetails["category"] = fmt.Sprintf("Invalid category ID %q", part)
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				query.CategoryIDs = append(query.CategoryIDs, id)
+			}
+		}
+	}
+	sort.Slice(query.CategoryIDs, func(i, j int) bool {
+		return query.CategoryIDs[i].String() < query.CategoryIDs[j].String()
+	})
+
+	query.Search = get("search")
+
+	if query.Sort = get("sort"); query.Sort != "" {
+		if _, ok := ProductSortFields[query.Sort]; !ok {
+			errorDetails["sort"] = "Sort must be one of price, -price, name, -created_at, stock"
+		}
+	}
+
+	for key, target := range map[string]**float64{"min_price": &query.MinPrice, "max_price": &query.MaxPrice} {
+		if raw := get(key); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil || value < 0 {
+				errorDetails[key] = "Must be a non-negative number"
+				continue
+			}
+			*target = &value
+		}
+	}
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		errorDetails["min_price"] = "min_price cannot be greater than max_price"
+	}
+
+	for key, target := range map[string]*bool{"in_stock": &query.InStock, "low_stock": &query.LowStock} {
+		if raw := get(key); raw != "" {
+			value, err := strconv.ParseBool(raw)
+			if err != nil {
+				errorDetails[key] = "Must be true or false"
+				continue
+			}
+			*target = value
+		}
+	}
+
+	if raw := get("updated_since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			since, err = time.Parse("2006-01-02", raw)
+		}
+		if err != nil {
+			errorDetails["updated_since"] = "Must be an RFC3339 timestamp or YYYY-MM-DD date"
+		} else {
+			query.UpdatedSince = &since
+		}
+	}
+
+	cursorParams, cursorErrors := ParseCursorParams(values)
+	query.CursorParams = cursorParams
+	for key, message := range cursorErrors {
+		errorDetails[key] = message
+	}
+	// Mode cursor selalu berurutan (created_at, id) sehingga tidak bisa digabung dengan sort
+	if query.Enabled && query.Sort != "" {
+		errorDetails["sort"] = "Sort cannot be combined with cursor pagination"
+	}
+
+	query.Page, _ = strconv.Atoi(get("page"))
+	query.Limit, _ = strconv.Atoi(get("limit"))
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = 10
+	}
+
+	return query, errorDetails
+}
+
+// CacheKey menghasilkan key Redis yang sama untuk kombinasi filter yang sama,
+// tidak bergantung pada urutan parameter di URL
+func (q ProductListQuery) CacheKey() string {
+	categories := make([]string, len(q.CategoryIDs))
+	for i, id := range q.CategoryIDs {
+		categories[i] = id.String()
+	}
+
+	formatPrice := func(price *float64) string {
+		if price == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*price, 'f', -1, 64)
+	}
+	updatedSince := ""
+	if q.UpdatedSince != nil {
+		updatedSince = strconv.FormatInt(q.UpdatedSince.Unix(), 10)
+	}
+
+	page := strconv.Itoa(q.Page)
+	if q.Enabled {
+		page = "cursor"
+		if q.Cursor != nil {
+			page += "=" + q.Cursor.Encode()
+		}
+	}
+
+	return fmt.Sprintf("products_list:c=%s:q=%s:sort=%s:min=%s:max=%s:in=%t:low=%t:since=%s:total=%t:%s:%d",
+		strings.Join(categories, ","), strings.ToLower(q.Search), q.Sort,
+		formatPrice(q.MinPrice), formatPrice(q.MaxPrice),
+		q.InStock, q.LowStock, updatedSince, q.IncludeTotal, page, q.Limit)
+}
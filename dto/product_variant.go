@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"aro-shop/models"
+
+	"github.com/google/uuid"
+)
+
+type OptionTypeRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Values []string `json:"values" validate:"required,min=1,dive,required,max=100"`
+}
+
+type VariantRequest struct {
+	SKU            string           `json:"sku" validate:"max=64"`
+	Barcode        string           `json:"barcode" validate:"max=64"`
+	Price          *float64         `json:"price" validate:"omitempty,gt=0"`
+	Stock          *models.Quantity `json:"stock" validate:"omitempty,gte=0"` // stok awal, setelah dibuat read-only
+	OptionValueIDs []uuid.UUID      `json:"option_value_ids" validate:"required,min=1"`
+}
+
+func (r VariantRequest) InitialStock() models.Quantity {
+	if r.Stock == nil {
+		return 0
+	}
+	return *r.Stock
+}
+
+type OptionTypeResponse struct {
+	ID     uuid.UUID             `json:"id"`
+	Name   string                `json:"name"`
+	Values []OptionValueResponse `json:"values"`
+}
+
+type OptionValueResponse struct {
+	ID    uuid.UUID `json:"id"`
+	Value string    `json:"value"`
+}
+
+type VariantOptionResponse struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type ProductVariantResponse struct {
+	ID            uuid.UUID               `json:"id"`
+	Name          string                  `json:"name"`
+	SKU           string                  `json:"sku"`
+	Barcode       string                  `json:"barcode"`
+	Price         float64                 `json:"price"`
+	PriceOverride *float64                `json:"price_override"`
+	Stock         models.Quantity         `json:"stock"`
+	LocationStock *models.Quantity        `json:"location_stock,omitempty"`
+	Options       []VariantOptionResponse `json:"options"`
+}
+
+func ConvertToOptionTypeResponse(optionType models.ProductOptionType) OptionTypeResponse {
+	response := OptionTypeResponse{ID: optionType.ID, Name: optionType.Name, Values: []OptionValueResponse{}}
+	for _, value := range optionType.Values {
+		response.Values = append(response.Values, OptionValueResponse{ID: value.ID, Value: value.Value})
+	}
+	return response
+}
+
+func ConvertToVariantResponse(variant models.ProductVariant, parentPrice float64) ProductVariantResponse {
+	response := ProductVariantResponse{
+		ID:            variant.ID,
+		Name:          variant.Name(),
+		SKU:           variant.SKU,
+		Barcode:       variant.Barcode,
+		Price:         variant.EffectivePrice(parentPrice),
+		PriceOverride: variant.Price,
+		Stock:         variant.Stock,
+		Options:       []VariantOptionResponse{},
+	}
+	for _, value := range variant.OptionValues {
+		option := VariantOptionResponse{Value: value.Value}
+		if value.OptionType != nil {
+			option.Type = value.OptionType.Name
+		}
+		response.Options = append(response.Options, option)
+	}
+	return response
+}
@@ -0,0 +1,140 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SupplierRequest struct {
+	Name         string `json:"name" validate:"required,max=255"`
+	ContactName  string `json:"contact_name" validate:"max=255"`
+	Phone        string `json:"phone" validate:"max=50"`
+	Email        string `json:"email" validate:"omitempty,email,max=255"`
+	Address      string `json:"address" validate:"max=1000"`
+	LeadTimeDays int    `json:"lead_time_days" validate:"min=0,max=365"`
+	Note         string `json:"note" validate:"max=1000"`
+}
+
+// PurchaseOrderRequest dipakai untuk membuat PO dan mengubah PO yang masih draft.
+// expected_at memakai format yang sama dengan jadwal harga (waktu lokal toko),
+// location_id adalah lokasi penerima barang (kosong berarti lokasi default).
+type PurchaseOrderRequest struct {
+	SupplierID uuid.UUID                  `json:"supplier_id" validate:"required"`
+	LocationID *uuid.UUID                 `json:"location_id"`
+	Note       string                     `json:"note" validate:"max=1000"`
+	ExpectedAt string                     `json:"expected_at"`
+	Lines      []PurchaseOrderLineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+type PurchaseOrderLineRequest struct {
+	ProductID uuid.UUID       `json:"product_id" validate:"required"`
+	VariantID *uuid.UUID      `json:"variant_id"`
+	Quantity  models.Quantity `json:"quantity" validate:"required,gt=0"`
+	UnitCost  float64         `json:"unit_cost" validate:"gte=0"`
+}
+
+// GoodsReceiptRequest mencatat barang yang datang. Baris yang tidak disebut dianggap
+// belum datang; unit_cost kosong berarti harga beli sesuai PO.
+type GoodsReceiptRequest struct {
+	Note  string                    `json:"note" validate:"max=1000"`
+	Lines []GoodsReceiptLineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+// GoodsReceiptLineRequest menerima satu baris PO. Produk yang melacak lot wajib
+// menyertakan nomor lot dan tanggal kedaluwarsa (YYYY-MM-DD).
+type GoodsReceiptLineRequest struct {
+	LineID    uuid.UUID       `json:"line_id" validate:"required"`
+	Quantity  models.Quantity `json:"quantity" validate:"required,gt=0"`
+	UnitCost  *float64        `json:"unit_cost" validate:"omitempty,gte=0"`
+	LotNumber string          `json:"lot_number" validate:"max=64"`
+	ExpiresAt string          `json:"expires_at"`
+}
+
+type PurchaseOrderLineResponse struct {
+	ID               uuid.UUID       `json:"id"`
+	LineNo           int             `json:"line_no"`
+	ProductID        uuid.UUID       `json:"product_id"`
+	ProductName      string          `json:"product_name"`
+	SKU              string          `json:"sku"`
+	VariantID        *uuid.UUID      `json:"variant_id"`
+	VariantName      string          `json:"variant_name,omitempty"`
+	Unit             string          `json:"unit"`
+	Quantity         models.Quantity `json:"quantity"`
+	ReceivedQuantity models.Quantity `json:"received_quantity"`
+	Remaining        models.Quantity `json:"remaining"`
+	UnitCost         float64         `json:"unit_cost"`
+	Total            float64         `json:"total"`
+}
+
+type PurchaseOrderResponse struct {
+	ID           uuid.UUID                   `json:"id"`
+	Number       string                      `json:"number"`
+	SupplierID   uuid.UUID                   `json:"supplier_id"`
+	SupplierName string                      `json:"supplier_name"`
+	LocationID   *uuid.UUID                  `json:"location_id"`
+	Status       string                      `json:"status"`
+	Note         string                      `json:"note"`
+	ExpectedAt   *time.Time                  `json:"expected_at"`
+	CreatedBy    *uuid.UUID                  `json:"created_by"`
+	SentAt       *time.Time                  `json:"sent_at"`
+	ReceivedAt   *time.Time                  `json:"received_at"`
+	ClosedAt     *time.Time                  `json:"closed_at"`
+	Total        float64                     `json:"total"`
+	Lines        []PurchaseOrderLineResponse `json:"lines"`
+	Receipts     []models.GoodsReceipt       `json:"receipts,omitempty"`
+	CreatedAt    time.Time                   `json:"created_at"`
+	UpdatedAt    time.Time                   `json:"updated_at"`
+}
+
+func ConvertToPurchaseOrderLineResponse(line models.PurchaseOrderLine) PurchaseOrderLineResponse {
+	response := PurchaseOrderLineResponse{
+		ID:               line.ID,
+		LineNo:           line.LineNo,
+		ProductID:        line.ProductID,
+		ProductName:      line.Product.Name,
+		SKU:              line.Product.SKU,
+		VariantID:        line.VariantID,
+		Unit:             line.Product.Unit,
+		Quantity:         line.Quantity,
+		ReceivedQuantity: line.ReceivedQuantity,
+		Remaining:        line.Remaining(),
+		UnitCost:         line.UnitCost,
+		Total:            math.Round(line.Total()*100) / 100,
+	}
+	if line.Variant != nil {
+		response.VariantName = line.Variant.Name()
+		if line.Variant.SKU != "" {
+			response.SKU = line.Variant.SKU
+		}
+	}
+	return response
+}
+
+func ConvertToPurchaseOrderResponse(po models.PurchaseOrder) PurchaseOrderResponse {
+	response := PurchaseOrderResponse{
+		ID:           po.ID,
+		Number:       po.Number,
+		SupplierID:   po.SupplierID,
+		SupplierName: po.Supplier.Name,
+		LocationID:   po.LocationID,
+		Status:       po.Status,
+		Note:         po.Note,
+		ExpectedAt:   po.ExpectedAt,
+		CreatedBy:    po.CreatedBy,
+		SentAt:       po.SentAt,
+		ReceivedAt:   po.ReceivedAt,
+		ClosedAt:     po.ClosedAt,
+		Total:        math.Round(po.Total()*100) / 100,
+		Lines:        make([]PurchaseOrderLineResponse, 0, len(po.Lines)),
+		Receipts:     po.Receipts,
+		CreatedAt:    po.CreatedAt,
+		UpdatedAt:    po.UpdatedAt,
+	}
+	for _, line := range po.Lines {
+		response.Lines = append(response.Lines, ConvertToPurchaseOrderLineResponse(line))
+	}
+	return response
+}
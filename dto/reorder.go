@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// ReorderSuggestion adalah saran pemesanan ulang satu produk. ReorderLevel adalah nilai
+// terbesar antara reorder_point dan perkiraan penjualan selama lead time supplier;
+// produk perlu dipesan jika stok ditambah barang yang sedang dipesan berada di bawahnya.
+type ReorderSuggestion struct {
+	ProductID         uuid.UUID       `json:"product_id"`
+	ProductName       string          `json:"product_name"`
+	SKU               string          `json:"sku"`
+	Unit              string          `json:"unit"`
+	SupplierID        *uuid.UUID      `json:"supplier_id"`
+	SupplierName      string          `json:"supplier_name"`
+	Stock             models.Quantity `json:"stock"`
+	OnOrder           models.Quantity `json:"on_order"`
+	ReorderPoint      models.Quantity `json:"reorder_point"`
+	ReorderQty        models.Quantity `json:"reorder_qty"`
+	SoldInWindow      models.Quantity `json:"sold_in_window"`
+	AverageDailySales float64         `json:"average_daily_sales"`
+	LeadTimeDays      int             `json:"lead_time_days"`
+	LeadTimeDemand    models.Quantity `json:"lead_time_demand"`
+	ReorderLevel      models.Quantity `json:"reorder_level"`
+	NeedsReorder      bool            `json:"needs_reorder"`
+	SuggestedQuantity models.Quantity `json:"suggested_quantity"`
+	EstimatedCost     float64         `json:"estimated_cost"`
+}
+
+// BuildReorderSuggestion menghitung saran untuk product (Variants sudah di-preload).
+// sold adalah penjualan bersih selama windowDays, onOrder sisa PO yang belum diterima,
+// dan supplier boleh nil jika produk belum pernah dipesan.
+func BuildReorderSuggestion(product models.Product, sold, onOrder models.Quantity, windowDays, leadTimeDays int, supplier *models.Supplier) ReorderSuggestion {
+	suggestion := ReorderSuggestion{
+		ProductID:    product.ID,
+		ProductName:  product.Name,
+		SKU:          product.SKU,
+		Unit:         product.Unit,
+		Stock:        product.OnHand(),
+		OnOrder:      onOrder,
+		ReorderPoint: product.ReorderPoint,
+		ReorderQty:   product.ReorderQty,
+		SoldInWindow: max(sold, 0),
+		LeadTimeDays: leadTimeDays,
+	}
+	if supplier != nil {
+		suggestion.SupplierID = &supplier.ID
+		suggestion.SupplierName = supplier.Name
+	}
+
+	if windowDays > 0 {
+		suggestion.AverageDailySales = math.Round(suggestion.SoldInWindow.Float64()/float64(windowDays)*1000) / 1000
+	}
+	suggestion.LeadTimeDemand = ceilQuantity(suggestion.SoldInWindow.Float64()/float64(max(windowDays, 1))*float64(leadTimeDays), product.Precision)
+	suggestion.ReorderLevel = max(product.ReorderPoint, suggestion.LeadTimeDemand)
+
+	// Pesan sampai stok kembali ke reorder level ditambah kebutuhan satu lead time berikutnya
+	available := suggestion.Stock + onOrder
+	if available < suggestion.ReorderLevel {
+		suggestion.NeedsReorder = true
+		suggestion.SuggestedQuantity = max(product.ReorderQty, suggestion.ReorderLevel-available+suggestion.LeadTimeDemand)
+		suggestion.EstimatedCost = math.Round(product.CostPrice*suggestion.SuggestedQuantity.Float64()*100) / 100
+	}
+	return suggestion
+}
+
+// ceilQuantity membulatkan ke atas sesuai presisi produk agar saran tidak kurang dari kebutuhan
+func ceilQuantity(value float64, precision int) models.Quantity {
+	scale := math.Pow10(precision)
+	return models.QuantityFromFloat(math.Ceil(value*scale-1e-9) / scale)
+}
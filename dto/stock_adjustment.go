@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockAdjustmentRequest menambah (quantity positif) atau mengurangi (quantity negatif) stok
+// di location_id, kosong berarti lokasi default
+type StockAdjustmentRequest struct {
+	VariantID  *uuid.UUID      `json:"variant_id"`
+	LocationID *uuid.UUID      `json:"location_id"`
+	Quantity   models.Quantity `json:"quantity" validate:"required"`
+	Reason     string          `json:"reason" validate:"required,oneof=damaged expired theft found correction"`
+	Note       string          `json:"note" validate:"max=500"`
+}
+
+// ValidateDirection memastikan arah perubahan sesuai alasan: barang rusak, kedaluwarsa,
+// dan hilang selalu mengurangi stok, barang ditemukan selalu menambah stok
+func (r StockAdjustmentRequest) ValidateDirection() string {
+	switch r.Reason {
+	case models.AdjustmentReasonDamaged, models.AdjustmentReasonExpired, models.AdjustmentReasonTheft:
+		if r.Quantity > 0 {
+			return "Quantity must be negative for reason " + r.Reason
+		}
+	case models.AdjustmentReasonFound:
+		if r.Quantity < 0 {
+			return "Quantity must be positive for reason found"
+		}
+	}
+	return ""
+}
+
+type StockAdjustmentReviewRequest struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+type StockAdjustmentResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	ProductID   uuid.UUID       `json:"product_id"`
+	ProductName string          `json:"product_name"`
+	VariantID   *uuid.UUID      `json:"variant_id"`
+	LocationID  *uuid.UUID      `json:"location_id"`
+	Quantity    models.Quantity `json:"quantity"`
+	Reason      string          `json:"reason"`
+	Note        string          `json:"note"`
+	Status      string          `json:"status"`
+	RequestedBy *uuid.UUID      `json:"requested_by"`
+	ReviewedBy  *uuid.UUID      `json:"reviewed_by"`
+	ReviewedAt  *time.Time      `json:"reviewed_at"`
+	ReviewNote  string          `json:"review_note"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func ConvertToStockAdjustmentResponse(adjustment models.StockAdjustment) StockAdjustmentResponse {
+	return StockAdjustmentResponse{
+		ID:          adjustment.ID,
+		ProductID:   adjustment.ProductID,
+		ProductName: adjustment.Product.Name,
+		VariantID:   adjustment.VariantID,
+		LocationID:  adjustment.LocationID,
+		Quantity:    adjustment.Quantity,
+		Reason:      adjustment.Reason,
+		Note:        adjustment.Note,
+		Status:      adjustment.Status,
+		RequestedBy: adjustment.RequestedBy,
+		ReviewedBy:  adjustment.ReviewedBy,
+		ReviewedAt:  adjustment.ReviewedAt,
+		ReviewNote:  adjustment.ReviewNote,
+		CreatedAt:   adjustment.CreatedAt,
+	}
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockMovementResponse adalah satu baris ledger stok beserta saldo setelah movement tersebut
+type StockMovementResponse struct {
+	ID          uint64          `json:"id"`
+	ProductID   uuid.UUID       `json:"product_id"`
+	VariantID   *uuid.UUID      `json:"variant_id"`
+	Delta       models.Quantity `json:"delta"`
+	Balance     models.Quantity `json:"balance"`
+	Reason      string          `json:"reason"`
+	ReferenceID string          `json:"reference_id"`
+	Note        string          `json:"note"`
+	UserID      *uuid.UUID      `json:"user_id"`
+	UserName    string          `json:"user_name"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func ConvertToStockMovementResponse(movement models.StockMovement, balance models.Quantity) StockMovementResponse {
+	response := StockMovementResponse{
+		ID:          movement.ID,
+		ProductID:   movement.ProductID,
+		VariantID:   movement.VariantID,
+		Delta:       movement.Delta,
+		Balance:     balance,
+		Reason:      movement.Reason,
+		ReferenceID: movement.ReferenceID,
+		Note:        movement.Note,
+		UserID:      movement.UserID,
+		CreatedAt:   movement.CreatedAt,
+	}
+	if movement.User != nil {
+		response.UserName = movement.User.Name
+	}
+	return response
+}
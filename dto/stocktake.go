@@ -0,0 +1,155 @@
+package dto
+
+import (
+	"aro-shop/models"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StocktakeRequest memulai sesi stock opname untuk semua produk atau satu kategori
+// (beserta sub kategorinya) di satu lokasi, kosong berarti lokasi default
+type StocktakeRequest struct {
+	Note       string     `json:"note" validate:"max=500"`
+	CategoryID *uuid.UUID `json:"category_id"`
+	LocationID *uuid.UUID `json:"location_id"`
+}
+
+// StocktakeCountRequest mengirim satu hitungan. Item dicari dari barcode/SKU hasil scan,
+// atau dari product_id (dan variant_id untuk produk bervarian).
+type StocktakeCountRequest struct {
+	Barcode   string           `json:"barcode" validate:"max=64"`
+	ProductID *uuid.UUID       `json:"product_id"`
+	VariantID *uuid.UUID       `json:"variant_id"`
+	Quantity  *models.Quantity `json:"quantity" validate:"required,gte=0"`
+	Note      string           `json:"note" validate:"max=255"`
+}
+
+// StocktakeFinalizeRequest: zero_uncounted=true menganggap item yang tidak dihitung
+// stoknya nol, selain itu item tersebut dilewati dan stoknya tidak diubah
+type StocktakeFinalizeRequest struct {
+	ZeroUncounted bool `json:"zero_uncounted"`
+}
+
+type StocktakeResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	Status       string     `json:"status"`
+	Note         string     `json:"note"`
+	CategoryID   *uuid.UUID `json:"category_id"`
+	LocationID   *uuid.UUID `json:"location_id"`
+	StartedBy    *uuid.UUID `json:"started_by"`
+	FinalizedBy  *uuid.UUID `json:"finalized_by"`
+	FinalizedAt  *time.Time `json:"finalized_at"`
+	TotalItems   int        `json:"total_items"`
+	CountedItems int        `json:"counted_items"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ConvertToStocktakeResponse meringkas sesi, Items harus sudah di-preload
+func ConvertToStocktakeResponse(stocktake models.Stocktake) StocktakeResponse {
+	response := StocktakeResponse{
+		ID:          stocktake.ID,
+		Status:      stocktake.Status,
+		Note:        stocktake.Note,
+		CategoryID:  stocktake.CategoryID,
+		LocationID:  stocktake.LocationID,
+		StartedBy:   stocktake.StartedBy,
+		FinalizedBy: stocktake.FinalizedBy,
+		FinalizedAt: stocktake.FinalizedAt,
+		TotalItems:  len(stocktake.Items),
+		CreatedAt:   stocktake.CreatedAt,
+	}
+	for _, item := range stocktake.Items {
+		if item.Counted() {
+			response.CountedItems++
+		}
+	}
+	return response
+}
+
+// StocktakeVarianceLine adalah selisih satu item. MovementDelta adalah perubahan stok dari
+// ledger (penjualan, penerimaan, dll.) antara sesi dimulai dan hitungan terakhir, sehingga
+// ExpectedAtCount adalah stok yang seharusnya ada saat item dihitung.
+type StocktakeVarianceLine struct {
+	ItemID           uuid.UUID        `json:"item_id"`
+	ProductID        uuid.UUID        `json:"product_id"`
+	ProductName      string           `json:"product_name"`
+	SKU              string           `json:"sku"`
+	VariantID        *uuid.UUID       `json:"variant_id"`
+	VariantName      string           `json:"variant_name,omitempty"`
+	Unit             string           `json:"unit"`
+	ExpectedQuantity models.Quantity  `json:"expected_quantity"`
+	MovementDelta    models.Quantity  `json:"movement_delta"`
+	ExpectedAtCount  models.Quantity  `json:"expected_at_count"`
+	CountedQuantity  *models.Quantity `json:"counted_quantity"`
+	Counts           int              `json:"counts"`
+	Variance         models.Quantity  `json:"variance"`
+	CostPrice        float64          `json:"cost_price"`
+	VarianceValue    float64          `json:"variance_value"`
+}
+
+type StocktakeVarianceReport struct {
+	Stocktake          StocktakeResponse       `json:"stocktake"`
+	Lines              []StocktakeVarianceLine `json:"lines"`
+	UncountedItems     int                     `json:"uncounted_items"`
+	ShortageValue      float64                 `json:"shortage_value"`
+	SurplusValue       float64                 `json:"surplus_value"`
+	TotalVarianceValue float64                 `json:"total_variance_value"`
+}
+
+// BuildStocktakeVarianceLine menghitung selisih item. Item yang belum dihitung tidak
+// memiliki selisih. Product (dan Variant beserta OptionValues) harus sudah di-preload.
+func BuildStocktakeVarianceLine(item models.StocktakeItem, movementDelta models.Quantity) StocktakeVarianceLine {
+	line := StocktakeVarianceLine{
+		ItemID:           item.ID,
+		ProductID:        item.ProductID,
+		ProductName:      item.Product.Name,
+		SKU:              item.Product.SKU,
+		VariantID:        item.VariantID,
+		Unit:             item.Product.Unit,
+		ExpectedQuantity: item.ExpectedQuantity,
+		ExpectedAtCount:  item.ExpectedQuantity,
+		Counts:           item.Counts,
+		CostPrice:        item.CostPrice,
+	}
+	if item.Variant != nil {
+		line.VariantName = item.Variant.Name()
+		if item.Variant.SKU != "" {
+			line.SKU = item.Variant.SKU
+		}
+	}
+	if !item.Counted() {
+		return line
+	}
+
+	counted := item.CountedQuantity
+	line.CountedQuantity = &counted
+	line.MovementDelta = movementDelta
+	line.ExpectedAtCount = item.ExpectedQuantity + movementDelta
+	line.Variance = counted - line.ExpectedAtCount
+	line.VarianceValue = math.Round(line.Variance.Float64()*item.CostPrice*100) / 100
+	return line
+}
+
+// BuildStocktakeVarianceReport menyusun laporan selisih dari baris yang sudah dihitung
+func BuildStocktakeVarianceReport(stocktake models.Stocktake, lines []StocktakeVarianceLine) StocktakeVarianceReport {
+	report := StocktakeVarianceReport{
+		Stocktake: ConvertToStocktakeResponse(stocktake),
+		Lines:     lines,
+	}
+	for _, line := range lines {
+		switch {
+		case line.CountedQuantity == nil:
+			report.UncountedItems++
+		case line.VarianceValue < 0:
+			report.ShortageValue += line.VarianceValue
+		default:
+			report.SurplusValue += line.VarianceValue
+		}
+	}
+	report.ShortageValue = math.Round(report.ShortageValue*100) / 100
+	report.SurplusValue = math.Round(report.SurplusValue*100) / 100
+	report.TotalVarianceValue = math.Round((report.ShortageValue+report.SurplusValue)*100) / 100
+	return report
+}
@@ -1,14 +1,18 @@
 package dto
 
 import (
+	"aro-shop/models"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type TransactionItemRequest struct {
-	ProductID uuid.UUID `json:"product_id" validate:"required"`
-	Quantity  int       `json:"quantity" validate:"required,min=1"`
+	ProductID   uuid.UUID       `json:"product_id" validate:"required"`
+	VariantID   *uuid.UUID      `json:"variant_id"`
+	Quantity    models.Quantity `json:"quantity" validate:"required,gt=0"`
+	ModifierIDs []uuid.UUID     `json:"modifier_ids"`
+	Note        string          `json:"note" validate:"max=255"`
 }
 
 type TransactionRequest struct {
@@ -19,6 +23,7 @@ type TransactionRequest struct {
 type TransactionResponse struct {
 	ID         uuid.UUID                 `json:"id"`
 	User       SimpleUserResponse        `json:"user"`
+	LocationID *uuid.UUID                `json:"location_id,omitempty"`
 	Date       time.Time                 `json:"date"`
 	AmountPaid float64                   `json:"amount_paid"`
 	Items      []TransactionItemResponse `json:"items,omitempty"`
@@ -34,11 +39,16 @@ type SimpleUserResponse struct {
 }
 
 type TransactionItemResponse struct {
-	ID          uuid.UUID `json:"id"`
-	ProductID   uuid.UUID `json:"product_id"`
-	ProductName string    `json:"product_name"`
-	Quantity    int       `json:"quantity"`
-	SubTotal    float64   `json:"subtotal"`
+	ID          uuid.UUID                         `json:"id"`
+	ProductID   uuid.UUID                         `json:"product_id"`
+	ProductName string                            `json:"product_name"`
+	VariantID   *uuid.UUID                        `json:"variant_id,omitempty"`
+	VariantName string                            `json:"variant_name,omitempty"`
+	Quantity    models.Quantity                   `json:"quantity"`
+	Unit        string                            `json:"unit,omitempty"`
+	SubTotal    float64                           `json:"subtotal"`
+	Note        string                            `json:"note,omitempty"`
+	Modifiers   []TransactionItemModifierResponse `json:"modifiers,omitempty"`
 }
 
 type PaymentResponse struct {
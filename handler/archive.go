@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"aro-shop/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// includeArchived mengecek flag ?include_archived=true. Data yang diarsipkan (soft delete)
+// hanya boleh dilihat admin, untuk kasir flag ini diabaikan.
+func includeArchived(c echo.Context) bool {
+	if c.QueryParam("include_archived") != "true" {
+		return false
+	}
+
+	role := currentUserRole(c)
+	return role == models.RoleAdmin || role == models.RoleSuperAdmin
+}
+
+// withArchived menyertakan data yang sudah diarsipkan bila diminta
+func withArchived(query *gorm.DB, archived bool) *gorm.DB {
+	if archived {
+		return query.Unscoped()
+	}
+	return query
+}
+
+// unscoped dipakai pada Preload agar relasi yang sudah diarsipkan tetap termuat,
+// misalnya produk pada riwayat transaksi
+func unscoped(query *gorm.DB) *gorm.DB {
+	return query.Unscoped()
+}
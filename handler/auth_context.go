@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"aro-shop/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// currentUserID mengambil ID user dari token JWT, nil jika tidak tersedia
+func currentUserID(c echo.Context) *uuid.UUID {
+	strID, ok := c.Get("user_id").(string)
+	if !ok {
+		return nil
+	}
+	uid, err := uuid.Parse(strID)
+	if err != nil || uid == uuid.Nil {
+		return nil
+	}
+	return &uid
+}
+
+// currentUserRole mengambil role dari klaim token JWT, kosong jika tidak tersedia
+func currentUserRole(c echo.Context) models.Role {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	role, _ := claims["role"].(string)
+	return models.Role(role)
+}
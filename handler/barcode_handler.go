@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"aro-shop/barcode"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/pdf"
+	"aro-shop/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Tata letak lembar label A4: 3 kolom x 8 baris
+const (
+	labelColumns = 3
+	labelRows    = 8
+	labelMargin  = 20.0
+)
+
+func GetProductBarcode(c echo.Context) error {
+	var (
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+		format       = c.QueryParam("format")
+		barcodeType  = barcode.Type(c.QueryParam("type"))
+	)
+
+	uuidID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := db.DB.First(&product, "id = ?", uuidID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	if barcodeType == "" {
+		barcodeType = barcode.TypeCode128
+	}
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		errorDetails["format"] = "Format must be png or svg"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	scale, _ := strconv.Atoi(c.QueryParam("scale"))
+	if scale < 1 || scale > 10 {
+		scale = 2
+	}
+	height, _ := strconv.Atoi(c.QueryParam("height"))
+	if height < 20 || height > 500 {
+		height = 80
+	}
+
+	code, err := encodeProductBarcode(product, barcodeType)
+	if err != nil {
+		errorDetails["barcode"] = err.Error()
+		return utils.Response(c, http.StatusBadRequest, "Failed to generate barcode", nil, nil, errorDetails)
+	}
+
+	if format == "svg" {
+		return c.Blob(http.StatusOK, "image/svg+xml", code.SVG(scale, height))
+	}
+
+	image, err := code.PNG(scale, height)
+	if err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to render barcode", nil, err, nil)
+	}
+	return c.Blob(http.StatusOK, "image/png", image)
+}
+
+func GenerateLabelSheet(c echo.Context) error {
+	var (
+		req          dto.LabelSheetRequest
+		products     []models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if len(req.ProductIDs) == 0 && req.CategoryID == nil {
+		errorDetails["product_ids"] = "Provide product_ids or category_id"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if req.BarcodeType == "" {
+		req.BarcodeType = string(barcode.TypeCode128)
+	}
+	if req.Copies < 1 {
+		req.Copies = 1
+	}
+
+	query := db.DB.Order("name ASC")
+	if len(req.ProductIDs) > 0 {
+		query = query.Where("id IN ?", req.ProductIDs)
+	} else {
+		query = query.Where("category_id = ?", *req.CategoryID)
+	}
+	if err := query.Find(&products).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch products"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	if len(products) == 0 {
+		errorDetails["products"] = "No products found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetails)
+	}
+
+	// Render barcode dulu agar produk yang gagal bisa dilaporkan sekaligus
+	codes := make([]*barcode.Barcode, len(products))
+	for i, product := range products {
+		code, err := encodeProductBarcode(product, barcode.Type(req.BarcodeType))
+		if err != nil {
+			errorDetails[product.ID.String()] = product.Name + ": " + err.Error()
+			continue
+		}
+		codes[i] = code
+	}
+	if len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Failed to generate barcode", nil, nil, errorDetails)
+	}
+
+	doc := pdf.NewDocument(pdf.A4Width, pdf.A4Height)
+	labelWidth := (doc.Width() - 2*labelMargin) / labelColumns
+	labelHeight := (doc.Height() - 2*labelMargin) / labelRows
+
+	var page *pdf.Page
+	slot := 0
+	for i, product := range products {
+		for n := 0; n < req.Copies; n++ {
+			if slot%(labelColumns*labelRows) == 0 {
+				page = doc.AddPage()
+			}
+			col := slot % labelColumns
+			row := (slot / labelColumns) % labelRows
+			x := labelMargin + float64(col)*labelWidth
+			y := doc.Height() - labelMargin - float64(row+1)*labelHeight
+
+			drawLabel(page, x, y, labelWidth, labelHeight, product, codes[i])
+			slot++
+		}
+	}
+
+	content, err := doc.Bytes()
+	if err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to render label sheet", nil, err, nil)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="labels.pdf"`)
+	return c.Blob(http.StatusOK, "application/pdf", content)
+}
+
+// encodeProductBarcode memilih isi barcode: barcode produk, lalu SKU, lalu ID produk
+func encodeProductBarcode(product models.Product, barcodeType barcode.Type) (*barcode.Barcode, error) {
+	content := product.Barcode
+	if content == "" {
+		content = product.SKU
+	}
+
+	if barcodeType == barcode.TypeEAN13 {
+		if content == "" {
+			return nil, errors.New("product has no EAN-13 barcode")
+		}
+		return barcode.Encode(barcodeType, content)
+	}
+
+	if content == "" {
+		content = product.ID.String()
+	}
+	return barcode.Encode(barcodeType, content)
+}
+
+func drawLabel(page *pdf.Page, x, y, width, height float64, product models.Product, code *barcode.Barcode) {
+	const padding = 8.0
+	innerWidth := width - 2*padding
+
+	page.StrokeRect(x+2, y+2, width-4, height-4, 0.3)
+	page.Text(x+padding, y+height-padding-9, 9, true, pdf.Truncate(product.Name, 9, innerWidth))
+	page.Text(x+padding, y+height-padding-21, 10, false, utils.FormatRupiah(product.Price))
+
+	// Barcode diskalakan agar muat di lebar label
+	moduleWidth := innerWidth / float64(len(code.Modules)+2*barcode.QuietZone)
+	if moduleWidth > 1.5 {
+		moduleWidth = 1.5
+	}
+	barsWidth := moduleWidth * float64(len(code.Modules))
+	barsX := x + (width-barsWidth)/2
+	barsY := y + padding + 10
+	barsHeight := height - 2*padding - 10 - 28
+
+	for _, bar := range code.Bars() {
+		page.Rect(barsX+float64(bar[0])*moduleWidth, barsY, float64(bar[1])*moduleWidth, barsHeight)
+	}
+
+	text := pdf.Truncate(code.Content, 7, innerWidth)
+	page.Text(x+(width-pdf.TextWidth(text, 7))/2, y+padding, 7, false, text)
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// SetProductBundle menjadikan produk sebagai bundle dan mengganti seluruh komponennya
+func SetProductBundle(c echo.Context) error {
+	var (
+		req          dto.BundleRequest
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if err := db.DB.Preload("Variants").First(&product, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	if len(product.Variants) > 0 {
+		errorDetails["id"] = "Products with variants cannot be bundles"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	// Komponen harus produk simple (tanpa bundle bersarang dan tanpa varian)
+	var components []models.BundleComponent
+	seen := make(map[uuid.UUID]bool)
+	for i, reqComponent := range req.Components {
+		field := fmt.Sprintf("components[%d].product_id", i)
+
+		if reqComponent.ProductID == product.ID {
+			errorDetails[field] = "A bundle cannot contain itself"
+			continue
+		}
+		if seen[reqComponent.ProductID] {
+			errorDetails[field] = "Duplicate component"
+			continue
+		}
+		seen[reqComponent.ProductID] = true
+
+		var component models.Product
+		if err := db.DB.Preload("Variants").First(&component, "id = ?", reqComponent.ProductID).Error; err != nil {
+			errorDetails[field] = "Component product not found"
+			continue
+		}
+		if component.IsBundle() || len(component.Variants) > 0 {
+			errorDetails[field] = "Component must be a simple product without variants"
+			continue
+		}
+
+		components = append(components, models.BundleComponent{
+			BundleID:    product.ID,
+			ComponentID: component.ID,
+			Quantity:    reqComponent.Quantity,
+		})
+	}
+
+	if len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bundle_id = ?", product.ID).Delete(&models.BundleComponent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&components).Error; err != nil {
+			return err
+		}
+		return tx.Model(&product).Update("type", models.ProductTypeBundle).Error
+	})
+	if err != nil {
+		errorDetails["database"] = "Failed to save bundle components"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	if err := preloadProductDetails(db.DB).First(&product, "id = ?", product.ID).Error; err != nil {
+		errorDetails["database"] = err.Error()
+		return utils.Response(c, http.StatusInternalServerError, "Failed to load product", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Bundle updated successfully", dto.ConvertToProductResponse(product), nil, nil)
+}
+
+// RemoveProductBundle mengembalikan bundle menjadi produk simple
+func RemoveProductBundle(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bundle_id = ?", productID).Delete(&models.BundleComponent{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Product{}).Where("id = ?", productID).Update("type", models.ProductTypeSimple).Error
+	})
+	if err != nil {
+		errorDetails["database"] = "Failed to remove bundle"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Bundle removed successfully", nil, nil, nil)
+}
+
+// buildBundleComponents memeriksa ketersediaan stok komponen dan membagi subtotal item
+// ke setiap komponen secara proporsional terhadap harga normal komponen
+func buildBundleComponents(bundle models.Product, quantity models.Quantity, subTotal float64) ([]models.TransactionItemComponent, error) {
+	if err := db.DB.Preload("BundleComponents.Component").First(&bundle, "id = ?", bundle.ID).Error; err != nil {
+		return nil, err
+	}
+
+	if len(bundle.BundleComponents) == 0 {
+		return nil, fmt.Errorf("bundle %s belum memiliki komponen", bundle.Name)
+	}
+	if available := bundle.BundleAvailability(); available < quantity {
+		return nil, fmt.Errorf("stok bundle %s tidak mencukupi, tersedia %s", bundle.Name, available)
+	}
+
+	var weightTotal float64
+	for _, component := range bundle.BundleComponents {
+		weightTotal += component.Component.Price * component.Quantity.Float64()
+	}
+
+	var (
+		components []models.TransactionItemComponent
+		allocated  float64
+	)
+	for i, component := range bundle.BundleComponents {
+		share := 0.0
+		switch {
+		case i == len(bundle.BundleComponents)-1:
+			// Komponen terakhir menerima sisa agar total alokasi sama persis dengan subtotal
+			share = math.Round((subTotal-allocated)*100) / 100
+		case weightTotal > 0:
+			share = math.Round(subTotal*component.Component.Price*component.Quantity.Float64()/weightTotal*100) / 100
+		default:
+			share = math.Round(subTotal/float64(len(bundle.BundleComponents))*100) / 100
+		}
+		allocated += share
+
+		components = append(components, models.TransactionItemComponent{
+			ProductID:        component.ComponentID,
+			Quantity:         component.Quantity.Mul(quantity),
+			AllocatedRevenue: share,
+		})
+	}
+
+	return components, nil
+}
+
+func GetBundleRevenueReport(c echo.Context) error {
+	var (
+		errorDetails = make(dto.ErrorDetails)
+		startDate    = c.QueryParam("start")
+		endDate      = c.QueryParam("end")
+	)
+
+	if startDate == "" || endDate == "" {
+		errorDetails["date_range_error"] = "Start date dan end date diperlukan"
+		return utils.Response(c, http.StatusBadRequest, "Start date and end date are required", nil, nil, errorDetails)
+	}
+
+	var rows []struct {
+		BundleID      uuid.UUID
+		BundleName    string
+		ComponentID   uuid.UUID
+		ComponentName string
+		Quantity      models.Quantity
+		Revenue       float64
+	}
+
+	// Hanya transaksi yang sudah dibayar yang dihitung sebagai pendapatan
+	if err := db.DB.Table("transaction_item_components AS tic").
+		Select(`ti.product_id AS bundle_id, bundle.name AS bundle_name,
+			tic.product_id AS component_id, component.name AS component_name,
+			SUM(tic.quantity) AS quantity, SUM(tic.allocated_revenue) AS revenue`).
+		Joins("JOIN transaction_items ti ON ti.id = tic.transaction_item_id").
+		Joins("JOIN transactions t ON t.id = ti.transaction_id").
+		Joins("JOIN payments p ON p.transaction_id = t.id AND p.payment_status = ?", "paid").
+		Joins("JOIN products bundle ON bundle.id = ti.product_id").
+		Joins("JOIN products component ON component.id = tic.product_id").
+		Where("t.date BETWEEN ? AND ?", startDate, endDate).
+		Group("ti.product_id, bundle.name, tic.product_id, component.name").
+		Order("bundle.name, component.name").
+		Scan(&rows).Error; err != nil {
+		errorDetails["database"] = "Failed to build bundle revenue report"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	report := []dto.BundleRevenueReport{}
+	index := make(map[uuid.UUID]int)
+	for _, row := range rows {
+		i, ok := index[row.BundleID]
+		if !ok {
+			report = append(report, dto.BundleRevenueReport{BundleID: row.BundleID, BundleName: row.BundleName})
+			i = len(report) - 1
+			index[row.BundleID] = i
+		}
+		report[i].Revenue += row.Revenue
+		report[i].Components = append(report[i].Components, dto.BundleRevenueComponent{
+			ProductID: row.ComponentID,
+			Name:      row.ComponentName,
+			Quantity:  row.Quantity,
+			Revenue:   row.Revenue,
+		})
+	}
+
+	return utils.Response(c, http.StatusOK, "Bundle revenue report generated successfully", report, nil, nil)
+}
@@ -3,37 +3,62 @@ package handler
 import (
 	"aro-shop/cache"
 	"aro-shop/db"
+	"aro-shop/dto"
 	"aro-shop/models"
 	"aro-shop/utils"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
+var (
+	cachedDataCategories = []string{
+		"categories",
+		"category:*",
+		"categories_tree",
+		"categories_with_products*",
+	}
+)
+
+// categoryTreeSQL mengambil id kategori beserta seluruh turunannya dengan recursive CTE
+const categoryTreeSQL = `WITH RECURSIVE tree AS (
+	SELECT id FROM categories WHERE id IN ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+) SELECT id FROM tree`
+
 func GetCategories(c echo.Context) error {
 	cacheKey := "categories"
-
-	// Cek cache Redis
-	cachedData, err := cache.GetCache(cacheKey)
-	if err == nil {
-		var categories []models.Category
-		if err := json.Unmarshal([]byte(cachedData), &categories); err == nil {
-			return utils.Response(c, http.StatusOK, "Categories retrieved from cache", categories, nil, nil)
+	archived := includeArchived(c)
+
+	// Cek cache Redis, daftar dengan kategori arsip tidak di-cache
+	if !archived {
+		cachedData, err := cache.GetCache(cacheKey)
+		if err == nil {
+			var categories []models.Category
+			if err := json.Unmarshal([]byte(cachedData), &categories); err == nil {
+				return utils.Response(c, http.StatusOK, "Categories retrieved from cache", categories, nil, nil)
+			}
 		}
 	}
 
 	// Fetch dari database jika cache tidak tersedia
 	var categories []models.Category
-	if err := db.DB.Find(&categories).Error; err != nil {
+	if err := withArchived(db.DB, archived).Find(&categories).Error; err != nil {
 		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch categories", nil, err, nil)
 	}
 
 	// Simpan ke Redis dengan waktu kadaluarsa 5 menit
-	jsonData, _ := json.Marshal(categories)
-	cache.SetCache(cacheKey, string(jsonData), 5*time.Minute)
+	if !archived {
+		jsonData, _ := json.Marshal(categories)
+		cache.SetCache(cacheKey, string(jsonData), 5*time.Minute)
+	}
 
 	return utils.Response(c, http.StatusOK, "Categories retrieved successfully", categories, nil, nil)
 }
@@ -71,13 +96,47 @@ func GetCategoriesById(c echo.Context) error {
 }
 
 func CreateCategory(c echo.Context) error {
-	var category models.Category
-	if err := c.Bind(&category); err != nil {
+	var (
+		req          dto.CategoryRequest
+		errorDetails = make(dto.ErrorDetails)
+	)
+	if err := c.Bind(&req); err != nil {
 		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
 	}
 
-	if category.Name == "" {
-		return utils.Response(c, http.StatusBadRequest, "Name is required", nil, nil, nil)
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	category := models.Category{Name: req.Name}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+
+	if req.ParentID != nil && *req.ParentID != "" {
+		parentID, err := uuid.Parse(*req.ParentID)
+		if err != nil {
+			errorDetails["parent_id"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, errorDetails)
+		}
+		var parent models.Category
+		if err := db.DB.First(&parent, "id = ?", parentID).Error; err != nil {
+			errorDetails["parent_id"] = "Parent category not found"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+		category.ParentID = &parentID
+	}
+
+	// Slug dibuat dari nama jika tidak diisi, slug yang diisi manual harus unik
+	if req.Slug != nil && *req.Slug != "" {
+		category.Slug = utils.Slugify(*req.Slug)
+		if categorySlugExists(category.Slug, uuid.Nil) {
+			errorDetails["slug"] = "Slug is already used by another category"
+			return utils.Response(c, http.StatusConflict, "Validation failed", nil, nil, errorDetails)
+		}
+	} else {
+		category.Slug = uniqueCategorySlug(category.Name, uuid.Nil)
 	}
 
 	if err := db.DB.Create(&category).Error; err != nil {
@@ -85,13 +144,14 @@ func CreateCategory(c echo.Context) error {
 	}
 
 	// Hapus cache kategori agar data terbaru bisa diambil
-	go cache.ResetRedisCache("categories")
+	go cache.ResetRedisCache(cachedDataCategories...)
 
 	return utils.Response(c, http.StatusCreated, "Category created successfully", category, nil, nil)
 }
 
 func UpdateCategory(c echo.Context) error {
 	id := c.Param("id")
+	errorDetails := make(dto.ErrorDetails)
 
 	// lakukan pengecekan id
 	uuidID, err := uuid.Parse(id)
@@ -105,20 +165,65 @@ func UpdateCategory(c echo.Context) error {
 		return utils.Response(c, http.StatusNotFound, "Category not found", nil, err, nil)
 	}
 
-	var updateData struct {
-		Name string `json:"name"`
-	}
+	var updateData dto.CategoryRequest
 
 	// bind data
 	if err := c.Bind(&updateData); err != nil {
 		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
 	}
 
-	if updateData.Name == "" {
-		return utils.Response(c, http.StatusBadRequest, "Name is required", nil, nil, nil)
+	if err := validate.Struct(updateData); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
 	}
 
 	existingCategory.Name = updateData.Name
+	if updateData.SortOrder != nil {
+		existingCategory.SortOrder = *updateData.SortOrder
+	}
+
+	// parent_id kosong memindahkan kategori ke root
+	if updateData.ParentID != nil {
+		if *updateData.ParentID == "" {
+			existingCategory.ParentID = nil
+		} else {
+			parentID, err := uuid.Parse(*updateData.ParentID)
+			if err != nil {
+				errorDetails["parent_id"] = "Invalid UUID format"
+				return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, errorDetails)
+			}
+
+			var parent models.Category
+			if err := db.DB.First(&parent, "id = ?", parentID).Error; err != nil {
+				errorDetails["parent_id"] = "Parent category not found"
+				return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+			}
+
+			// Kategori tidak boleh dipindah ke dalam dirinya sendiri atau turunannya
+			descendants, err := categoryDescendantIDs(existingCategory.ID)
+			if err != nil {
+				return utils.Response(c, http.StatusInternalServerError, "Failed to load category tree", nil, err, nil)
+			}
+			for _, descendantID := range descendants {
+				if descendantID == parentID {
+					errorDetails["parent_id"] = "A category cannot be moved under itself or its descendants"
+					return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+				}
+			}
+			existingCategory.ParentID = &parentID
+		}
+	}
+
+	if updateData.Slug != nil && *updateData.Slug != "" {
+		slug := utils.Slugify(*updateData.Slug)
+		if slug != existingCategory.Slug && categorySlugExists(slug, existingCategory.ID) {
+			errorDetails["slug"] = "Slug is already used by another category"
+			return utils.Response(c, http.StatusConflict, "Validation failed", nil, nil, errorDetails)
+		}
+		existingCategory.Slug = slug
+	} else if existingCategory.Slug == "" {
+		existingCategory.Slug = uniqueCategorySlug(existingCategory.Name, existingCategory.ID)
+	}
 
 	// simpan data
 	if err := db.DB.Save(&existingCategory).Error; err != nil {
@@ -126,25 +231,205 @@ func UpdateCategory(c echo.Context) error {
 	}
 
 	// Hapus cache kategori terkait agar data terbaru bisa diambil
-	go cache.ResetRedisCache("categories")
+	go cache.ResetRedisCache(cachedDataCategories...)
 
 	return utils.Response(c, http.StatusOK, "Category updated successfully", existingCategory, nil, nil)
 }
 
+// GetCategoryTree mengembalikan kategori dalam bentuk pohon, diurutkan berdasarkan sort_order lalu nama
+func GetCategoryTree(c echo.Context) error {
+	cacheKey := "categories_tree"
+
+	cachedData, err := cache.GetCache(cacheKey)
+	if err == nil {
+		var tree []*dto.CategoryTreeNode
+		if err := json.Unmarshal([]byte(cachedData), &tree); err == nil {
+			return utils.Response(c, http.StatusOK, "Category tree retrieved from cache", tree, nil, nil)
+		}
+	}
+
+	var categories []models.Category
+	if err := db.DB.Find(&categories).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch categories", nil, err, nil)
+	}
+
+	tree, _ := dto.BuildCategoryTree(categories)
+
+	jsonData, _ := json.Marshal(tree)
+	cache.SetCache(cacheKey, string(jsonData), 5*time.Minute)
+
+	return utils.Response(c, http.StatusOK, "Category tree retrieved successfully", tree, nil, nil)
+}
+
+// DeleteCategory mengarsipkan kategori. Jika masih ada produk di dalamnya, request ditolak
+// dengan 409 kecuali ?reassign_to=<category_id> diberikan; produk dipindahkan lebih dulu
+// dalam satu transaksi database.
 func DeleteCategory(c echo.Context) error {
 	id := c.Param("id")
+	errorDetails := make(dto.ErrorDetails)
 
 	uuidID, err := uuid.Parse(id)
 	if err != nil {
 		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
 	}
 
-	if err := db.DB.Delete(&models.Category{}, "id = ?", uuidID).Error; err != nil {
+	var category models.Category
+	if err := db.DB.First(&category, "id = ?", uuidID).Error; err != nil {
+		return utils.Response(c, http.StatusNotFound, "Category not found", nil, err, nil)
+	}
+
+	// Sub kategori harus dipindah atau dihapus lebih dulu agar tidak menjadi yatim
+	var children int64
+	if err := db.DB.Model(&models.Category{}).Where("parent_id = ?", uuidID).Count(&children).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to check subcategories", nil, err, nil)
+	}
+	if children > 0 {
+		errorDetails["children"] = fmt.Sprintf("%d subcategories still belong to this category", children)
+		return utils.Response(c, http.StatusConflict, "Category still has subcategories", nil, nil, errorDetails)
+	}
+
+	var (
+		reassignTo *uuid.UUID
+		moved      int64
+	)
+	if value := c.QueryParam("reassign_to"); value != "" {
+		targetID, err := uuid.Parse(value)
+		if err != nil {
+			errorDetails["reassign_to"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, errorDetails)
+		}
+		if targetID == uuidID {
+			errorDetails["reassign_to"] = "Target category must be different from the deleted category"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+		}
+
+		var target models.Category
+		if err := db.DB.First(&target, "id = ?", targetID).Error; err != nil {
+			errorDetails["reassign_to"] = "Target category not found"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+		reassignTo = &targetID
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Product{}).Where("category_id = ?", uuidID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if reassignTo == nil {
+			if count > 0 {
+				return errCategoryInUse{count: count}
+			}
+		} else {
+			// Produk yang diarsipkan ikut dipindahkan agar tetap bisa dipulihkan
+			result := tx.Unscoped().Model(&models.Product{}).Where("category_id = ?", uuidID).Update("category_id", *reassignTo)
+			if result.Error != nil {
+				return result.Error
+			}
+			moved = result.RowsAffected
+		}
+
+		return tx.Delete(&category).Error
+	})
+
+	var inUse errCategoryInUse
+	if errors.As(err, &inUse) {
+		errorDetails["products"] = fmt.Sprintf("%d products still use this category, pass reassign_to to move them", inUse.count)
+		return utils.Response(c, http.StatusConflict, "Category still has products", nil, err, errorDetails)
+	}
+	if err != nil {
 		return utils.Response(c, http.StatusInternalServerError, "Failed to delete category", nil, err, nil)
 	}
 
 	// Hapus cache kategori terkait agar data terbaru bisa diambil
-	go cache.ResetRedisCache("categories")
+	go cache.ResetRedisCache(cachedDataCategories...)
+	if moved > 0 {
+		go cache.ResetRedisCache(cachedDataProducts...)
+	}
+
+	response := map[string]interface{}{
+		"products_moved": moved,
+		"reassigned_to":  reassignTo,
+	}
+
+	return utils.Response(c, http.StatusOK, "Category archived successfully", response, nil, nil)
+}
+
+type errCategoryInUse struct {
+	count int64
+}
+
+func (e errCategoryInUse) Error() string {
+	return fmt.Sprintf("kategori masih dipakai %d produk", e.count)
+}
+
+// RestoreCategory mengembalikan kategori yang sudah diarsipkan
+func RestoreCategory(c echo.Context) error {
+	id := c.Param("id")
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	var archived models.Category
+	if err := db.DB.Unscoped().First(&archived, "id = ? AND deleted_at IS NOT NULL", uuidID).Error; err != nil {
+		return utils.Response(c, http.StatusNotFound, "Archived category not found", nil, err, nil)
+	}
+
+	// Induk yang masih diarsipkan harus dipulihkan terlebih dahulu
+	if archived.ParentID != nil {
+		var parent models.Category
+		if err := db.DB.First(&parent, "id = ?", *archived.ParentID).Error; err != nil {
+			return utils.Response(c, http.StatusConflict, "Parent category is archived, restore the parent first", nil, err, nil)
+		}
+	}
+
+	result := db.DB.Unscoped().Model(&models.Category{}).
+		Where("id = ? AND deleted_at IS NOT NULL", uuidID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to restore category", nil, result.Error, nil)
+	}
+	if result.RowsAffected == 0 {
+		return utils.Response(c, http.StatusNotFound, "Archived category not found", nil, nil, nil)
+	}
+
+	var category models.Category
+	if err := db.DB.First(&category, "id = ?", uuidID).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to load category", nil, err, nil)
+	}
+
+	go cache.ResetRedisCache(cachedDataCategories...)
 
-	return utils.Response(c, http.StatusOK, "Category deleted successfully", nil, nil, nil)
+	return utils.Response(c, http.StatusOK, "Category restored successfully", category, nil, nil)
+}
+
+// categoryDescendantIDs mengembalikan id kategori beserta seluruh turunannya
+func categoryDescendantIDs(id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.DB.Raw(categoryTreeSQL, []uuid.UUID{id}).Scan(&ids).Error
+	return ids, err
+}
+
+// categorySlugExists mengecek slug termasuk pada kategori yang diarsipkan
+func categorySlugExists(slug string, excludeID uuid.UUID) bool {
+	var count int64
+	db.DB.Unscoped().Model(&models.Category{}).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count)
+	return count > 0
+}
+
+// uniqueCategorySlug membuat slug dari nama dan menambahkan akhiran angka jika sudah dipakai
+func uniqueCategorySlug(name string, excludeID uuid.UUID) string {
+	base := utils.Slugify(name)
+	if base == "" {
+		base = "category"
+	}
+
+	slug := base
+	for i := 2; categorySlugExists(slug, excludeID); i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug
 }
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var errLocationInUse = errors.New("lokasi masih dipakai")
+
+// GetLocations menampilkan semua outlet/gudang, lokasi default paling atas
+func GetLocations(c echo.Context) error {
+	var locations []models.Location
+
+	if err := withArchived(db.DB, includeArchived(c)).Order("is_default DESC, name ASC").Find(&locations).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch locations", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Locations retrieved successfully", locations, nil, nil)
+}
+
+func CreateLocation(c echo.Context) error {
+	var req dto.LocationRequest
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errDetails := utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errDetails)
+	}
+
+	location := models.Location{}
+	if status, errorDetails := applyLocationRequest(&location, req); len(errorDetails) > 0 {
+		return utils.Response(c, status, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if err := saveLocation(&location); err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to create location", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusCreated, "Location created successfully", location, nil, nil)
+}
+
+func UpdateLocation(c echo.Context) error {
+	var (
+		req      dto.LocationRequest
+		location models.Location
+	)
+
+	locationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	if err := db.DB.First(&location, "id = ?", locationID).Error; err != nil {
+		return utils.Response(c, http.StatusNotFound, "Location not found", nil, err, nil)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errDetails := utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errDetails)
+	}
+
+	// Lokasi default hanya berpindah dengan menjadikan lokasi lain default
+	if location.IsDefault && !req.IsDefault {
+		errorDetails := dto.ErrorDetails{"is_default": "Make another location the default instead"}
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if status, errorDetails := applyLocationRequest(&location, req); len(errorDetails) > 0 {
+		return utils.Response(c, status, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if err := saveLocation(&location); err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to update location", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Location updated successfully", location, nil, nil)
+}
+
+// DeleteLocation mengarsipkan lokasi yang sudah kosong. Lokasi default, lokasi yang masih
+// menyimpan stok, dan lokasi dengan transfer yang belum diterima tidak bisa diarsipkan.
+// User yang bertugas di lokasi tersebut dipindahkan ke lokasi default.
+func DeleteLocation(c echo.Context) error {
+	var (
+		location     models.Location
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	locationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	if err := db.DB.First(&location, "id = ?", locationID).Error; err != nil {
+		return utils.Response(c, http.StatusNotFound, "Location not found", nil, err, nil)
+	}
+	if location.IsDefault {
+		errorDetails["id"] = "The default location cannot be archived"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, errLocationInUse, errorDetails)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var stocked, transfers int64
+		if err := tx.Model(&models.LocationStock{}).Where("location_id = ? AND quantity <> 0", location.ID).Count(&stocked).Error; err != nil {
+			return err
+		}
+		if stocked > 0 {
+			errorDetails["id"] = "Location still holds stock, transfer it out first"
+			return errLocationInUse
+		}
+		if err := tx.Model(&models.StockTransfer{}).
+			Where("status = ? AND (from_location_id = ? OR to_location_id = ?)", models.TransferInTransit, location.ID, location.ID).
+			Count(&transfers).Error; err != nil {
+			return err
+		}
+		if transfers > 0 {
+			errorDetails["id"] = "Location has transfers in transit"
+			return errLocationInUse
+		}
+
+		if err := tx.Model(&models.User{}).Where("location_id = ?", location.ID).Update("location_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&location).Error
+	})
+	switch {
+	case errors.Is(err, errLocationInUse):
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case err != nil:
+		return utils.Response(c, http.StatusInternalServerError, "Failed to delete location", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Location archived successfully", nil, nil, nil)
+}
+
+// GetLocationStock menampilkan stok setiap produk dan varian di satu lokasi
+func GetLocationStock(c echo.Context) error {
+	var stocks []models.LocationStock
+
+	locationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	if err := db.DB.First(&models.Location{}, "id = ?", locationID).Error; err != nil {
+		return utils.Response(c, http.StatusNotFound, "Location not found", nil, err, nil)
+	}
+
+	if err := db.DB.Where("location_id = ? AND quantity <> 0", locationID).Order("product_id, variant_id").Find(&stocks).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch location stock", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Location stock retrieved successfully", stocks, nil, nil)
+}
+
+// UpdateUserLocation menempatkan kasir di sebuah outlet. Transaksi kasir tersebut
+// mengurangi stok outlet ini, null mengembalikannya ke lokasi default.
+func UpdateUserLocation(c echo.Context) error {
+	var (
+		req  dto.UserLocationRequest
+		user models.User
+	)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	if err := db.DB.First(&user, "id = ?", userID).Error; err != nil {
+		return utils.Response(c, http.StatusNotFound, "User not found", nil, err, nil)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if req.LocationID != nil {
+		if err := db.DB.First(&models.Location{}, "id = ?", *req.LocationID).Error; err != nil {
+			errorDetails := dto.ErrorDetails{"location_id": "Location not found"}
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+		}
+	}
+
+	if err := db.DB.Model(&user).Update("location_id", req.LocationID).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to update user location", nil, err, nil)
+	}
+
+	responseData := map[string]interface{}{
+		"user_id":     user.ID,
+		"location_id": req.LocationID,
+	}
+	return utils.Response(c, http.StatusOK, "User location updated successfully", responseData, nil, nil)
+}
+
+// applyLocationRequest mengisi field lokasi dari request. Kode lokasi unik termasuk
+// terhadap lokasi yang sudah diarsipkan agar riwayat tetap bisa dibedakan.
+func applyLocationRequest(location *models.Location, req dto.LocationRequest) (int, dto.ErrorDetails) {
+	errorDetails := make(dto.ErrorDetails)
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+
+	var count int64
+	if err := db.DB.Unscoped().Model(&models.Location{}).Where("code = ? AND id <> ?", code, location.ID).Count(&count).Error; err != nil {
+		errorDetails["database"] = "Failed to check location code"
+		return http.StatusInternalServerError, errorDetails
+	}
+	if count > 0 {
+		errorDetails["code"] = "Location code is already used"
+		return http.StatusConflict, errorDetails
+	}
+
+	location.Name = strings.TrimSpace(req.Name)
+	location.Code = code
+	location.Address = req.Address
+	location.IsDefault = req.IsDefault
+	return http.StatusOK, nil
+}
+
+// saveLocation menyimpan lokasi; jika lokasi dijadikan default, default lama dilepas
+// dalam transaksi yang sama
+func saveLocation(location *models.Location) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if location.IsDefault {
+			if err := tx.Model(&models.Location{}).Where("is_default = ? AND id <> ?", true, location.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(location).Error
+	})
+}
+
+// queryLocationID membaca ?location_id= untuk menampilkan stok lokal. Nil jika parameter
+// kosong; lokasi yang tidak ada menghasilkan error beserta status HTTP-nya.
+func queryLocationID(c echo.Context) (*uuid.UUID, int, dto.ErrorDetails, error) {
+	value := c.QueryParam("location_id")
+	if value == "" {
+		return nil, http.StatusOK, nil, nil
+	}
+
+	locationID, err := uuid.Parse(value)
+	if err != nil {
+		return nil, http.StatusBadRequest, dto.ErrorDetails{"location_id": "Invalid UUID format"}, err
+	}
+	if err := db.DB.First(&models.Location{}, "id = ?", locationID).Error; err != nil {
+		return nil, http.StatusNotFound, dto.ErrorDetails{"location_id": "Location not found"}, err
+	}
+	return &locationID, http.StatusOK, nil, nil
+}
+
+// productResponsesAt mengonversi produk ke response; bila lokasi diminta, stok lokal
+// (termasuk stok komponen bundle) dimuat sekaligus dan diisi ke location_stock
+func productResponsesAt(products []models.Product, locationID *uuid.UUID) ([]dto.ProductResponse, error) {
+	responses := make([]dto.ProductResponse, 0, len(products))
+	for _, product := range products {
+		responses = append(responses, dto.ConvertToProductResponse(product))
+	}
+	if locationID == nil || len(products) == 0 {
+		return responses, nil
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+		for _, component := range product.BundleComponents {
+			productIDs = append(productIDs, component.ComponentID)
+		}
+	}
+
+	levels, err := locationStockLevels(db.DB, *locationID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range responses {
+		levels.ApplyTo(&responses[i], products[i])
+	}
+	return responses, nil
+}
+
+// locationStockLevels memuat stok di satu lokasi untuk productIDs, atau semua produk jika nil
+func locationStockLevels(tx *gorm.DB, locationID uuid.UUID, productIDs []uuid.UUID) (dto.LocationStockLevels, error) {
+	var stocks []models.LocationStock
+
+	query := tx.Where("location_id = ?", locationID)
+	if productIDs != nil {
+		query = query.Where("product_id IN ?", productIDs)
+	}
+	if err := query.Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+
+	levels := make(dto.LocationStockLevels, len(stocks))
+	for _, stock := range stocks {
+		key := dto.LocationStockKey{ProductID: stock.ProductID}
+		if stock.VariantID != nil {
+			key.VariantID = *stock.VariantID
+		}
+		levels[key] = stock.Quantity
+	}
+	return levels, nil
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func preloadStockLot(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Product", unscoped).
+		Preload("Variant.OptionValues").
+		Preload("Location", unscoped)
+}
+
+// GetExpiringLots adalah laporan lot yang kedaluwarsa dalam ?days= hari (default 30),
+// termasuk lot yang sudah kedaluwarsa namun masih ada di stok. Filter ?location_id= opsional.
+func GetExpiringLots(c echo.Context) error {
+	var (
+		lots         []models.StockLot
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	days := 30
+	if daysStr := c.QueryParam("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 0 || parsed > 365 {
+			errorDetails["days"] = "Days must be between 0 and 365"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		days = parsed
+	}
+
+	locationID, status, locationErrors, err := queryLocationID(c)
+	if err != nil {
+		return utils.Response(c, status, "Invalid query parameters", nil, err, locationErrors)
+	}
+
+	today := models.LotDate(time.Now().In(storeLocation))
+	query := preloadStockLot(db.DB).
+		Where("quantity > 0 AND expires_at IS NOT NULL AND expires_at <= ?", today.AddDate(0, 0, days))
+	if locationID != nil {
+		query = query.Where("location_id = ?", *locationID)
+	}
+	if err := query.Order("expires_at ASC, lot_number ASC").Find(&lots).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch expiring lots"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	responses := make([]dto.StockLotResponse, 0, len(lots))
+	for _, lot := range lots {
+		responses = append(responses, dto.ConvertToStockLotResponse(lot, today))
+	}
+
+	return utils.Response(c, http.StatusOK, "Expiring lots fetched successfully", dto.BuildExpiringLotReport(days, today, responses), nil, nil)
+}
+
+// GetProductLots menampilkan lot produk yang masih tersisa dengan urutan FEFO,
+// filter ?location_id= opsional
+func GetProductLots(c echo.Context) error {
+	var (
+		lots         []models.StockLot
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := db.DB.Unscoped().First(&models.Product{}, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	locationID, status, locationErrors, err := queryLocationID(c)
+	if err != nil {
+		return utils.Response(c, status, "Invalid query parameters", nil, err, locationErrors)
+	}
+
+	query := preloadStockLot(db.DB).Where("product_id = ? AND quantity > 0", productID)
+	if locationID != nil {
+		query = query.Where("location_id = ?", *locationID)
+	}
+	if err := query.Order("expires_at ASC NULLS LAST, created_at ASC").Find(&lots).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch lots"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	today := models.LotDate(time.Now().In(storeLocation))
+	responses := make([]dto.StockLotResponse, 0, len(lots))
+	for _, lot := range lots {
+		responses = append(responses, dto.ConvertToStockLotResponse(lot, today))
+	}
+
+	return utils.Response(c, http.StatusOK, "Lots fetched successfully", responses, nil, nil)
+}
+
+// checkExpiredStock menolak item transaksi yang hanya bisa dipenuhi dari lot kedaluwarsa:
+// kuantitas yang diminta melebihi stok lokasi di luar lot yang sudah kedaluwarsa.
+// Produk tanpa lot kedaluwarsa tidak diperiksa di sini.
+func checkExpiredStock(tx *gorm.DB, locationID *uuid.UUID, items []models.TransactionItem) error {
+	if locationID == nil {
+		defaultID, err := defaultLocationID(tx)
+		if err != nil || defaultID == nil {
+			return err
+		}
+		locationID = defaultID
+	}
+
+	requested := make(map[dto.LocationStockKey]models.Quantity)
+	for _, item := range items {
+		if len(item.Components) > 0 {
+			for _, component := range item.Components {
+				requested[dto.LocationStockKey{ProductID: component.ProductID}] += component.Quantity
+			}
+			continue
+		}
+		key := dto.LocationStockKey{ProductID: item.ProductID}
+		if item.VariantID != nil {
+			key.VariantID = *item.VariantID
+		}
+		requested[key] += item.Quantity
+	}
+
+	today := models.LotDate(time.Now().In(storeLocation))
+	for key, quantity := range requested {
+		var variantID *uuid.UUID
+		if key.VariantID != uuid.Nil {
+			variantID = &key.VariantID
+		}
+
+		// SUM numeric dikembalikan driver sebagai string, Quantity yang mengurainya
+		var expired models.Quantity
+		err := lotQuery(tx.Model(&models.StockLot{}), *locationID, key.ProductID, variantID).
+			Where("quantity > 0 AND expires_at < ?", today).
+			Select("COALESCE(SUM(quantity), 0)").Scan(&expired).Error
+		if err != nil {
+			return err
+		}
+		if expired == 0 {
+			continue
+		}
+
+		levels, err := locationStockLevels(tx, *locationID, []uuid.UUID{key.ProductID})
+		if err != nil {
+			return err
+		}
+		if quantity > levels[key]-expired {
+			return fmt.Errorf("%w: produk dengan ID %v hanya tersisa %s yang belum kedaluwarsa",
+				errExpiredLot, key.ProductID, levels[key]-expired)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"aro-shop/dto"
+	"aro-shop/storage"
+	"aro-shop/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetMedia melayani file dari storage pada /media/<key>. URL ini yang disimpan di database
+// saat memakai S3 sehingga tetap berlaku di semua replika. Dengan S3_READ_MODE=presign
+// browser diarahkan ke URL presigned, selain itu isi file dialirkan lewat aplikasi.
+func GetMedia(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	key := c.Param("*")
+	if !storage.ValidKey(key) {
+		errorDetails["key"] = "Invalid file key"
+		return utils.Response(c, http.StatusBadRequest, "Client error", nil, nil, errorDetails)
+	}
+
+	if presigner, ok := storage.Files.(storage.Presigner); ok && cfg.S3ReadMode == "presign" {
+		expiry := time.Duration(cfg.S3PresignExpiryMin) * time.Minute
+		url, err := presigner.PresignGet(key, expiry)
+		if err != nil {
+			errorDetails["storage"] = "Failed to sign file URL"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+		// Redirect di-cache lebih singkat dari masa berlaku URL presigned
+		c.Response().Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(expiry.Seconds())/2))
+		return c.Redirect(http.StatusFound, url)
+	}
+
+	body, contentType, err := storage.Files.Get(c.Request().Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		errorDetails["key"] = "File not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+	if err != nil {
+		errorDetails["storage"] = "Failed to read file"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	defer body.Close()
+
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	// Nama file selalu unik (diawali UUID), jadi isinya tidak pernah berubah
+	c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	return c.Stream(http.StatusOK, contentType, body)
+}
@@ -2,12 +2,14 @@ package handler
 
 import (
 	"aro-shop/db"
+	"aro-shop/dto"
 	"aro-shop/models"
 	"aro-shop/utils"
 	"errors"
 	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
@@ -17,6 +19,11 @@ func GetNotifications(c echo.Context) error {
 	var notifications []models.Notification
 	var total int64
 
+	cursorParams, errorDetails := dto.ParseCursorParams(c.QueryParams())
+	if len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+
 	// Ambil query parameter page & limit dengan default
 	page, _ := strconv.Atoi(c.QueryParam("page"))
 	limit, _ := strconv.Atoi(c.QueryParam("limit"))
@@ -30,9 +37,33 @@ func GetNotifications(c echo.Context) error {
 
 	offset := (page - 1) * limit
 
-	// Hitung total notifikasi untuk pagination
-	if err := db.DB.Model(&models.Notification{}).Count(&total).Error; err != nil {
-		return utils.Response(c, http.StatusInternalServerError, "Failed to count notifications", nil, err, nil)
+	// Hitung total notifikasi hanya jika diminta
+	if cursorParams.IncludeTotal {
+		if err := db.DB.Model(&models.Notification{}).Count(&total).Error; err != nil {
+			return utils.Response(c, http.StatusInternalServerError, "Failed to count notifications", nil, err, nil)
+		}
+	}
+
+	if cursorParams.Enabled {
+		notifications, pagination, err := paginateByCursor(db.DB.Model(&models.Notification{}), "notifications", cursorParams.Cursor, limit, func(n models.Notification) (time.Time, uint) {
+			return n.CreatedAt, n.ID
+		})
+		if errors.Is(err, dto.ErrInvalidCursor) {
+			errorDetails["cursor"] = "Invalid cursor"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		if err != nil {
+			return utils.Response(c, http.StatusInternalServerError, "Failed to fetch notifications", nil, err, nil)
+		}
+		if cursorParams.IncludeTotal {
+			pagination.Total = &total
+		}
+
+		responseData := map[string]interface{}{
+			"notifications": notifications,
+			"pagination":    pagination,
+		}
+		return utils.Response(c, http.StatusOK, "Notifications retrieved successfully", responseData, nil, nil)
 	}
 
 	// Ambil data dengan pagination
@@ -41,14 +72,17 @@ func GetNotifications(c echo.Context) error {
 	}
 
 	// Struktur respons dengan pagination info
+	pagination := map[string]interface{}{
+		"page":  page,
+		"limit": limit,
+	}
+	if cursorParams.IncludeTotal {
+		pagination["total"] = total
+		pagination["totalPages"] = int(math.Ceil(float64(total) / float64(limit)))
+	}
 	responseData := map[string]interface{}{
 		"notifications": notifications,
-		"pagination": map[string]interface{}{
-			"page":       page,
-			"limit":      limit,
-			"total":      total,
-			"totalPages": int(math.Ceil(float64(total) / float64(limit))),
-		},
+		"pagination":    pagination,
 	}
 
 	return utils.Response(c, http.StatusOK, "Notifications retrieved successfully", responseData, nil, nil)
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"aro-shop/dto"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// paginateByCursor menjalankan query dengan keyset pagination, terbaru lebih dulu berdasarkan
+// (created_at, id). Satu baris ekstra diambil untuk mengetahui apakah masih ada halaman
+// berikutnya, jadi tidak perlu COUNT(*). key mengambil created_at dan id dari setiap baris.
+func paginateByCursor[T any, K any](query *gorm.DB, table string, cursor *dto.Cursor, limit int, key func(T) (time.Time, K)) ([]T, dto.CursorPagination, error) {
+	backward := cursor != nil && cursor.Prev
+
+	order := fmt.Sprintf("%[1]s.created_at DESC, %[1]s.id DESC", table)
+	if backward {
+		order = fmt.Sprintf("%[1]s.created_at ASC, %[1]s.id ASC", table)
+	}
+	if cursor != nil {
+		operator := "<"
+		if backward {
+			operator = ">"
+		}
+		var id K
+		if err := json.Unmarshal(cursor.ID, &id); err != nil {
+			return nil, dto.CursorPagination{}, dto.ErrInvalidCursor
+		}
+		query = query.Where(fmt.Sprintf("(%[1]s.created_at, %[1]s.id) %[2]s (?, ?)", table, operator), cursor.CreatedAt, id)
+	}
+
+	var rows []T
+	if err := query.Order(order).Limit(limit + 1).Find(&rows).Error; err != nil {
+		return nil, dto.CursorPagination{}, err
+	}
+
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	pagination := dto.CursorPagination{Limit: limit}
+	if len(rows) == 0 {
+		return rows, pagination, nil
+	}
+
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		createdAt, id := key(rows[0])
+		prev := dto.NewCursor(createdAt, id, true).Encode()
+		pagination.PrevCursor = &prev
+	}
+	if (!backward && hasMore) || backward {
+		createdAt, id := key(rows[len(rows)-1])
+		next := dto.NewCursor(createdAt, id, false).Encode()
+		pagination.NextCursor = &next
+	}
+
+	return rows, pagination, nil
+}
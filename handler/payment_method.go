@@ -14,7 +14,7 @@ import (
 
 func GetPaymentMethods(c echo.Context) error {
 	var methods []models.PaymentMethod
-	if err := db.DB.Find(&methods).Error; err != nil {
+	if err := withArchived(db.DB, includeArchived(c)).Find(&methods).Error; err != nil {
 		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch payment methods", nil, err, nil)
 	}
 
@@ -52,9 +52,12 @@ func CreatePaymentMethod(c echo.Context) error {
 		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errDetails)
 	}
 
-	// Cek apakah payment method sudah ada
+	// Cek apakah payment method sudah ada, termasuk yang diarsipkan karena nama harus unik
 	var existing models.PaymentMethod
-	if err := db.DB.Where("name = ?", input.Name).First(&existing).Error; err == nil {
+	if err := db.DB.Unscoped().Where("name = ?", input.Name).First(&existing).Error; err == nil {
+		if existing.DeletedAt.Valid {
+			return utils.Response(c, http.StatusBadRequest, "Payment method already exists but is archived, restore it instead", nil, nil, nil)
+		}
 		return utils.Response(c, http.StatusBadRequest, "Payment method already exists", nil, nil, nil)
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return utils.Response(c, http.StatusInternalServerError, "Database error", nil, err, nil)
@@ -108,10 +111,41 @@ func DeletePaymentMethod(c echo.Context) error {
 		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
 	}
 
-	// Hapus payment method
-	if err := db.DB.Delete(&models.PaymentMethod{}, "id = ?", uuidID).Error; err != nil {
-		return utils.Response(c, http.StatusInternalServerError, "Failed to delete payment method", nil, err, nil)
+	// Arsipkan payment method, pembayaran lama tetap merujuk ke data ini
+	result := db.DB.Delete(&models.PaymentMethod{}, "id = ?", uuidID)
+	if result.Error != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to delete payment method", nil, result.Error, nil)
+	}
+	if result.RowsAffected == 0 {
+		return utils.Response(c, http.StatusNotFound, "Payment method not found", nil, nil, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Payment method archived successfully", nil, nil, nil)
+}
+
+// RestorePaymentMethod mengembalikan payment method yang sudah diarsipkan
+func RestorePaymentMethod(c echo.Context) error {
+	id := c.Param("id")
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	result := db.DB.Unscoped().Model(&models.PaymentMethod{}).
+		Where("id = ? AND deleted_at IS NOT NULL", uuidID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to restore payment method", nil, result.Error, nil)
+	}
+	if result.RowsAffected == 0 {
+		return utils.Response(c, http.StatusNotFound, "Archived payment method not found", nil, nil, nil)
+	}
+
+	var method models.PaymentMethod
+	if err := db.DB.First(&method, "id = ?", uuidID).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to load payment method", nil, err, nil)
 	}
 
-	return utils.Response(c, http.StatusOK, "Payment method deleted successfully", nil, nil, nil)
+	return utils.Response(c, http.StatusOK, "Payment method restored successfully", method, nil, nil)
 }
@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	// Data zona waktu ikut di-embed agar Asia/Jakarta tetap tersedia di image tanpa tzdata
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const priceSchedulerInterval = time.Minute
+
+// storeLocation adalah zona waktu lokal toko untuk membaca dan menampilkan jadwal harga
+var storeLocation = loadStoreLocation(cfg.Timezone)
+
+func loadStoreLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("⚠️ Zona waktu %q tidak dikenal, memakai UTC: %v", name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// recordPriceChange mencatat riwayat harga, tidak melakukan apa pun jika harga tidak berubah
+func recordPriceChange(tx *gorm.DB, productID uuid.UUID, variantID *uuid.UUID, oldPrice, newPrice float64, source string, userID *uuid.UUID) error {
+	if oldPrice == newPrice {
+		return nil
+	}
+	return tx.Create(&models.ProductPriceHistory{
+		ProductID: productID,
+		VariantID: variantID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		Source:    source,
+		ChangedBy: userID,
+	}).Error
+}
+
+// GetProductPriceHistory menampilkan riwayat harga produk beserta variannya, terbaru lebih dulu.
+// Gunakan ?variant_id= untuk satu varian saja.
+func GetProductPriceHistory(c echo.Context) error {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails := dto.ErrorDetails{"id": "Invalid UUID format"}
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	cursorParams, errorDetails := dto.ParseCursorParams(c.QueryParams())
+	if len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+
+	if err := db.DB.Unscoped().Select("id").First(&models.Product{}, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	query := db.DB.Model(&models.ProductPriceHistory{}).Where("product_id = ?", productID)
+	if variantIDStr := c.QueryParam("variant_id"); variantIDStr != "" {
+		variantID, err := uuid.Parse(variantIDStr)
+		if err != nil {
+			errorDetails["variant_id"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		query = query.Where("variant_id = ?", variantID)
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var total int64
+	if cursorParams.IncludeTotal {
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			errorDetails["database"] = "Failed to count price history"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+	}
+
+	var (
+		history    []models.ProductPriceHistory
+		pagination interface{}
+	)
+	if cursorParams.Enabled {
+		var cursorPagination dto.CursorPagination
+		history, cursorPagination, err = paginateByCursor(query.Preload("User"), "product_price_histories", cursorParams.Cursor, limit, func(h models.ProductPriceHistory) (time.Time, uuid.UUID) {
+			return h.CreatedAt, h.ID
+		})
+		if errors.Is(err, dto.ErrInvalidCursor) {
+			errorDetails["cursor"] = "Invalid cursor"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		if cursorParams.IncludeTotal {
+			cursorPagination.Total = &total
+		}
+		pagination = cursorPagination
+	} else {
+		err = query.Preload("User").Order("created_at DESC, id DESC").Limit(limit).Offset((page - 1) * limit).Find(&history).Error
+		offsetPagination := map[string]interface{}{"page": page, "limit": limit}
+		if cursorParams.IncludeTotal {
+			offsetPagination["total"] = total
+		}
+		pagination = offsetPagination
+	}
+	if err != nil {
+		errorDetails["database"] = "Failed to fetch price history"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	historyResponses := make([]dto.PriceHistoryResponse, 0, len(history))
+	for _, entry := range history {
+		historyResponses = append(historyResponses, dto.ConvertToPriceHistoryResponse(entry))
+	}
+
+	responseData := map[string]interface{}{
+		"history":    historyResponses,
+		"pagination": pagination,
+	}
+	return utils.Response(c, http.StatusOK, "Price history fetched successfully", responseData, nil, nil)
+}
+
+// GetProductPriceSchedules menampilkan jadwal harga produk, default hanya yang masih pending
+func GetProductPriceSchedules(c echo.Context) error {
+	var (
+		schedules    []models.ScheduledPriceChange
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	query := db.DB.Where("product_id = ?", productID)
+	switch status := c.QueryParam("status"); status {
+	case "":
+		query = query.Where("status = ?", models.PriceSchedulePending)
+	case "all":
+	case models.PriceSchedulePending, models.PriceScheduleApplied, models.PriceScheduleCancelled, models.PriceScheduleFailed:
+		query = query.Where("status = ?", status)
+	default:
+		errorDetails["status"] = "Status must be one of pending, applied, cancelled, failed, all"
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+
+	if err := query.Order("effective_at ASC").Find(&schedules).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch price schedules"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	responses := make([]dto.ScheduledPriceResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		responses = append(responses, dto.ConvertToScheduledPriceResponse(schedule, storeLocation))
+	}
+	return utils.Response(c, http.StatusOK, "Price schedules fetched successfully", responses, nil, nil)
+}
+
+// CreateProductPriceSchedule menjadwalkan harga baru produk atau varian pada waktu tertentu
+func CreateProductPriceSchedule(c echo.Context) error {
+	var (
+		req          dto.ScheduledPriceRequest
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	effectiveAt, err := dto.ParseEffectiveAt(req.EffectiveAt, storeLocation)
+	if err != nil {
+		errorDetails["effective_at"] = err.Error()
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+	if !effectiveAt.After(time.Now()) {
+		errorDetails["effective_at"] = "Effective time must be in the future"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if err := db.DB.First(&product, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	if req.VariantID != nil {
+		if err := db.DB.Select("id").First(&models.ProductVariant{}, "id = ? AND product_id = ?", *req.VariantID, productID).Error; err != nil {
+			errorDetails["variant_id"] = "Variant not found"
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+		}
+	}
+
+	schedule := models.ScheduledPriceChange{
+		ProductID:   productID,
+		VariantID:   req.VariantID,
+		Price:       req.Price,
+		EffectiveAt: effectiveAt.UTC(),
+		Status:      models.PriceSchedulePending,
+		CreatedBy:   currentUserID(c),
+	}
+	if err := db.DB.Create(&schedule).Error; err != nil {
+		errorDetails["database"] = "Failed to create price schedule"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusCreated, "Price change scheduled successfully", dto.ConvertToScheduledPriceResponse(schedule, storeLocation), nil, nil)
+}
+
+// CancelProductPriceSchedule membatalkan jadwal harga yang belum diterapkan
+func CancelProductPriceSchedule(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+	scheduleID, err := uuid.Parse(c.Param("schedule_id"))
+	if err != nil {
+		errorDetails["schedule_id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	result := db.DB.Model(&models.ScheduledPriceChange{}).
+		Where("id = ? AND product_id = ? AND status = ?", scheduleID, productID, models.PriceSchedulePending).
+		Update("status", models.PriceScheduleCancelled)
+	if result.Error != nil {
+		errorDetails["database"] = "Failed to cancel price schedule"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, result.Error, errorDetails)
+	}
+	if result.RowsAffected == 0 {
+		errorDetails["schedule_id"] = "Pending price schedule not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Price schedule cancelled successfully", nil, nil, nil)
+}
+
+// StartPriceScheduler menerapkan jadwal harga yang sudah jatuh tempo setiap menit.
+// Dijalankan sebagai goroutine dari main.
+func StartPriceScheduler() {
+	log.Println("⏰ Scheduler harga berjalan...")
+
+	ticker := time.NewTicker(priceSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		applied, _, err := ApplyDuePriceSchedules(time.Now())
+		if err != nil {
+			log.Println("❌ Gagal menerapkan jadwal harga:", err)
+		} else if applied > 0 {
+			log.Printf("✅ %d jadwal harga diterapkan", applied)
+		}
+		<-ticker.C
+	}
+}
+
+// ApplyDuePriceSchedules menerapkan semua jadwal yang effective_at-nya sudah lewat, masing-masing
+// dalam transaksinya sendiri agar satu jadwal yang gagal tidak membatalkan jadwal lain. Jadwal yang
+// gagal ditandai failed beserta pesan kesalahannya sehingga tidak dicoba ulang setiap menit.
+func ApplyDuePriceSchedules(now time.Time) (applied, failed int, err error) {
+	var dueIDs []uuid.UUID
+	if err := db.DB.Model(&models.ScheduledPriceChange{}).
+		Where("status = ? AND effective_at <= ?", models.PriceSchedulePending, now).
+		Order("effective_at ASC").
+		Pluck("id", &dueIDs).Error; err != nil {
+		return 0, 0, err
+	}
+
+	for _, id := range dueIDs {
+		ok, applyErr := applyPriceSchedule(id, now)
+		if applyErr == nil {
+			if ok {
+				applied++
+			}
+			continue
+		}
+
+		log.Printf("❌ Jadwal harga %s gagal diterapkan: %v", id, applyErr)
+		if err := db.DB.Model(&models.ScheduledPriceChange{}).
+			Where("id = ? AND status = ?", id, models.PriceSchedulePending).
+			Updates(map[string]interface{}{
+				"status": models.PriceScheduleFailed,
+				"error":  applyErr.Error(),
+			}).Error; err != nil {
+			return applied, failed, err
+		}
+		failed++
+	}
+
+	if applied > 0 {
+		cache.ResetRedisCache(cachedDataProducts...)
+	}
+	return applied, failed, nil
+}
+
+// applyPriceSchedule menerapkan satu jadwal yang masih pending. FOR UPDATE SKIP LOCKED mencegah
+// jadwal yang sama diterapkan dua kali saat beberapa instance aplikasi berjalan bersamaan;
+// false berarti jadwal sudah diambil instance lain atau tidak lagi pending.
+func applyPriceSchedule(id uuid.UUID, now time.Time) (bool, error) {
+	applied := false
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var schedules []models.ScheduledPriceChange
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ? AND status = ?", id, models.PriceSchedulePending).
+			Limit(1).
+			Find(&schedules).Error; err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		schedule := schedules[0]
+
+		var product models.Product
+		if err := tx.Unscoped().First(&product, "id = ?", schedule.ProductID).Error; err != nil {
+			return err
+		}
+
+		oldPrice := product.Price
+		query := tx.Model(&models.Product{}).Where("id = ?", schedule.ProductID)
+		if schedule.VariantID != nil {
+			var variant models.ProductVariant
+			if err := tx.First(&variant, "id = ?", *schedule.VariantID).Error; err != nil {
+				return err
+			}
+			oldPrice = variant.EffectivePrice(product.Price)
+			query = tx.Model(&models.ProductVariant{}).Where("id = ?", *schedule.VariantID)
+		}
+
+		if err := query.Update("price", schedule.Price).Error; err != nil {
+			return err
+		}
+		if err := recordPriceChange(tx, schedule.ProductID, schedule.VariantID, oldPrice, schedule.Price, models.PriceSourceSchedule, schedule.CreatedBy); err != nil {
+			return err
+		}
+
+		appliedAt := now
+		if err := tx.Model(&schedule).Updates(map[string]interface{}{
+			"status":     models.PriceScheduleApplied,
+			"applied_at": &appliedAt,
+		}).Error; err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// productExportHeader diawali kolom import (name sampai image_url) sehingga export CSV tanpa
+// include_variants bisa diimpor kembali untuk produk yang punya SKU. Kolom sisanya hanya
+// informasi dan diabaikan saat import.
+var productExportHeader = []string{"name", "sku", "price", "stock", "category", "image_url", "barcode", "unit", "type", "parent_sku", "id"}
+
+// ExportProducts mengunduh katalog sebagai CSV (default) atau JSON dengan filter yang sama
+// seperti GET /products, tanpa pagination. Baris varian hanya disertakan dengan include_variants=true;
+// SKU varian tidak bisa diimpor sebagai produk, jadi file tersebut bukan untuk diimpor ulang.
+func ExportProducts(c echo.Context) error {
+	var products []models.Product
+
+	values := c.QueryParams()
+	// Export selalu mengambil seluruh data, parameter pagination diabaikan
+	for _, key := range []string{"page", "limit", "cursor", "include_total"} {
+		values.Del(key)
+	}
+	params, errorDetails := dto.ParseProductListQuery(values)
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		errorDetails["format"] = "Format must be csv or json"
+	}
+	if len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+	includeVariants := c.QueryParam("include_variants") == "true"
+
+	query := applyProductListFilters(preloadProductDetails(withArchived(db.DB, includeArchived(c))), params)
+	if params.Sort == "" {
+		query = query.Order("name ASC, id ASC")
+	}
+	if err := query.Find(&products).Error; err != nil {
+		errorDetails["database"] = "Failed to load products"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	// Kategori ditulis sebagai nama, path, atau slug yang dikenali import meski nama kategori
+	// dipakai di bawah beberapa induk
+	var categories []models.Category
+	if err := db.DB.Find(&categories).Error; err != nil {
+		errorDetails["database"] = "Failed to load categories"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	lookup := dto.NewCategoryLookup(categories)
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "json" {
+		productResponses := make([]dto.ProductResponse, 0, len(products))
+		for _, product := range products {
+			response := dto.ConvertToProductResponse(product)
+			if !includeVariants {
+				response.Variants = nil
+			}
+			productResponses = append(productResponses, response)
+		}
+		return c.JSON(http.StatusOK, productResponses)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(c.Response())
+	if err := writer.Write(productExportHeader); err != nil {
+		return err
+	}
+	for _, product := range products {
+		if err := writer.Write(productExportRecord(product, lookup)); err != nil {
+			return err
+		}
+		if !includeVariants {
+			continue
+		}
+		for _, variant := range product.Variants {
+			if err := writer.Write(variantExportRecord(product, variant, lookup)); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func productExportRecord(product models.Product, lookup dto.CategoryLookup) []string {
+	return []string{
+		product.Name,
+		product.SKU,
+		strconv.FormatFloat(product.Price, 'f', 2, 64),
+		product.Stock.String(),
+		exportCategory(product, lookup),
+		product.URLImage,
+		product.Barcode,
+		product.Unit,
+		product.Type,
+		"",
+		product.ID.String(),
+	}
+}
+
+func variantExportRecord(product models.Product, variant models.ProductVariant, lookup dto.CategoryLookup) []string {
+	return []string{
+		fmt.Sprintf("%s (%s)", product.Name, variant.Name()),
+		variant.SKU,
+		strconv.FormatFloat(variant.EffectivePrice(product.Price), 'f', 2, 64),
+		variant.Stock.String(),
+		exportCategory(product, lookup),
+		product.URLImage,
+		variant.Barcode,
+		product.Unit,
+		"variant",
+		product.SKU,
+		variant.ID.String(),
+	}
+}
+
+// exportCategory memakai nama kategori apa adanya bila kategorinya sudah diarsipkan
+func exportCategory(product models.Product, lookup dto.CategoryLookup) string {
+	if reference := lookup.Reference(product.CategoryID); reference != "" {
+		return reference
+	}
+	return product.Category.Name
+}
@@ -7,17 +7,17 @@ import (
 	"aro-shop/models"
 	"aro-shop/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 var (
@@ -25,64 +25,153 @@ var (
 	cachedDataProducts = []string{
 		"products_list:*",
 		"product:*",
-		"categories_with_products",
+		"categories_with_products*",
 	}
 )
 
 func GetProducts(c echo.Context) error {
 	var (
-		products     []models.Product
-		errorDetails = make(dto.ErrorDetails)
-		category     = c.QueryParam("category")
-		search       = c.QueryParam("search")
-		page, _      = strconv.Atoi(c.QueryParam("page"))
-		limit, _     = strconv.Atoi(c.QueryParam("limit"))
-		cacheKey     = fmt.Sprintf("products_list:%s:%s:%d:%d", category, search, page, limit)
+		products []models.Product
+		archived = includeArchived(c)
 	)
 
-	if page < 1 {
-		page = 1
+	params, errorDetails := dto.ParseProductListQuery(c.QueryParams())
+	if len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
 	}
-	if limit < 1 {
-		limit = 10
+	cacheKey := params.CacheKey()
+
+	locationID, status, locationErrors, err := queryLocationID(c)
+	if err != nil {
+		return utils.Response(c, status, "Invalid query parameters", nil, err, locationErrors)
 	}
-	offset := (page - 1) * limit
 
-	// Cek apakah data ada di Redis
-	cachedData, err := cache.GetCache(cacheKey)
-	if err == nil {
-		var cachedProducts []dto.ProductResponse
-		json.Unmarshal([]byte(cachedData), &cachedProducts)
-		return utils.Response(c, http.StatusOK, "Products fetched from cache", cachedProducts, nil, nil)
+	// Cek apakah data ada di Redis, listing dengan produk arsip atau stok lokal tidak di-cache
+	cached := !archived && locationID == nil
+	if cached {
+		cachedData, err := cache.GetCache(cacheKey)
+		if err == nil && json.Valid([]byte(cachedData)) {
+			return utils.Response(c, http.StatusOK, "Products fetched from cache", json.RawMessage(cachedData), nil, nil)
+		}
 	}
 
 	// Jika tidak ada di Redis, ambil dari database
-	query := db.DB.Preload("Category")
-	if category != "" {
-		query = query.Where("category_id = ?", category)
-	}
-	if search != "" {
-		query = query.Where("name LIKE ?", "%"+search+"%")
-	}
+	query := applyProductListFilters(preloadProductDetails(withArchived(db.DB, archived)), params)
 
-	if err := query.Limit(limit).Offset(offset).Find(&products).Error; err != nil {
-		errorDetails["database"] = "Gagal mengambil data produk dari database"
-		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	var pagination dto.CursorPagination
+	if params.Enabled {
+		var err error
+		products, pagination, err = paginateByCursor(query, "products", params.Cursor, params.Limit, func(p models.Product) (time.Time, uuid.UUID) {
+			return p.CreatedAt, p.ID
+		})
+		if errors.Is(err, dto.ErrInvalidCursor) {
+			errorDetails["cursor"] = "Invalid cursor"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		if err != nil {
+			errorDetails["database"] = "Gagal mengambil data produk dari database"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+	} else {
+		offset := (params.Page - 1) * params.Limit
+		if err := query.Limit(params.Limit).Offset(offset).Find(&products).Error; err != nil {
+			errorDetails["database"] = "Gagal mengambil data produk dari database"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
 	}
 
 	// Konversi ke format response yang diinginkan
 	var productResponses []dto.ProductResponse
 	if len(products) > 0 {
-		for _, product := range products {
-			productResponses = append(productResponses, dto.ConvertToProductResponse(product))
+		if productResponses, err = productResponsesAt(products, locationID); err != nil {
+			errorDetails["database"] = "Failed to load location stock"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+	}
+
+	// Tanpa cursor dan include_total, response tetap berupa array seperti sebelumnya
+	var data interface{} = productResponses
+	if params.Enabled || params.IncludeTotal {
+		if params.IncludeTotal {
+			var total int64
+			countQuery := applyProductListFilters(withArchived(db.DB, archived).Model(&models.Product{}), params)
+			if err := countQuery.Count(&total).Error; err != nil {
+				errorDetails["database"] = "Failed to count products"
+				return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+			}
+			pagination.Total = &total
+		}
+		pagination.Limit = params.Limit
+
+		response := map[string]interface{}{
+			"products":   productResponses,
+			"pagination": pagination,
 		}
+		if !params.Enabled {
+			response["pagination"] = map[string]interface{}{
+				"page":        params.Page,
+				"limit":       params.Limit,
+				"total":       pagination.Total,
+				"total_pages": int(math.Ceil(float64(*pagination.Total) / float64(params.Limit))),
+			}
+		}
+		data = response
 	}
 
 	// Simpan hasil query ke Redis untuk cache selama 10 menit
-	jsonData, _ := json.Marshal(productResponses)
-	cache.SetCache(cacheKey, string(jsonData), 10*time.Minute)
+	if cached {
+		jsonData, _ := json.Marshal(data)
+		cache.SetCache(cacheKey, string(jsonData), 10*time.Minute)
+	}
 
-	return utils.Response(c, http.StatusOK, "Products fetched successfully", productResponses, nil, nil)
+	return utils.Response(c, http.StatusOK, "Products fetched successfully", data, nil, nil)
+}
+
+// inStockSQL: produk simple dengan stok, produk varian dengan minimal satu varian berstok,
+// atau bundle yang semua komponennya cukup untuk minimal satu paket
+const inStockSQL = `(
+	(products.type <> 'bundle' AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id) AND products.stock > 0)
+	OR EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.stock > 0)
+	OR (products.type = 'bundle' AND EXISTS (SELECT 1 FROM bundle_components bc WHERE bc.bundle_id = products.id)
+		AND NOT EXISTS (SELECT 1 FROM bundle_components bc JOIN products comp ON comp.id = bc.component_id
+			WHERE bc.bundle_id = products.id AND comp.stock < bc.quantity))
+)`
+
+// lowStockSQL: stok produk (atau salah satu variannya) berada di bawah ambang batas
+const lowStockSQL = `(
+	(products.type <> 'bundle' AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id) AND products.stock <= ?)
+	OR EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.stock <= ?)
+)`
+
+func applyProductListFilters(query *gorm.DB, params dto.ProductListQuery) *gorm.DB {
+	// Filter kategori ikut menyertakan seluruh sub kategori
+	if len(params.CategoryIDs) > 0 {
+		query = query.Where("category_id IN ("+categoryTreeSQL+")", params.CategoryIDs)
+	}
+	// Pencarian tidak peka huruf besar/kecil, sama seperti cache key-nya
+	if params.Search != "" {
+		query = query.Where("name ILIKE ?", "%"+params.Search+"%")
+	}
+	if params.MinPrice != nil {
+		query = query.Where("price >= ?", *params.MinPrice)
+	}
+	if params.MaxPrice != nil {
+		query = query.Where("price <= ?", *params.MaxPrice)
+	}
+	if params.InStock {
+		query = query.Where(inStockSQL)
+	}
+	if params.LowStock {
+		threshold := models.NewQuantity(cfg.LowStockThreshold)
+		query = query.Where(lowStockSQL, threshold, threshold)
+	}
+	if params.UpdatedSince != nil {
+		query = query.Where("updated_at >= ?", *params.UpdatedSince)
+	}
+	if orderBy, ok := dto.ProductSortFields[params.Sort]; ok {
+		query = query.Order(orderBy)
+	}
+	return query
 }
 
 func GetProductByID(c echo.Context) error {
@@ -90,15 +179,24 @@ func GetProductByID(c echo.Context) error {
 		id           = c.Param("id")
 		product      models.Product
 		cacheKey     = fmt.Sprintf("product:%s", id)
+		archived     = includeArchived(c)
 		errorDetails = make(dto.ErrorDetails)
 	)
 
-	// Cek apakah data ada di Redis
-	cachedData, err := cache.GetCache(cacheKey)
-	if err == nil {
-		var cachedProduct dto.ProductResponse
-		if json.Unmarshal([]byte(cachedData), &cachedProduct) == nil {
-			return utils.Response(c, http.StatusOK, "Product fetched from cache", cachedProduct, nil, nil)
+	locationID, status, locationErrors, err := queryLocationID(c)
+	if err != nil {
+		return utils.Response(c, status, "Invalid query parameters", nil, err, locationErrors)
+	}
+
+	// Cek apakah data ada di Redis, stok lokal tidak di-cache
+	cached := !archived && locationID == nil
+	if cached {
+		cachedData, err := cache.GetCache(cacheKey)
+		if err == nil {
+			var cachedProduct dto.ProductResponse
+			if json.Unmarshal([]byte(cachedData), &cachedProduct) == nil {
+				return utils.Response(c, http.StatusOK, "Product fetched from cache", cachedProduct, nil, nil)
+			}
 		}
 	}
 
@@ -110,17 +208,24 @@ func GetProductByID(c echo.Context) error {
 	}
 
 	// Jika tidak ada di Redis, ambil dari database
-	if err := db.DB.Preload("Category").First(&product, "id = ?", uuidID).Error; err != nil {
+	if err := preloadProductDetails(withArchived(db.DB, archived)).First(&product, "id = ?", uuidID).Error; err != nil {
 		errorDetails["id"] = "Product not found"
 		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
 	}
 
 	// Konversi ke format response yang diinginkan
-	productResponse := dto.ConvertToProductResponse(product)
+	productResponses, err := productResponsesAt([]models.Product{product}, locationID)
+	if err != nil {
+		errorDetails["database"] = "Failed to load location stock"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	productResponse := productResponses[0]
 
 	// Simpan hasil query ke Redis untuk cache selama 10 menit
-	jsonData, _ := json.Marshal(productResponse)
-	cache.SetCache(cacheKey, string(jsonData), 10*time.Minute)
+	if cached {
+		jsonData, _ := json.Marshal(productResponse)
+		cache.SetCache(cacheKey, string(jsonData), 10*time.Minute)
+	}
 
 	return utils.Response(c, http.StatusOK, "Product fetched successfully", productResponse, nil, nil)
 }
@@ -142,6 +247,10 @@ func GetCategoriesWithProducts(c echo.Context) error {
 		limit = 5 // Default 5 produk per kategori
 	}
 
+	if c.QueryParam("tree") == "true" {
+		return getCategoryTreeWithProducts(c, page, limit)
+	}
+
 	// Cek apakah data ada di Redis
 	cachedData, err := cache.GetCache(cacheKey)
 	if err == nil && cachedData != "" {
@@ -152,7 +261,7 @@ func GetCategoriesWithProducts(c echo.Context) error {
 	}
 
 	// Ambil semua produk dari database
-	if err := db.DB.Preload("Category").Find(&products).Error; err != nil {
+	if err := preloadProductDetails(db.DB).Find(&products).Error; err != nil {
 		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch products", nil, err, nil)
 	}
 
@@ -201,6 +310,59 @@ func GetCategoriesWithProducts(c echo.Context) error {
 	return utils.Response(c, http.StatusOK, "Categories fetched successfully", response, nil, nil)
 }
 
+// getCategoryTreeWithProducts mengembalikan pohon kategori, setiap node berisi produk
+// langsung di kategori tersebut dengan pagination yang sama untuk semua kategori
+func getCategoryTreeWithProducts(c echo.Context, page, limit int) error {
+	cacheKey := fmt.Sprintf("categories_with_products:tree:%d:%d", page, limit)
+
+	cachedData, err := cache.GetCache(cacheKey)
+	if err == nil && cachedData != "" {
+		var cachedTree []*dto.CategoryTreeNode
+		if err := json.Unmarshal([]byte(cachedData), &cachedTree); err == nil {
+			return utils.Response(c, http.StatusOK, "Categories fetched from cache", cachedTree, nil, nil)
+		}
+	}
+
+	var (
+		categories []models.Category
+		products   []models.Product
+	)
+	if err := db.DB.Find(&categories).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch categories", nil, err, nil)
+	}
+	if err := preloadProductDetails(db.DB).Find(&products).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch products", nil, err, nil)
+	}
+
+	tree, nodes := dto.BuildCategoryTree(categories)
+
+	productsByCategory := make(map[uuid.UUID][]dto.ProductResponse)
+	for _, product := range products {
+		productsByCategory[product.CategoryID] = append(productsByCategory[product.CategoryID], dto.ConvertToProductResponse(product))
+	}
+
+	offset := (page - 1) * limit
+	for categoryID, node := range nodes {
+		productList := productsByCategory[categoryID]
+		start, end := offset, offset+limit
+		if start > len(productList) {
+			start = len(productList)
+		}
+		if end > len(productList) {
+			end = len(productList)
+		}
+		node.Products = productList[start:end]
+		node.TotalProducts = len(productList)
+	}
+
+	jsonData, err := json.Marshal(tree)
+	if err == nil {
+		cache.SetCache(cacheKey, string(jsonData), 10*time.Minute)
+	}
+
+	return utils.Response(c, http.StatusOK, "Categories fetched successfully", tree, nil, nil)
+}
+
 func CreateProduct(c echo.Context) error {
 	var (
 		category     models.Category
@@ -212,8 +374,22 @@ func CreateProduct(c echo.Context) error {
 	// Bind form field ke struct (bukan untuk file)
 	req.Name = c.FormValue("name")
 	req.Description = c.FormValue("description")
+	req.SKU = c.FormValue("sku")
+	req.Barcode = c.FormValue("barcode")
 	req.Price, _ = strconv.ParseFloat(c.FormValue("price"), 64)
-	req.Stock, _ = strconv.Atoi(c.FormValue("stock"))
+	req.Stock, _ = models.ParseQuantity(c.FormValue("stock"))
+	req.Unit = c.FormValue("unit")
+	req.Precision, _ = strconv.Atoi(c.FormValue("precision"))
+	req.PLU = c.FormValue("plu")
+	req.TrackLots, _ = strconv.ParseBool(c.FormValue("track_lots"))
+	req.ReorderPoint, _ = models.ParseQuantity(c.FormValue("reorder_point"))
+	req.ReorderQty, _ = models.ParseQuantity(c.FormValue("reorder_qty"))
+	if req.Unit == "" {
+		req.Unit = models.UnitPcs
+		req.Precision = 0
+	} else if c.FormValue("precision") == "" {
+		req.Precision = models.DefaultPrecision(req.Unit)
+	}
 	req.CategoryID, _ = uuid.Parse(c.FormValue("category_id"))
 
 	// Ambil file dari form-data
@@ -223,28 +399,6 @@ func CreateProduct(c echo.Context) error {
 		return utils.Response(c, http.StatusBadRequest, "Image is required", nil, err, errorDetails)
 	}
 
-	src, err := file.Open()
-	if err != nil {
-		return utils.Response(c, http.StatusInternalServerError, "Failed to open image", nil, err, nil)
-	}
-	defer src.Close()
-
-	// Simpan file di folder local
-	filename := fmt.Sprintf("public/uploads/%s-%s", uuid.New().String(), file.Filename)
-	dst, err := os.Create(filename)
-	if err != nil {
-		return utils.Response(c, http.StatusInternalServerError, "Failed to save image", nil, err, nil)
-	}
-	defer dst.Close()
-
-	if _, err = io.Copy(dst, src); err != nil {
-		return utils.Response(c, http.StatusInternalServerError, "Failed to copy image", nil, err, nil)
-	}
-
-	// Misal URL-nya dari server lokal
-	baseURL := cfg.BaseURL
-	imageURL := baseURL + "public/uploads/" + filepath.Base(filename)
-
 	// Validasi manual jika perlu
 	if err := validate.Struct(req); err != nil {
 		errorDetails := make(map[string]string)
@@ -258,12 +412,19 @@ func CreateProduct(c echo.Context) error {
 
 	// Buat product
 	product = models.Product{
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Stock:       req.Stock,
-		URLImage:    imageURL,
-		CategoryID:  req.CategoryID,
+		Name:         req.Name,
+		Description:  req.Description,
+		SKU:          req.SKU,
+		Barcode:      req.Barcode,
+		Price:        req.Price,
+		Stock:        req.Stock,
+		Unit:         req.Unit,
+		Precision:    req.Precision,
+		PLU:          req.PLU,
+		TrackLots:    req.TrackLots,
+		ReorderPoint: req.ReorderPoint,
+		ReorderQty:   req.ReorderQty,
+		CategoryID:   req.CategoryID,
 	}
 
 	// Cek kategori
@@ -272,8 +433,47 @@ func CreateProduct(c echo.Context) error {
 		return utils.Response(c, http.StatusBadRequest, "Invalid category ID", nil, err, errorDetails)
 	}
 
-	// Simpan ke DB
-	if err := db.DB.Create(&product).Error; err != nil {
+	if !product.Stock.FitsPrecision(product.Precision) {
+		errorDetails["stock"] = fmt.Sprintf("Stock allows at most %d decimal places", product.Precision)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+	if !product.ReorderPoint.FitsPrecision(product.Precision) || !product.ReorderQty.FitsPrecision(product.Precision) {
+		errorDetails["reorder_point"] = fmt.Sprintf("Reorder point and quantity allow at most %d decimal places", product.Precision)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	// SKU harus unik
+	if product.SKU != "" && skuExists(product.SKU, uuid.Nil) {
+		errorDetails["sku"] = "SKU already used by another product"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	// Gambar baru disimpan setelah semua validasi lolos agar tidak ada file yatim
+	images, err := saveProductImage(c.Request().Context(), file)
+	if err != nil {
+		errorDetails["image"] = err.Error()
+		if isImageValidationError(err) {
+			return utils.Response(c, http.StatusBadRequest, "Invalid image", nil, err, errorDetails)
+		}
+		return utils.Response(c, http.StatusInternalServerError, "Failed to save image", nil, err, errorDetails)
+	}
+	images.apply(&product)
+
+	// Simpan ke DB, stok awal dicatat di ledger stok
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		return recordStockMovement(tx, models.StockMovement{
+			ProductID: product.ID,
+			Delta:     product.Stock,
+			Reason:    models.StockReasonAdjustment,
+			Note:      "Stok awal",
+			UserID:    currentUserID(c),
+		})
+	})
+	if err != nil {
+		removeProductImageFiles(productImageURLs(product)...)
 		errorDetails["database"] = err.Error()
 		return utils.Response(c, http.StatusInternalServerError, "Failed to create product", nil, err, errorDetails)
 	}
@@ -309,6 +509,8 @@ func UpdateProduct(c echo.Context) error {
 		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
 	}
 
+	oldPrice := product.Price
+
 	// Ambil dan cek form value satu per satu
 	if name := c.FormValue("name"); name != "" {
 		product.Name = name
@@ -318,6 +520,58 @@ func UpdateProduct(c echo.Context) error {
 		product.Description = description
 	}
 
+	if sku := c.FormValue("sku"); sku != "" {
+		if len(sku) > 64 || skuExists(sku, product.ID) {
+			errorDetails["sku"] = "SKU is too long or already used by another product"
+		} else {
+			product.SKU = sku
+		}
+	}
+
+	if barcode := c.FormValue("barcode"); barcode != "" {
+		if len(barcode) > 64 {
+			errorDetails["barcode"] = "Barcode is too long"
+		} else {
+			product.Barcode = barcode
+		}
+	}
+
+	if unit := c.FormValue("unit"); unit != "" {
+		if !models.IsValidUnit(unit) {
+			errorDetails["unit"] = "Unit must be one of pcs, kg, g, l, m"
+		} else {
+			product.Unit = unit
+			product.Precision = models.DefaultPrecision(unit)
+		}
+	}
+
+	if precisionStr := c.FormValue("precision"); precisionStr != "" {
+		precision, err := strconv.Atoi(precisionStr)
+		if err != nil || precision < 0 || precision > models.QuantityScale {
+			errorDetails["precision"] = "Precision must be between 0 and 3"
+		} else {
+			product.Precision = precision
+		}
+	}
+
+	// Aturan PLU sama dengan dto.ProductRequest saat membuat produk
+	if plu := c.FormValue("plu"); plu != "" {
+		if err := validate.Var(plu, "len=5,number"); err != nil {
+			errorDetails["plu"] = "PLU must be 5 digits"
+		} else {
+			product.PLU = plu
+		}
+	}
+
+	if trackLotsStr := c.FormValue("track_lots"); trackLotsStr != "" {
+		trackLots, err := strconv.ParseBool(trackLotsStr)
+		if err != nil {
+			errorDetails["track_lots"] = "track_lots must be true or false"
+		} else {
+			product.TrackLots = trackLots
+		}
+	}
+
 	if priceStr := c.FormValue("price"); priceStr != "" {
 		price, err := strconv.ParseFloat(priceStr, 64)
 		if err != nil || price <= 0 {
@@ -327,12 +581,31 @@ func UpdateProduct(c echo.Context) error {
 		}
 	}
 
+	// Stok read-only setelah produk dibuat, perubahan stok harus lewat penyesuaian stok
+	// agar tercatat dengan alasan. Nilai yang sama dengan stok sekarang tetap diterima.
 	if stockStr := c.FormValue("stock"); stockStr != "" {
-		stock, err := strconv.Atoi(stockStr)
-		if err != nil || stock < 0 {
-			errorDetails["stock"] = "Invalid stock"
-		} else {
-			product.Stock = stock
+		if stock, err := models.ParseQuantity(stockStr); err != nil || stock != product.Stock {
+			errorDetails["stock"] = "Stock is read-only, use POST /api/product/:id/stock-adjustments"
+		}
+	}
+
+	if !product.Stock.FitsPrecision(product.Precision) {
+		errorDetails["stock"] = fmt.Sprintf("Stock allows at most %d decimal places", product.Precision)
+	}
+
+	for field, target := range map[string]*models.Quantity{"reorder_point": &product.ReorderPoint, "reorder_qty": &product.ReorderQty} {
+		value := c.FormValue(field)
+		if value == "" {
+			continue
+		}
+		quantity, err := models.ParseQuantity(value)
+		switch {
+		case err != nil || quantity < 0:
+			errorDetails[field] = field + " must be a non-negative number"
+		case !quantity.FitsPrecision(product.Precision):
+			errorDetails[field] = fmt.Sprintf("%s allows at most %d decimal places", field, product.Precision)
+		default:
+			*target = quantity
 		}
 	}
 
@@ -354,40 +627,38 @@ func UpdateProduct(c echo.Context) error {
 		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
 	}
 
-	// Upload file jika ada
-	file, err := c.FormFile("url_image")
-	if err == nil {
-		src, err := file.Open()
+	// Upload file jika ada, gambar lama baru dihapus setelah perubahan tersimpan
+	var oldImages []string
+	if file, err := c.FormFile("url_image"); err == nil {
+		images, err := saveProductImage(c.Request().Context(), file)
 		if err != nil {
-			errorDetails["file"] = "Failed to open file"
+			errorDetails["file"] = err.Error()
+			if isImageValidationError(err) {
+				return utils.Response(c, http.StatusBadRequest, "Invalid image", nil, err, errorDetails)
+			}
 			return utils.Response(c, http.StatusInternalServerError, "File error", nil, err, errorDetails)
 		}
-		defer src.Close()
+		oldImages = productImageURLs(product)
+		images.apply(&product)
+	}
 
-		filename := uuid.New().String() + filepath.Ext(file.Filename)
-		filepath := "uploads/" + filename
-		dst, err := os.Create(filepath)
-		if err != nil {
-			errorDetails["file"] = "Failed to save file"
-			return utils.Response(c, http.StatusInternalServerError, "File error", nil, err, errorDetails)
+	// Simpan perubahan beserta riwayat harga jika harga berubah
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("stock").Save(&product).Error; err != nil {
+			return err
 		}
-		defer dst.Close()
-
-		if _, err := io.Copy(dst, src); err != nil {
-			errorDetails["file"] = "Failed to copy file"
-			return utils.Response(c, http.StatusInternalServerError, "File error", nil, err, errorDetails)
+		return recordPriceChange(tx, product.ID, nil, oldPrice, product.Price, models.PriceSourceManual, currentUserID(c))
+	})
+	if err != nil {
+		if oldImages != nil {
+			removeProductImageFiles(productImageURLs(product)...)
 		}
-
-		product.URLImage = c.Scheme() + "://" + c.Request().Host + "/uploads/" + filename
-	}
-
-	// Simpan perubahan
-	if err := db.DB.Save(&product).Error; err != nil {
 		errorDetails["database"] = "Failed to update product"
 		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
 	}
+	removeProductImageFiles(oldImages...)
 
-	if err := db.DB.Preload("Category").First(&product, product.ID).Error; err != nil {
+	if err := preloadProductDetails(db.DB).First(&product, product.ID).Error; err != nil {
 		errorDetails["database"] = err.Error()
 		return utils.Response(c, http.StatusInternalServerError, "Failed to load product with category", nil, err, errorDetails)
 	}
@@ -411,14 +682,78 @@ func DeleteProduct(c echo.Context) error {
 		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetail)
 	}
 
-	// mencari data di database
-	if err := db.DB.Delete(&models.Product{}, "id = ?", uuidID).Error; err != nil {
+	// soft delete: produk diarsipkan agar riwayat transaksi tetap bisa dimuat
+	result := db.DB.Delete(&models.Product{}, "id = ?", uuidID)
+	if result.Error != nil {
 		errorDetail["database"] = "Failed to delete product"
-		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetail)
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, result.Error, errorDetail)
+	}
+	if result.RowsAffected == 0 {
+		errorDetail["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetail)
 	}
 
 	// reset redis agar terjadi konsistensi data
 	go cache.ResetRedisCache(cachedDataProducts...)
 
-	return utils.Response(c, http.StatusOK, "Product deleted successfully", nil, nil, nil)
+	return utils.Response(c, http.StatusOK, "Product archived successfully", nil, nil, nil)
+}
+
+// RestoreProduct mengembalikan produk yang sudah diarsipkan
+func RestoreProduct(c echo.Context) error {
+	var (
+		product     models.Product
+		errorDetail = make(dto.ErrorDetails)
+	)
+
+	uuidID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetail["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetail)
+	}
+
+	if err := db.DB.Unscoped().First(&product, "id = ? AND deleted_at IS NOT NULL", uuidID).Error; err != nil {
+		errorDetail["id"] = "Archived product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetail)
+	}
+
+	// Kategori yang masih diarsipkan harus dipulihkan terlebih dahulu
+	var category models.Category
+	if err := db.DB.First(&category, "id = ?", product.CategoryID).Error; err != nil {
+		errorDetail["category_id"] = "Category is archived, restore the category first"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetail)
+	}
+
+	if err := db.DB.Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+		errorDetail["database"] = "Failed to restore product"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetail)
+	}
+
+	if err := preloadProductDetails(db.DB).First(&product, "id = ?", product.ID).Error; err != nil {
+		errorDetail["database"] = err.Error()
+		return utils.Response(c, http.StatusInternalServerError, "Failed to load product", nil, err, errorDetail)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Product restored successfully", dto.ConvertToProductResponse(product), nil, nil)
+}
+
+// preloadProductDetails memuat kategori, opsi, varian beserta nilai opsinya, grup modifier, dan komponen bundle
+func preloadProductDetails(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Category", unscoped).
+		Preload("OptionTypes.Values").
+		Preload("Variants.OptionValues.OptionType").
+		Preload("ModifierGroups.Modifiers").
+		Preload("BundleComponents.Component")
+}
+
+// skuExists mengecek apakah SKU sudah dipakai produk atau varian lain,
+// termasuk produk yang diarsipkan agar SKU tidak bentrok saat dipulihkan
+func skuExists(sku string, excludeID uuid.UUID) bool {
+	var productCount, variantCount int64
+	db.DB.Unscoped().Model(&models.Product{}).Where("sku = ? AND id <> ?", sku, excludeID).Count(&productCount)
+	db.DB.Model(&models.ProductVariant{}).Where("sku = ? AND id <> ?", sku, excludeID).Count(&variantCount)
+	return productCount+variantCount > 0
 }
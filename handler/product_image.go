@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/storage"
+	"aro-shop/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Ukuran sisi terpanjang untuk versi gambar yang lebih kecil
+	productImageMediumSize = 800
+	productImageThumbSize  = 200
+	// Batas piksel untuk mencegah decompression bomb (file kecil dengan dimensi raksasa)
+	productImageMaxPixels = 40_000_000
+)
+
+// productImageSet adalah URL gambar yang sudah disimpan untuk satu produk
+type productImageSet struct {
+	Original  string
+	Medium    string
+	Thumbnail string
+}
+
+// apply mengisi kolom gambar pada produk
+func (s productImageSet) apply(product *models.Product) {
+	product.URLImage = s.Original
+	product.ImageMediumURL = s.Medium
+	product.ImageThumbURL = s.Thumbnail
+}
+
+// isImageValidationError menandakan kesalahan dari isi file kiriman klien (400),
+// bukan kegagalan server
+func isImageValidationError(err error) bool {
+	return errors.Is(err, utils.ErrUnsupportedImage) || errors.Is(err, utils.ErrImageTooLarge) || errors.Is(err, utils.ErrImageDimensions)
+}
+
+// saveProductImage memvalidasi isi file upload lalu menyimpan versi asli, medium, dan
+// thumbnail. WebP disimpan apa adanya karena library standar Go tidak bisa meng-encode WebP.
+func saveProductImage(ctx context.Context, fileHeader *multipart.FileHeader) (productImageSet, error) {
+	var images productImageSet
+
+	maxSize := int64(cfg.MaxUploadSizeMB) << 20
+	if fileHeader.Size > maxSize {
+		return images, fmt.Errorf("%w (maksimal %d MB)", utils.ErrImageTooLarge, cfg.MaxUploadSizeMB)
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return images, err
+	}
+	defer src.Close()
+
+	// Ukuran dari header bisa dipalsukan, jadi pembacaan tetap dibatasi
+	data, err := io.ReadAll(io.LimitReader(src, maxSize+1))
+	if err != nil {
+		return images, err
+	}
+	if int64(len(data)) > maxSize {
+		return images, fmt.Errorf("%w (maksimal %d MB)", utils.ErrImageTooLarge, cfg.MaxUploadSizeMB)
+	}
+
+	contentType, ext, err := utils.DetectImageType(data)
+	if err != nil {
+		return images, err
+	}
+
+	baseName := uuid.New().String() + "-" + utils.SanitizeFilename(fileHeader.Filename)
+	if err := storage.Files.Put(ctx, baseName+ext, data, contentType); err != nil {
+		return images, err
+	}
+	images.Original = storage.Files.URL(baseName + ext)
+
+	if contentType == "image/webp" {
+		return images, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		removeProductImageFiles(images.Original)
+		return images, utils.ErrUnsupportedImage
+	}
+	if config.Width*config.Height > productImageMaxPixels {
+		removeProductImageFiles(images.Original)
+		return images, utils.ErrImageDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		removeProductImageFiles(images.Original)
+		return images, utils.ErrUnsupportedImage
+	}
+
+	sizes := []struct {
+		suffix string
+		size   int
+		url    *string
+	}{
+		{"medium", productImageMediumSize, &images.Medium},
+		{"thumb", productImageThumbSize, &images.Thumbnail},
+	}
+	for _, size := range sizes {
+		var buf bytes.Buffer
+		resized := utils.ResizeToFit(img, size.size)
+		// PNG tetap PNG agar transparansi tidak hilang
+		if contentType == "image/png" {
+			err = png.Encode(&buf, resized)
+		} else {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
+		}
+		if err == nil {
+			filename := fmt.Sprintf("%s-%s%s", baseName, size.suffix, ext)
+			if err = storage.Files.Put(ctx, filename, buf.Bytes(), contentType); err == nil {
+				*size.url = storage.Files.URL(filename)
+			}
+		}
+		if err != nil {
+			removeProductImageFiles(images.Original, images.Medium, images.Thumbnail)
+			return productImageSet{}, err
+		}
+	}
+
+	return images, nil
+}
+
+// removeProductImageFiles menghapus file gambar dari storage. URL yang bukan milik storage
+// aktif (misalnya URL eksternal dari import CSV) dilewati.
+func removeProductImageFiles(urls ...string) {
+	for _, url := range urls {
+		key, ok := storage.Files.KeyFromURL(url)
+		if !ok {
+			continue
+		}
+		if err := storage.Files.Delete(context.Background(), key); err != nil {
+			log.Println("⚠️ Gagal menghapus file gambar:", key, err)
+		}
+	}
+}
+
+// productImageURLs mengembalikan semua URL gambar produk yang mungkin perlu dibersihkan
+func productImageURLs(product models.Product) []string {
+	return []string{product.URLImage, product.ImageMediumURL, product.ImageThumbURL}
+}
+
+// DeleteProductImage menghapus gambar produk beserta seluruh ukurannya
+func DeleteProductImage(c echo.Context) error {
+	var (
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := db.DB.First(&product, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	oldImages := productImageURLs(product)
+	if err := db.DB.Model(&product).Updates(map[string]interface{}{
+		"url_image":        "",
+		"image_medium_url": "",
+		"image_thumb_url":  "",
+	}).Error; err != nil {
+		errorDetails["database"] = "Failed to delete product image"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	removeProductImageFiles(oldImages...)
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Product image deleted successfully", nil, nil, nil)
+}
@@ -0,0 +1,385 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	importMaxFileSize = 10 << 20
+	// File dengan baris lebih banyak dari ini diproses di background per batch
+	importSyncMaxRows = 500
+	importBatchSize   = 200
+	importLookupChunk = 1000
+	importJobTTL      = 24 * time.Hour
+)
+
+// productImportPlan adalah hasil pencocokan baris CSV dengan data di database
+type productImportPlan struct {
+	rows          []dto.ProductImportRow
+	existing      map[string]models.Product
+	categories    map[string]uuid.UUID
+	newCategories []string
+	errors        []dto.ImportRowError
+	// userID dicatat sebagai pengubah harga pada riwayat harga
+	userID *uuid.UUID
+}
+
+// ImportProducts membuat atau memperbarui produk berdasarkan SKU dari file CSV.
+// Dengan ?dry_run=true hanya laporan validasi yang dikembalikan tanpa menyimpan apa pun.
+func ImportProducts(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+	dryRun := c.QueryParam("dry_run") == "true" || c.FormValue("dry_run") == "true"
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		errorDetails["file"] = "CSV file is required"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+	if fileHeader.Size > importMaxFileSize {
+		errorDetails["file"] = fmt.Sprintf("File must be at most %d MB", importMaxFileSize>>20)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		errorDetails["file"] = "Failed to read file"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+	defer file.Close()
+
+	rows, rowErrors, err := dto.ParseProductImportCSV(file)
+	if err != nil {
+		errorDetails["file"] = err.Error()
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	plan, err := planProductImport(rows)
+	if err != nil {
+		errorDetails["database"] = "Failed to match rows with existing data"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	plan.userID = currentUserID(c)
+	plan.errors = append(rowErrors, plan.errors...)
+	sort.SliceStable(plan.errors, func(i, j int) bool { return plan.errors[i].Row < plan.errors[j].Row })
+
+	report := dto.ProductImportReport{
+		DryRun:             dryRun,
+		TotalRows:          len(rows) + len(rowErrors),
+		CategoriesToCreate: plan.newCategories,
+		Errors:             plan.errors,
+	}
+	for _, row := range plan.rows {
+		if _, ok := plan.existing[row.SKU]; ok {
+			report.ToUpdate++
+		} else {
+			report.ToCreate++
+		}
+	}
+
+	if dryRun {
+		return utils.Response(c, http.StatusOK, "Import validated (dry run)", report, nil, nil)
+	}
+
+	// Import tidak dijalankan sebagian: semua baris harus valid terlebih dahulu
+	if len(plan.errors) > 0 {
+		errorDetails["file"] = fmt.Sprintf("%d rows are invalid, fix them or run with dry_run=true for details", len(plan.errors))
+		return utils.Response(c, http.StatusUnprocessableEntity, "Import has invalid rows", report, nil, errorDetails)
+	}
+	if len(plan.rows) == 0 {
+		errorDetails["file"] = "CSV file has no product rows"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	job := dto.ImportJob{
+		ID:        uuid.NewString(),
+		Status:    dto.ImportJobPending,
+		TotalRows: len(plan.rows),
+		StartedAt: time.Now(),
+	}
+
+	// File besar diproses di background, client memantau lewat job ID
+	if len(plan.rows) > importSyncMaxRows {
+		saveImportJob(job)
+		go runProductImportJob(job, plan)
+		return utils.Response(c, http.StatusAccepted, "Import started", job, nil, nil)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createImportCategories(tx, plan); err != nil {
+			return err
+		}
+		created, updated, err := applyProductImportRows(tx, plan, plan.rows)
+		job.Created, job.Updated = created, updated
+		return err
+	})
+	if err != nil {
+		errorDetails["database"] = "Failed to import products"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	finishedAt := time.Now()
+	job.Status = dto.ImportJobCompleted
+	job.Processed = len(plan.rows)
+	job.FinishedAt = &finishedAt
+	saveImportJob(job)
+
+	go cache.ResetRedisCache(append(cachedDataProducts, cachedDataCategories...)...)
+
+	return utils.Response(c, http.StatusOK, "Products imported successfully", job, nil, nil)
+}
+
+// GetProductImportJob mengembalikan status job import
+func GetProductImportJob(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	cachedData, err := cache.GetCache(importJobKey(c.Param("job_id")))
+	if err != nil {
+		errorDetails["job_id"] = "Import job not found or expired"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetails)
+	}
+
+	var job dto.ImportJob
+	if err := json.Unmarshal([]byte(cachedData), &job); err != nil {
+		errorDetails["job_id"] = "Import job data is corrupted"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Import job retrieved successfully", job, nil, nil)
+}
+
+// planProductImport mencocokkan baris dengan kategori dan produk yang sudah ada,
+// sekaligus menjalankan validasi yang membutuhkan database
+func planProductImport(rows []dto.ProductImportRow) (*productImportPlan, error) {
+	plan := &productImportPlan{
+		existing:   make(map[string]models.Product),
+		categories: make(map[string]uuid.UUID),
+	}
+
+	var categories []models.Category
+	if err := db.DB.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	// Nama kategori bisa sama di bawah induk berbeda, jadi kolom category boleh berisi slug
+	// atau path "Induk/Anak"; hasilnya disimpan per teks kolom untuk dipakai saat apply
+	lookup := dto.NewCategoryLookup(categories)
+
+	skus := make([]string, len(rows))
+	for i, row := range rows {
+		skus[i] = row.SKU
+	}
+
+	archived := make(map[string]bool)
+	variantSKUs := make(map[string]bool)
+	for start := 0; start < len(skus); start += importLookupChunk {
+		chunk := skus[start:min(start+importLookupChunk, len(skus))]
+
+		var products []models.Product
+		if err := db.DB.Unscoped().Preload("Variants").Where("sku IN ?", chunk).Find(&products).Error; err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			if product.DeletedAt.Valid {
+				archived[product.SKU] = true
+				continue
+			}
+			plan.existing[product.SKU] = product
+		}
+
+		var variants []string
+		if err := db.DB.Model(&models.ProductVariant{}).Where("sku IN ?", chunk).Pluck("sku", &variants).Error; err != nil {
+			return nil, err
+		}
+		for _, sku := range variants {
+			variantSKUs[sku] = true
+		}
+	}
+
+	newCategories := make(map[string]bool)
+	for _, row := range rows {
+		var details []dto.ErrorDetail
+
+		switch {
+		case archived[row.SKU]:
+			details = append(details, dto.ErrorDetail{Field: "sku", Message: "SKU belongs to an archived product, restore it first"})
+		case variantSKUs[row.SKU]:
+			details = append(details, dto.ErrorDetail{Field: "sku", Message: "SKU is already used by a product variant"})
+		}
+
+		precision := 0
+		if product, ok := plan.existing[row.SKU]; ok {
+			precision = product.Precision
+			// Stok produk yang sudah ada hanya berubah lewat penyesuaian stok agar alasan dan
+			// batas persetujuan superAdmin tetap berlaku; nilai yang sama (misalnya hasil export) diabaikan
+			if row.StockSet && row.Stock != product.Stock {
+				details = append(details, dto.ErrorDetail{Field: "stock", Message: "Stock of existing products can only be changed through a stock adjustment"})
+			}
+		}
+		if row.StockSet && !row.Stock.FitsPrecision(precision) {
+			details = append(details, dto.ErrorDetail{Field: "stock", Message: fmt.Sprintf("Stock allows at most %d decimals", precision)})
+		}
+
+		key := strings.ToLower(row.Category)
+		categoryID, found, err := lookup.Resolve(row.Category)
+		switch {
+		case errors.Is(err, dto.ErrCategoryAmbiguous):
+			details = append(details, dto.ErrorDetail{Field: "category", Message: "Category name is used by several categories, use its slug or the Parent/Child path"})
+		case errors.Is(err, dto.ErrCategoryPathNotFound):
+			details = append(details, dto.ErrorDetail{Field: "category", Message: "Category path not found"})
+		case found:
+			plan.categories[key] = categoryID
+		}
+
+		if len(details) > 0 {
+			plan.errors = append(plan.errors, dto.ImportRowError{Row: row.Row, Errors: details})
+			continue
+		}
+
+		if _, ok := plan.categories[key]; !ok && !newCategories[key] {
+			newCategories[key] = true
+			plan.newCategories = append(plan.newCategories, row.Category)
+		}
+		plan.rows = append(plan.rows, row)
+	}
+
+	return plan, nil
+}
+
+// createImportCategories membuat kategori yang belum ada berdasarkan nama di CSV
+func createImportCategories(tx *gorm.DB, plan *productImportPlan) error {
+	for _, name := range plan.newCategories {
+		category := models.Category{Name: name, Slug: uniqueCategorySlug(name, uuid.Nil)}
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		plan.categories[strings.ToLower(name)] = category.ID
+	}
+	return nil
+}
+
+func applyProductImportRows(tx *gorm.DB, plan *productImportPlan, rows []dto.ProductImportRow) (created, updated int, err error) {
+	for _, row := range rows {
+		categoryID := plan.categories[strings.ToLower(row.Category)]
+
+		if product, ok := plan.existing[row.SKU]; ok {
+			updates := map[string]interface{}{
+				"name":        row.Name,
+				"price":       row.Price,
+				"category_id": categoryID,
+			}
+			if row.ImageURL != "" {
+				updates["url_image"] = row.ImageURL
+			}
+			if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(updates).Error; err != nil {
+				return created, updated, fmt.Errorf("baris %d: %w", row.Row, err)
+			}
+			if err := recordPriceChange(tx, product.ID, nil, product.Price, row.Price, models.PriceSourceImport, plan.userID); err != nil {
+				return created, updated, fmt.Errorf("baris %d: %w", row.Row, err)
+			}
+			updated++
+			continue
+		}
+
+		product := models.Product{
+			Name:       row.Name,
+			SKU:        row.SKU,
+			Price:      row.Price,
+			Stock:      row.Stock,
+			Unit:       models.UnitPcs,
+			Type:       models.ProductTypeSimple,
+			URLImage:   row.ImageURL,
+			CategoryID: categoryID,
+		}
+		if err := tx.Create(&product).Error; err != nil {
+			return created, updated, fmt.Errorf("baris %d: %w", row.Row, err)
+		}
+		if err := recordStockMovement(tx, importStockMovement(plan, product.ID, product.Stock)); err != nil {
+			return created, updated, fmt.Errorf("baris %d: %w", row.Row, err)
+		}
+		created++
+	}
+	return created, updated, nil
+}
+
+func importStockMovement(plan *productImportPlan, productID uuid.UUID, delta models.Quantity) models.StockMovement {
+	return models.StockMovement{
+		ProductID: productID,
+		Delta:     delta,
+		Reason:    models.StockReasonAdjustment,
+		Note:      "Import CSV",
+		UserID:    plan.userID,
+	}
+}
+
+// runProductImportJob menyimpan baris per batch, setiap batch dalam transaksi sendiri.
+// Jika satu batch gagal, batch sebelumnya tetap tersimpan dan job ditandai failed.
+func runProductImportJob(job dto.ImportJob, plan *productImportPlan) {
+	job.Status = dto.ImportJobRunning
+	saveImportJob(job)
+
+	fail := func(err error) {
+		finishedAt := time.Now()
+		job.Status = dto.ImportJobFailed
+		job.Error = err.Error()
+		job.FinishedAt = &finishedAt
+		saveImportJob(job)
+		log.Printf("Import produk %s gagal: %v", job.ID, err)
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error { return createImportCategories(tx, plan) }); err != nil {
+		fail(err)
+		return
+	}
+
+	for start := 0; start < len(plan.rows); start += importBatchSize {
+		batch := plan.rows[start:min(start+importBatchSize, len(plan.rows))]
+
+		var created, updated int
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			created, updated, err = applyProductImportRows(tx, plan, batch)
+			return err
+		})
+		if err != nil {
+			fail(err)
+			go cache.ResetRedisCache(append(cachedDataProducts, cachedDataCategories...)...)
+			return
+		}
+
+		job.Created += created
+		job.Updated += updated
+		job.Processed += len(batch)
+		saveImportJob(job)
+	}
+
+	finishedAt := time.Now()
+	job.Status = dto.ImportJobCompleted
+	job.FinishedAt = &finishedAt
+	saveImportJob(job)
+
+	cache.ResetRedisCache(append(cachedDataProducts, cachedDataCategories...)...)
+}
+
+func importJobKey(id string) string {
+	return "product_import_job:" + id
+}
+
+func saveImportJob(job dto.ImportJob) {
+	jsonData, _ := json.Marshal(job)
+	cache.SetCache(importJobKey(job.ID), string(jsonData), importJobTTL)
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func CreateModifierGroup(c echo.Context) error {
+	var (
+		req          dto.ModifierGroupRequest
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if errorDetails = validateModifierGroupLimits(req); len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if err := db.DB.First(&product, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	group := models.ModifierGroup{
+		ProductID: product.ID,
+		Name:      req.Name,
+		MinSelect: req.MinSelect,
+		MaxSelect: req.MaxSelect,
+	}
+	for _, modifier := range req.Modifiers {
+		group.Modifiers = append(group.Modifiers, models.Modifier{Name: modifier.Name, PriceDelta: modifier.PriceDelta})
+	}
+
+	if err := db.DB.Create(&group).Error; err != nil {
+		errorDetails["database"] = "Failed to create modifier group"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusCreated, "Modifier group created successfully", dto.ConvertToModifierGroupResponse(group), nil, nil)
+}
+
+func UpdateModifierGroup(c echo.Context) error {
+	var (
+		req          dto.ModifierGroupRequest
+		group        models.ModifierGroup
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+	groupID, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		errorDetails["group_id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if errorDetails = validateModifierGroupLimits(req); len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if err := db.DB.First(&group, "id = ? AND product_id = ?", groupID, productID).Error; err != nil {
+		errorDetails["group_id"] = "Modifier group not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	group.Name = req.Name
+	group.MinSelect = req.MinSelect
+	group.MaxSelect = req.MaxSelect
+
+	// Modifier lama diganti seluruhnya; riwayat transaksi aman karena menyimpan salinan
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&group).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&models.Modifier{}).Error; err != nil {
+			return err
+		}
+		group.Modifiers = nil
+		for _, modifier := range req.Modifiers {
+			group.Modifiers = append(group.Modifiers, models.Modifier{GroupID: group.ID, Name: modifier.Name, PriceDelta: modifier.PriceDelta})
+		}
+		return tx.Create(&group.Modifiers).Error
+	})
+	if err != nil {
+		errorDetails["database"] = "Failed to update modifier group"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Modifier group updated successfully", dto.ConvertToModifierGroupResponse(group), nil, nil)
+}
+
+func DeleteModifierGroup(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+	groupID, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		errorDetails["group_id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	result := db.DB.Where("id = ? AND product_id = ?", groupID, productID).Delete(&models.ModifierGroup{})
+	if result.Error != nil {
+		errorDetails["database"] = "Failed to delete modifier group"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, result.Error, errorDetails)
+	}
+	if result.RowsAffected == 0 {
+		errorDetails["group_id"] = "Modifier group not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Modifier group deleted successfully", nil, nil, nil)
+}
+
+func validateModifierGroupLimits(req dto.ModifierGroupRequest) dto.ErrorDetails {
+	errorDetails := make(dto.ErrorDetails)
+	if req.MaxSelect > 0 && req.MinSelect > req.MaxSelect {
+		errorDetails["min_select"] = "min_select cannot be greater than max_select"
+	}
+	if req.MinSelect > len(req.Modifiers) {
+		errorDetails["min_select"] = "min_select cannot be greater than the number of modifiers"
+	}
+	return errorDetails
+}
+
+// resolveItemModifiers memvalidasi modifier yang dipilih untuk satu item transaksi
+// terhadap batas min/max setiap grup, lalu mengembalikan salinan modifier dan total selisih harga
+func resolveItemModifiers(product models.Product, modifierIDs []uuid.UUID) ([]models.TransactionItemModifier, float64, error) {
+	var groups []models.ModifierGroup
+	if err := db.DB.Preload("Modifiers").Where("product_id = ?", product.ID).Find(&groups).Error; err != nil {
+		return nil, 0, err
+	}
+
+	type choice struct {
+		group    *models.ModifierGroup
+		modifier models.Modifier
+	}
+	available := make(map[uuid.UUID]choice)
+	for i := range groups {
+		for _, modifier := range groups[i].Modifiers {
+			available[modifier.ID] = choice{group: &groups[i], modifier: modifier}
+		}
+	}
+
+	var (
+		selected  []models.TransactionItemModifier
+		delta     float64
+		perGroup  = make(map[uuid.UUID]int)
+		duplicate = make(map[uuid.UUID]bool)
+	)
+	for _, id := range modifierIDs {
+		chosen, ok := available[id]
+		if !ok {
+			return nil, 0, fmt.Errorf("modifier %v tidak tersedia untuk produk %s", id, product.Name)
+		}
+		if duplicate[id] {
+			return nil, 0, fmt.Errorf("modifier %s dipilih lebih dari sekali", chosen.modifier.Name)
+		}
+		duplicate[id] = true
+		perGroup[chosen.group.ID]++
+
+		modifierID := chosen.modifier.ID
+		selected = append(selected, models.TransactionItemModifier{
+			ModifierID: &modifierID,
+			GroupName:  chosen.group.Name,
+			Name:       chosen.modifier.Name,
+			PriceDelta: chosen.modifier.PriceDelta,
+		})
+		delta += chosen.modifier.PriceDelta
+	}
+
+	for _, group := range groups {
+		count := perGroup[group.ID]
+		if count < group.MinSelect {
+			return nil, 0, fmt.Errorf("%s pada produk %s wajib dipilih minimal %d", group.Name, product.Name, group.MinSelect)
+		}
+		if group.MaxSelect > 0 && count > group.MaxSelect {
+			return nil, 0, fmt.Errorf("%s pada produk %s maksimal %d pilihan", group.Name, product.Name, group.MaxSelect)
+		}
+	}
+
+	return selected, delta, nil
+}
+
+func MapItemModifiersToResponse(modifiers []models.TransactionItemModifier) []dto.TransactionItemModifierResponse {
+	var response []dto.TransactionItemModifierResponse
+	for _, modifier := range modifiers {
+		response = append(response, dto.TransactionItemModifierResponse{
+			ModifierID: modifier.ModifierID,
+			GroupName:  modifier.GroupName,
+			Name:       modifier.Name,
+			PriceDelta: modifier.PriceDelta,
+		})
+	}
+	return response
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// BulkUpdatePrices mengubah banyak harga sekaligus, baik dengan aturan harga (JSON) maupun
+// file CSV sku,price (multipart). Dengan dry_run=true hanya diff yang dikembalikan; tanpa
+// dry_run semua perubahan disimpan dalam satu transaksi database.
+func BulkUpdatePrices(c echo.Context) error {
+	var (
+		report       dto.BulkPriceReport
+		errorDetails = make(dto.ErrorDetails)
+		err          error
+	)
+
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		report.DryRun = c.QueryParam("dry_run") == "true" || c.FormValue("dry_run") == "true"
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			errorDetails["file"] = "CSV file is required"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+		if fileHeader.Size > importMaxFileSize {
+			errorDetails["file"] = fmt.Sprintf("File must be at most %d MB", importMaxFileSize>>20)
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			errorDetails["file"] = "Failed to read file"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+		defer file.Close()
+
+		rows, rowErrors, err := dto.ParsePriceListCSV(file)
+		if err != nil {
+			errorDetails["file"] = err.Error()
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+		report.RowErrors = rowErrors
+
+		if err := priceChangesFromList(rows, &report); err != nil {
+			errorDetails["database"] = "Failed to load products"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+	} else {
+		var req dto.BulkPriceRequest
+		if err := c.Bind(&req); err != nil {
+			return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+		}
+		if err := validate.Struct(req); err != nil {
+			errorDetails = utils.ParseValidationErrors(err)
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+		if req.Rule.CategoryID == nil && len(req.Rule.ProductIDs) == 0 {
+			errorDetails["rule"] = "Either category_id or product_ids is required"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+		}
+		if req.Rule.Type == dto.PriceRuleSet && req.Rule.Value <= 0 {
+			errorDetails["rule.value"] = "Price must be greater than 0"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+		}
+		report.DryRun = req.DryRun || c.QueryParam("dry_run") == "true"
+
+		if err := priceChangesFromRule(*req.Rule, &report); err != nil {
+			errorDetails["database"] = "Failed to load products"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+	}
+
+	if report.Changes == nil {
+		report.Changes = []dto.PriceChange{}
+	}
+	if report.DryRun {
+		return utils.Response(c, http.StatusOK, "Price changes preview", report, nil, nil)
+	}
+
+	// Perubahan hanya disimpan jika tidak ada satu pun kesalahan
+	if len(report.RowErrors) > 0 || len(report.Errors) > 0 {
+		errorDetails["prices"] = "Some prices are invalid, nothing was changed"
+		return utils.Response(c, http.StatusUnprocessableEntity, "Price update has errors", report, nil, errorDetails)
+	}
+
+	if err = db.DB.Transaction(func(tx *gorm.DB) error {
+		return applyPriceChanges(tx, report.Changes, models.PriceSourceBulk, currentUserID(c))
+	}); err != nil {
+		errorDetails["database"] = "Failed to update prices"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	report.Applied = true
+
+	// Cache dihapus sekali setelah semua harga tersimpan
+	if len(report.Changes) > 0 {
+		go cache.ResetRedisCache(cachedDataProducts...)
+	}
+
+	return utils.Response(c, http.StatusOK, "Prices updated successfully", report, nil, nil)
+}
+
+// priceChangesFromRule menghitung harga baru untuk setiap produk dalam cakupan aturan,
+// termasuk varian yang memiliki harga sendiri
+func priceChangesFromRule(rule dto.PriceRule, report *dto.BulkPriceReport) error {
+	var products []models.Product
+	query := db.DB.Preload("Variants.OptionValues").Order("name")
+	if rule.CategoryID != nil {
+		query = query.Where("category_id IN ("+categoryTreeSQL+")", []uuid.UUID{*rule.CategoryID})
+	}
+	if len(rule.ProductIDs) > 0 {
+		query = query.Where("id IN ?", rule.ProductIDs)
+	}
+	if err := query.Find(&products).Error; err != nil {
+		return err
+	}
+
+	add := func(change dto.PriceChange) {
+		switch {
+		case change.NewPrice <= 0:
+			report.Errors = append(report.Errors, dto.ErrorDetail{
+				Field:   change.SKU,
+				Message: fmt.Sprintf("%s: resulting price %.2f must be greater than 0", change.Name, change.NewPrice),
+			})
+		case change.NewPrice == change.OldPrice:
+			report.Unchanged++
+		default:
+			change.Difference = math.Round((change.NewPrice-change.OldPrice)*100) / 100
+			report.Changes = append(report.Changes, change)
+		}
+	}
+
+	for _, product := range products {
+		add(dto.PriceChange{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			OldPrice:  product.Price,
+			NewPrice:  rule.Apply(product.Price),
+		})
+		for _, variant := range product.Variants {
+			if variant.Price == nil {
+				continue
+			}
+			variantID := variant.ID
+			add(dto.PriceChange{
+				ProductID: product.ID,
+				VariantID: &variantID,
+				SKU:       variant.SKU,
+				Name:      fmt.Sprintf("%s (%s)", product.Name, variant.Name()),
+				OldPrice:  *variant.Price,
+				NewPrice:  rule.Apply(*variant.Price),
+			})
+		}
+	}
+	return nil
+}
+
+// priceChangesFromList mencocokkan baris sku,price dengan produk atau varian
+func priceChangesFromList(rows []dto.PriceListRow, report *dto.BulkPriceReport) error {
+	skus := make([]string, len(rows))
+	for i, row := range rows {
+		skus[i] = row.SKU
+	}
+
+	products := make(map[string]models.Product)
+	variants := make(map[string]models.ProductVariant)
+	parents := make(map[uuid.UUID]models.Product)
+	for start := 0; start < len(skus); start += importLookupChunk {
+		chunk := skus[start:min(start+importLookupChunk, len(skus))]
+
+		var foundProducts []models.Product
+		if err := db.DB.Where("sku IN ?", chunk).Find(&foundProducts).Error; err != nil {
+			return err
+		}
+		for _, product := range foundProducts {
+			products[product.SKU] = product
+		}
+
+		var foundVariants []models.ProductVariant
+		if err := db.DB.Preload("OptionValues").Where("sku IN ?", chunk).Find(&foundVariants).Error; err != nil {
+			return err
+		}
+		for _, variant := range foundVariants {
+			variants[variant.SKU] = variant
+			parents[variant.ProductID] = models.Product{}
+		}
+	}
+
+	if len(parents) > 0 {
+		ids := make([]uuid.UUID, 0, len(parents))
+		for id := range parents {
+			ids = append(ids, id)
+		}
+		var foundParents []models.Product
+		if err := db.DB.Where("id IN ?", ids).Find(&foundParents).Error; err != nil {
+			return err
+		}
+		for _, parent := range foundParents {
+			parents[parent.ID] = parent
+		}
+	}
+
+	for _, row := range rows {
+		var change dto.PriceChange
+		if product, ok := products[row.SKU]; ok {
+			change = dto.PriceChange{ProductID: product.ID, SKU: product.SKU, Name: product.Name, OldPrice: product.Price, NewPrice: row.Price}
+		} else if variant, ok := variants[row.SKU]; ok && parents[variant.ProductID].ID != uuid.Nil {
+			parent := parents[variant.ProductID]
+			variantID := variant.ID
+			change = dto.PriceChange{
+				ProductID: parent.ID,
+				VariantID: &variantID,
+				SKU:       variant.SKU,
+				Name:      fmt.Sprintf("%s (%s)", parent.Name, variant.Name()),
+				OldPrice:  variant.EffectivePrice(parent.Price),
+				NewPrice:  row.Price,
+			}
+		} else {
+			report.RowErrors = append(report.RowErrors, dto.ImportRowError{
+				Row:    row.Row,
+				Errors: []dto.ErrorDetail{{Field: "sku", Message: "SKU not found"}},
+			})
+			continue
+		}
+
+		if change.NewPrice == change.OldPrice {
+			report.Unchanged++
+			continue
+		}
+		change.Difference = math.Round((change.NewPrice-change.OldPrice)*100) / 100
+		report.Changes = append(report.Changes, change)
+	}
+	return nil
+}
+
+// applyPriceChanges menyimpan harga baru produk atau varian beserta riwayat harganya
+func applyPriceChanges(tx *gorm.DB, changes []dto.PriceChange, source string, userID *uuid.UUID) error {
+	for _, change := range changes {
+		query := tx.Model(&models.Product{}).Where("id = ?", change.ProductID)
+		if change.VariantID != nil {
+			query = tx.Model(&models.ProductVariant{}).Where("id = ?", *change.VariantID)
+		}
+		if err := query.Update("price", change.NewPrice).Error; err != nil {
+			return fmt.Errorf("gagal mengubah harga %s: %w", change.Name, err)
+		}
+		if err := recordPriceChange(tx, change.ProductID, change.VariantID, change.OldPrice, change.NewPrice, source, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// productSearchSQL mencari produk lewat kolom search_vector (nama, SKU, deskripsi) dan nama
+// kategori, ditambah pg_trgm agar salah ketik tetap ketemu. Parameter: tsquery, teks asli.
+// Highlight memakai penanda utils.HighlightStart/Stop yang diubah ke <mark> setelah di-escape.
+const productSearchSQL = `
+WITH q AS (SELECT to_tsquery('simple', @tsquery) AS query)
+SELECT p.id,
+	ts_rank(p.search_vector || setweight(to_tsvector('simple', coalesce(c.name, '')), 'B'), q.query)
+		+ word_similarity(@text, p.name) AS rank,
+	ts_headline('simple', translate(p.name, @markers, ''), q.query, @name_options) AS name_highlight,
+	ts_headline('simple', translate(coalesce(p.description, ''), @markers, ''), q.query, @description_options) AS description_highlight
+FROM products p
+JOIN categories c ON c.id = p.category_id
+CROSS JOIN q
+WHERE p.deleted_at IS NULL
+	AND (
+		p.search_vector @@ q.query
+		OR to_tsvector('simple', coalesce(c.name, '')) @@ q.query
+		OR @text <% p.name
+		OR p.sku ILIKE @sku ESCAPE '\'
+	)
+ORDER BY rank DESC, p.name
+LIMIT @limit OFFSET @offset`
+
+// SearchProducts melakukan full-text search produk dengan urutan relevansi
+func SearchProducts(c echo.Context) error {
+	var (
+		errorDetails = make(dto.ErrorDetails)
+		text         = strings.TrimSpace(c.QueryParam("q"))
+		page, _      = strconv.Atoi(c.QueryParam("page"))
+		limit, _     = strconv.Atoi(c.QueryParam("limit"))
+	)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	tsQuery := utils.PrefixTSQuery(text)
+	if tsQuery == "" {
+		errorDetails["q"] = "Search query must contain at least one letter or digit"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	// Namespace products_list: agar ikut terhapus setiap kali produk berubah
+	cacheKey := fmt.Sprintf("products_list:search:%s:%d:%d", strings.ToLower(text), page, limit)
+	cachedData, err := cache.GetCache(cacheKey)
+	if err == nil {
+		var cachedResults []dto.ProductSearchResult
+		if json.Unmarshal([]byte(cachedData), &cachedResults) == nil {
+			return utils.Response(c, http.StatusOK, "Products fetched from cache", cachedResults, nil, nil)
+		}
+	}
+
+	var rows []struct {
+		ID                   uuid.UUID
+		Rank                 float64
+		NameHighlight        string
+		DescriptionHighlight string
+	}
+	if err := db.DB.Raw(productSearchSQL, map[string]interface{}{
+		"tsquery": tsQuery,
+		"text":    text,
+		"sku":     utils.EscapeLike(text) + "%",
+		"limit":   limit,
+		"offset":  (page - 1) * limit,
+		"markers": utils.HighlightMarkers,
+		"name_options": fmt.Sprintf("StartSel=%s, StopSel=%s, HighlightAll=true",
+			utils.HighlightStart, utils.HighlightStop),
+		"description_options": fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2, MaxWords=20, MinWords=5",
+			utils.HighlightStart, utils.HighlightStop),
+	}).Scan(&rows).Error; err != nil {
+		errorDetails["database"] = "Gagal mencari produk"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	results := []dto.ProductSearchResult{}
+	if len(rows) > 0 {
+		ids := make([]uuid.UUID, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+
+		var products []models.Product
+		if err := preloadProductDetails(db.DB).Where("id IN ?", ids).Find(&products).Error; err != nil {
+			errorDetails["database"] = "Gagal mengambil data produk dari database"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+
+		byID := make(map[uuid.UUID]models.Product, len(products))
+		for _, product := range products {
+			byID[product.ID] = product
+		}
+
+		// Urutan mengikuti peringkat relevansi dari query pencarian
+		for _, row := range rows {
+			product, ok := byID[row.ID]
+			if !ok {
+				continue
+			}
+			results = append(results, dto.ProductSearchResult{
+				Product: dto.ConvertToProductResponse(product),
+				Rank:    row.Rank,
+				Highlight: dto.ProductSearchHighlight{
+					Name:        utils.HighlightHTML(row.NameHighlight),
+					Description: utils.HighlightHTML(row.DescriptionHighlight),
+				},
+			})
+		}
+	}
+
+	jsonData, _ := json.Marshal(results)
+	cache.SetCache(cacheKey, string(jsonData), 10*time.Minute)
+
+	return utils.Response(c, http.StatusOK, "Products fetched successfully", results, nil, nil)
+}
@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetProductVariants(c echo.Context) error {
+	var (
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	uuidID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	locationID, status, locationErrors, err := queryLocationID(c)
+	if err != nil {
+		return utils.Response(c, status, "Invalid query parameters", nil, err, locationErrors)
+	}
+
+	if err := preloadProductDetails(db.DB).First(&product, "id = ?", uuidID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	responses, err := productResponsesAt([]models.Product{product}, locationID)
+	if err != nil {
+		errorDetails["database"] = "Failed to load location stock"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	response := responses[0]
+	data := map[string]interface{}{
+		"option_types": response.OptionTypes,
+		"variants":     response.Variants,
+	}
+
+	return utils.Response(c, http.StatusOK, "Product variants fetched successfully", data, nil, nil)
+}
+
+func CreateProductOptionType(c echo.Context) error {
+	var (
+		req          dto.OptionTypeRequest
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	uuidID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if err := db.DB.First(&product, "id = ?", uuidID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	optionType := models.ProductOptionType{ProductID: product.ID, Name: req.Name}
+	for _, value := range req.Values {
+		optionType.Values = append(optionType.Values, models.ProductOptionValue{Value: value})
+	}
+
+	if err := db.DB.Create(&optionType).Error; err != nil {
+		errorDetails["database"] = "Failed to create option type"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusCreated, "Option type created successfully", dto.ConvertToOptionTypeResponse(optionType), nil, nil)
+}
+
+func DeleteProductOptionType(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+	optionID, err := uuid.Parse(c.Param("option_id"))
+	if err != nil {
+		errorDetails["option_id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	// Opsi yang masih dipakai varian tidak boleh dihapus
+	var used int64
+	db.DB.Table("product_variant_option_values").
+		Joins("JOIN product_option_values ON product_option_values.id = product_variant_option_values.product_option_value_id").
+		Where("product_option_values.option_type_id = ?", optionID).
+		Count(&used)
+	if used > 0 {
+		errorDetails["option_id"] = "Option type is still used by variants"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, nil, errorDetails)
+	}
+
+	result := db.DB.Where("id = ? AND product_id = ?", optionID, productID).Delete(&models.ProductOptionType{})
+	if result.Error != nil {
+		errorDetails["database"] = "Failed to delete option type"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, result.Error, errorDetails)
+	}
+	if result.RowsAffected == 0 {
+		errorDetails["option_id"] = "Option type not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Option type deleted successfully", nil, nil, nil)
+}
+
+func CreateProductVariant(c echo.Context) error {
+	var (
+		req          dto.VariantRequest
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	uuidID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if err := db.DB.Preload("Variants.OptionValues").First(&product, "id = ?", uuidID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	optionValues, err := resolveVariantOptions(product, req.OptionValueIDs, uuid.Nil)
+	if err != nil {
+		errorDetails["option_value_ids"] = err.Error()
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if req.SKU != "" && skuExists(req.SKU, uuid.Nil) {
+		errorDetails["sku"] = "SKU already used by another product"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	variant := models.ProductVariant{
+		ProductID:    product.ID,
+		SKU:          req.SKU,
+		Barcode:      req.Barcode,
+		Price:        req.Price,
+		Stock:        req.InitialStock(),
+		OptionValues: optionValues,
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&variant).Error; err != nil {
+			return err
+		}
+		return recordStockMovement(tx, models.StockMovement{
+			ProductID: product.ID,
+			VariantID: &variant.ID,
+			Delta:     variant.Stock,
+			Reason:    models.StockReasonAdjustment,
+			Note:      "Stok awal",
+			UserID:    currentUserID(c),
+		})
+	})
+	if err != nil {
+		errorDetails["database"] = "Failed to create variant"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	if err := db.DB.Preload("OptionValues.OptionType").First(&variant, "id = ?", variant.ID).Error; err != nil {
+		errorDetails["database"] = err.Error()
+		return utils.Response(c, http.StatusInternalServerError, "Failed to load variant", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusCreated, "Variant created successfully", dto.ConvertToVariantResponse(variant, product.Price), nil, nil)
+}
+
+func UpdateProductVariant(c echo.Context) error {
+	var (
+		req          dto.VariantRequest
+		product      models.Product
+		variant      models.ProductVariant
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+	variantID, err := uuid.Parse(c.Param("variant_id"))
+	if err != nil {
+		errorDetails["variant_id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if err := db.DB.Preload("Variants.OptionValues").First(&product, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	if err := db.DB.First(&variant, "id = ? AND product_id = ?", variantID, productID).Error; err != nil {
+		errorDetails["variant_id"] = "Variant not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	optionValues, err := resolveVariantOptions(product, req.OptionValueIDs, variant.ID)
+	if err != nil {
+		errorDetails["option_value_ids"] = err.Error()
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if req.SKU != "" && skuExists(req.SKU, variant.ID) {
+		errorDetails["sku"] = "SKU already used by another product"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	// Stok varian read-only, perubahan stok harus lewat penyesuaian stok
+	if req.Stock != nil && *req.Stock != variant.Stock {
+		errorDetails["stock"] = "Stock is read-only, use POST /api/product/:id/stock-adjustments"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	oldPrice := variant.EffectivePrice(product.Price)
+
+	variant.SKU = req.SKU
+	variant.Barcode = req.Barcode
+	variant.Price = req.Price
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("stock").Save(&variant).Error; err != nil {
+			return err
+		}
+		if err := recordPriceChange(tx, product.ID, &variant.ID, oldPrice, variant.EffectivePrice(product.Price), models.PriceSourceManual, currentUserID(c)); err != nil {
+			return err
+		}
+		return tx.Model(&variant).Association("OptionValues").Replace(optionValues)
+	})
+	if err != nil {
+		errorDetails["database"] = "Failed to update variant"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	if err := db.DB.Preload("OptionValues.OptionType").First(&variant, "id = ?", variant.ID).Error; err != nil {
+		errorDetails["database"] = err.Error()
+		return utils.Response(c, http.StatusInternalServerError, "Failed to load variant", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Variant updated successfully", dto.ConvertToVariantResponse(variant, product.Price), nil, nil)
+}
+
+func DeleteProductVariant(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+	variantID, err := uuid.Parse(c.Param("variant_id"))
+	if err != nil {
+		errorDetails["variant_id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var variant models.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&variant, "id = ? AND product_id = ?", variantID, productID).Error; err != nil {
+			return err
+		}
+
+		// Stok yang masih ada harus dikeluarkan lewat penyesuaian stok agar tercatat di ledger
+		if variant.Stock != 0 {
+			return errVariantInUse{stock: variant.Stock}
+		}
+		references, err := variantReferences(tx, variantID)
+		if err != nil {
+			return err
+		}
+		if len(references) > 0 {
+			return errVariantInUse{references: references}
+		}
+
+		if err := tx.Where("variant_id = ?", variantID).Delete(&models.LocationStock{}).Error; err != nil {
+			return err
+		}
+		return tx.Select("OptionValues").Delete(&variant).Error
+	})
+
+	var inUse errVariantInUse
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		errorDetails["variant_id"] = "Variant not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	case errors.As(err, &inUse) && inUse.stock != 0:
+		errorDetails["stock"] = fmt.Sprintf("Variant still has %s in stock, adjust it to zero first", inUse.stock)
+		return utils.Response(c, http.StatusConflict, "Variant still has stock", nil, err, errorDetails)
+	case errors.As(err, &inUse):
+		for table, count := range inUse.references {
+			errorDetails[table] = fmt.Sprintf("%d records still reference this variant", count)
+		}
+		return utils.Response(c, http.StatusConflict, "Variant is still referenced", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to delete variant"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Variant deleted successfully", nil, nil, nil)
+}
+
+// variantReferenceTables adalah riwayat yang menyimpan variant_id dan harus tetap utuh.
+// Setiap perubahan stok tercatat di stock_movements sehingga lot dan stok lokasi ikut tercakup.
+var variantReferenceTables = []string{
+	"transaction_items",
+	"stock_movements",
+	"stock_adjustments",
+	"purchase_order_lines",
+	"stock_transfer_lines",
+}
+
+// variantReferences menghitung baris riwayat per tabel yang masih memakai varian
+func variantReferences(tx *gorm.DB, variantID uuid.UUID) (map[string]int64, error) {
+	references := make(map[string]int64)
+	for _, table := range variantReferenceTables {
+		var count int64
+		if err := tx.Table(table).Where("variant_id = ?", variantID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			references[table] = count
+		}
+	}
+	return references, nil
+}
+
+type errVariantInUse struct {
+	stock      models.Quantity
+	references map[string]int64
+}
+
+func (e errVariantInUse) Error() string {
+	if e.stock != 0 {
+		return fmt.Sprintf("varian masih memiliki stok %s", e.stock)
+	}
+	return "varian masih dipakai riwayat transaksi atau stok"
+}
+
+// resolveVariantOptions memastikan nilai opsi milik produk, satu nilai per jenis opsi,
+// dan kombinasinya belum dipakai varian lain (kecuali varian yang sedang diupdate)
+func resolveVariantOptions(product models.Product, valueIDs []uuid.UUID, excludeVariantID uuid.UUID) ([]models.ProductOptionValue, error) {
+	var values []models.ProductOptionValue
+	if err := db.DB.
+		Joins("JOIN product_option_types ON product_option_types.id = product_option_values.option_type_id").
+		Where("product_option_values.id IN ? AND product_option_types.product_id = ?", valueIDs, product.ID).
+		Find(&values).Error; err != nil {
+		return nil, err
+	}
+	if len(values) != len(valueIDs) {
+		return nil, errors.New("some option values do not belong to this product")
+	}
+
+	seenTypes := make(map[uuid.UUID]bool)
+	for _, value := range values {
+		if seenTypes[value.OptionTypeID] {
+			return nil, errors.New("only one value per option type is allowed")
+		}
+		seenTypes[value.OptionTypeID] = true
+	}
+
+	key := optionCombinationKey(values)
+	for _, existing := range product.Variants {
+		if existing.ID != excludeVariantID && optionCombinationKey(existing.OptionValues) == key {
+			return nil, errors.New("a variant with the same option combination already exists")
+		}
+	}
+
+	return values, nil
+}
+
+func optionCombinationKey(values []models.ProductOptionValue) string {
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		ids = append(ids, value.ID.String())
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
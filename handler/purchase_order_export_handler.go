@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/pdf"
+	"aro-shop/utils"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+var purchaseOrderExportHeader = []string{"po_number", "supplier", "line_no", "sku", "product", "variant", "quantity", "unit", "unit_cost", "total"}
+
+// ExportPurchaseOrder mengunduh PO sebagai PDF (default) atau CSV untuk dikirim ke supplier
+func ExportPurchaseOrder(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "pdf"
+	}
+	if format != "pdf" && format != "csv" {
+		errorDetails := dto.ErrorDetails{"format": "Format must be pdf or csv"}
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+
+	order, status, errorDetails, err := findPurchaseOrder(c, preloadPurchaseOrder(db.DB))
+	if err != nil {
+		return utils.Response(c, status, "Client error", nil, err, errorDetails)
+	}
+
+	filename := fmt.Sprintf("%s.%s", order.Number, format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "pdf" {
+		content, err := renderPurchaseOrderPDF(order)
+		if err != nil {
+			return utils.Response(c, http.StatusInternalServerError, "Failed to render purchase order", nil, err, nil)
+		}
+		return c.Blob(http.StatusOK, "application/pdf", content)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(c.Response())
+	if err := writer.Write(purchaseOrderExportHeader); err != nil {
+		return err
+	}
+	for _, line := range dto.ConvertToPurchaseOrderResponse(order).Lines {
+		record := []string{
+			order.Number,
+			order.Supplier.Name,
+			strconv.Itoa(line.LineNo),
+			line.SKU,
+			line.ProductName,
+			line.VariantName,
+			line.Quantity.String(),
+			line.Unit,
+			strconv.FormatFloat(line.UnitCost, 'f', 2, 64),
+			strconv.FormatFloat(line.Total, 'f', 2, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Tata letak PDF purchase order dalam point
+const (
+	poMargin     = 40.0
+	poRowHeight  = 16.0
+	poFontSize   = 9.0
+	poFooterRoom = 80.0
+)
+
+// poColumns adalah posisi x kolom tabel PO, kolom angka rata kanan pada batas kanannya
+var poColumns = struct{ no, sku, product, qty, unit, cost, total float64 }{
+	no: poMargin, sku: poMargin + 22, product: poMargin + 112, qty: poMargin + 340,
+	unit: poMargin + 348, cost: poMargin + 440, total: pdf.A4Width - poMargin,
+}
+
+func renderPurchaseOrderPDF(order models.PurchaseOrder) ([]byte, error) {
+	doc := pdf.NewDocument(pdf.A4Width, pdf.A4Height)
+	response := dto.ConvertToPurchaseOrderResponse(order)
+
+	rightText := func(page *pdf.Page, right, y, size float64, bold bool, s string) {
+		page.Text(right-pdf.TextWidth(s, size), y, size, bold, s)
+	}
+
+	newPage := func() (*pdf.Page, float64) {
+		page := doc.AddPage()
+		y := doc.Height() - poMargin - 16
+		page.Text(poMargin, y, 16, true, "PURCHASE ORDER")
+		rightText(page, doc.Width()-poMargin, y, 11, true, order.Number)
+		y -= 16
+		rightText(page, doc.Width()-poMargin, y, poFontSize, false, "Tanggal: "+order.CreatedAt.In(storeLocation).Format("02 Jan 2006"))
+		return page, y
+	}
+
+	page, y := newPage()
+
+	// Data supplier dan tanggal pengiriman yang diharapkan
+	supplier := order.Supplier
+	y -= 10
+	page.Text(poMargin, y, 10, true, "Kepada:")
+	for _, line := range []string{supplier.Name, supplier.ContactName, supplier.Address, supplier.Phone, supplier.Email} {
+		if line == "" {
+			continue
+		}
+		y -= 13
+		page.Text(poMargin, y, poFontSize, false, pdf.Truncate(line, poFontSize, doc.Width()/2))
+	}
+	if order.ExpectedAt != nil {
+		y -= 13
+		page.Text(poMargin, y, poFontSize, false, "Diharapkan tiba: "+order.ExpectedAt.In(storeLocation).Format("02 Jan 2006"))
+	}
+
+	tableHeader := func(page *pdf.Page, y float64) float64 {
+		y -= 20
+		page.Text(poColumns.no, y, poFontSize, true, "No")
+		page.Text(poColumns.sku, y, poFontSize, true, "SKU")
+		page.Text(poColumns.product, y, poFontSize, true, "Produk")
+		rightText(page, poColumns.qty, y, poFontSize, true, "Qty")
+		page.Text(poColumns.unit, y, poFontSize, true, "Satuan")
+		rightText(page, poColumns.cost, y, poFontSize, true, "Harga")
+		rightText(page, poColumns.total, y, poFontSize, true, "Total")
+		page.Line(poMargin, y-5, doc.Width()-poMargin, y-5, 0.5)
+		return y - 5
+	}
+	y = tableHeader(page, y)
+
+	for _, line := range response.Lines {
+		if y-poRowHeight < poMargin+poFooterRoom {
+			page, y = newPage()
+			y = tableHeader(page, y)
+		}
+		y -= poRowHeight
+
+		name := line.ProductName
+		if line.VariantName != "" {
+			name += " (" + line.VariantName + ")"
+		}
+		page.Text(poColumns.no, y, poFontSize, false, strconv.Itoa(line.LineNo))
+		page.Text(poColumns.sku, y, poFontSize, false, pdf.Truncate(line.SKU, poFontSize, poColumns.product-poColumns.sku-6))
+		page.Text(poColumns.product, y, poFontSize, false, pdf.Truncate(name, poFontSize, poColumns.qty-poColumns.product-40))
+		rightText(page, poColumns.qty, y, poFontSize, false, line.Quantity.String())
+		page.Text(poColumns.unit, y, poFontSize, false, line.Unit)
+		rightText(page, poColumns.cost, y, poFontSize, false, utils.FormatRupiah(line.UnitCost))
+		rightText(page, poColumns.total, y, poFontSize, false, utils.FormatRupiah(line.Total))
+	}
+
+	page.Line(poMargin, y-6, doc.Width()-poMargin, y-6, 0.5)
+	y -= 20
+	rightText(page, poColumns.cost, y, 10, true, "Total")
+	rightText(page, poColumns.total, y, 10, true, utils.FormatRupiah(response.Total))
+
+	if order.Note != "" {
+		y -= 24
+		page.Text(poMargin, y, poFontSize, true, "Catatan:")
+		y -= 13
+		page.Text(poMargin, y, poFontSize, false, pdf.Truncate(order.Note, poFontSize, doc.Width()-2*poMargin))
+	}
+
+	return doc.Bytes()
+}
@@ -0,0 +1,579 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errPurchaseOrderStatus = errors.New("status purchase order tidak mengizinkan aksi ini")
+	errReceiptInvalid      = errors.New("data penerimaan barang tidak valid")
+)
+
+// preloadPurchaseOrder memuat relasi yang dibutuhkan untuk response dan export PO
+func preloadPurchaseOrder(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Supplier", unscoped).
+		Preload("Lines", func(q *gorm.DB) *gorm.DB { return q.Order("line_no ASC") }).
+		Preload("Lines.Product", unscoped).
+		Preload("Lines.Variant.OptionValues")
+}
+
+// GetPurchaseOrders menampilkan daftar PO terbaru, filter ?status= dan ?supplier_id=
+func GetPurchaseOrders(c echo.Context) error {
+	var (
+		orders       []models.PurchaseOrder
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	query := preloadPurchaseOrder(db.DB)
+	switch status := c.QueryParam("status"); status {
+	case "":
+	case models.PurchaseOrderDraft, models.PurchaseOrderSent, models.PurchaseOrderPartiallyReceived,
+		models.PurchaseOrderReceived, models.PurchaseOrderClosed:
+		query = query.Where("status = ?", status)
+	default:
+		errorDetails["status"] = "Status must be one of draft, sent, partially_received, received, closed"
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+	if supplierIDStr := c.QueryParam("supplier_id"); supplierIDStr != "" {
+		supplierID, err := uuid.Parse(supplierIDStr)
+		if err != nil {
+			errorDetails["supplier_id"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&orders).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch purchase orders"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	responses := make([]dto.PurchaseOrderResponse, 0, len(orders))
+	for _, order := range orders {
+		responses = append(responses, dto.ConvertToPurchaseOrderResponse(order))
+	}
+
+	responseData := map[string]interface{}{
+		"purchase_orders": responses,
+		"pagination":      map[string]interface{}{"page": page, "limit": limit},
+	}
+	return utils.Response(c, http.StatusOK, "Purchase orders fetched successfully", responseData, nil, nil)
+}
+
+// GetPurchaseOrder menampilkan detail PO beserta riwayat penerimaan barang
+func GetPurchaseOrder(c echo.Context) error {
+	order, status, errorDetails, err := findPurchaseOrder(c, preloadPurchaseOrder(db.DB).Preload("Receipts.Lines"))
+	if err != nil {
+		return utils.Response(c, status, "Client error", nil, err, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Purchase order fetched successfully", dto.ConvertToPurchaseOrderResponse(order), nil, nil)
+}
+
+// CreatePurchaseOrder membuat PO baru dengan status draft
+func CreatePurchaseOrder(c echo.Context) error {
+	var (
+		req          dto.PurchaseOrderRequest
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	order := models.PurchaseOrder{
+		Status:    models.PurchaseOrderDraft,
+		CreatedBy: currentUserID(c),
+	}
+	if status, errorDetails := applyPurchaseOrderRequest(&order, req); len(errorDetails) > 0 {
+		return utils.Response(c, status, "Validation failed", nil, nil, errorDetails)
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		number, err := nextPurchaseOrderNumber(tx, time.Now())
+		if err != nil {
+			return err
+		}
+		order.Number = number
+		return tx.Omit("Supplier").Create(&order).Error
+	})
+	if err != nil {
+		errorDetails["database"] = "Failed to create purchase order"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	preloadPurchaseOrder(db.DB).First(&order, "id = ?", order.ID)
+	return utils.Response(c, http.StatusCreated, "Purchase order created successfully", dto.ConvertToPurchaseOrderResponse(order), nil, nil)
+}
+
+// UpdatePurchaseOrder mengganti supplier, catatan, dan seluruh baris PO. Hanya PO draft
+// yang boleh diubah, PO yang sudah dikirim ke supplier menjadi acuan penerimaan barang.
+func UpdatePurchaseOrder(c echo.Context) error {
+	var req dto.PurchaseOrderRequest
+
+	order, status, errorDetails, err := findPurchaseOrder(c, db.DB)
+	if err != nil {
+		return utils.Response(c, status, "Client error", nil, err, errorDetails)
+	}
+	if order.Status != models.PurchaseOrderDraft {
+		errorDetails["status"] = "Only draft purchase orders can be edited"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, errPurchaseOrderStatus, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if status, errorDetails := applyPurchaseOrderRequest(&order, req); len(errorDetails) > 0 {
+		return utils.Response(c, status, "Validation failed", nil, nil, errorDetails)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		// Status dicek ulang dengan lock agar PO tidak diubah bersamaan dengan pengiriman
+		var current models.PurchaseOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", order.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != models.PurchaseOrderDraft {
+			return errPurchaseOrderStatus
+		}
+
+		if err := tx.Where("purchase_order_id = ?", order.ID).Delete(&models.PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Product", "Variant").Create(&order.Lines).Error; err != nil {
+			return err
+		}
+		return tx.Model(&order).Updates(map[string]interface{}{
+			"supplier_id": order.SupplierID,
+			"location_id": order.LocationID,
+			"note":        order.Note,
+			"expected_at": order.ExpectedAt,
+		}).Error
+	})
+	if errors.Is(err, errPurchaseOrderStatus) {
+		errorDetails["status"] = "Only draft purchase orders can be edited"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	}
+	if err != nil {
+		errorDetails["database"] = "Failed to update purchase order"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	preloadPurchaseOrder(db.DB).First(&order, "id = ?", order.ID)
+	return utils.Response(c, http.StatusOK, "Purchase order updated successfully", dto.ConvertToPurchaseOrderResponse(order), nil, nil)
+}
+
+// DeletePurchaseOrder menghapus PO draft yang batal dibuat
+func DeletePurchaseOrder(c echo.Context) error {
+	order, status, errorDetails, err := findPurchaseOrder(c, db.DB)
+	if err != nil {
+		return utils.Response(c, status, "Client error", nil, err, errorDetails)
+	}
+
+	result := db.DB.Where("id = ? AND status = ?", order.ID, models.PurchaseOrderDraft).Delete(&models.PurchaseOrder{})
+	if result.Error != nil {
+		errorDetails["database"] = "Failed to delete purchase order"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, result.Error, errorDetails)
+	}
+	if result.RowsAffected == 0 {
+		errorDetails["status"] = "Only draft purchase orders can be deleted"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, errPurchaseOrderStatus, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Purchase order deleted successfully", nil, nil, nil)
+}
+
+// SendPurchaseOrder menandai PO draft sudah dikirim ke supplier
+func SendPurchaseOrder(c echo.Context) error {
+	return transitionPurchaseOrder(c, "sent_at", models.PurchaseOrderSent, models.PurchaseOrderDraft)
+}
+
+// ClosePurchaseOrder menutup PO. PO yang baru diterima sebagian boleh ditutup bila
+// sisa barang tidak akan dikirim supplier.
+func ClosePurchaseOrder(c echo.Context) error {
+	return transitionPurchaseOrder(c, "closed_at", models.PurchaseOrderClosed,
+		models.PurchaseOrderSent, models.PurchaseOrderPartiallyReceived, models.PurchaseOrderReceived)
+}
+
+func transitionPurchaseOrder(c echo.Context, timestampColumn, status string, from ...string) error {
+	order, httpStatus, errorDetails, err := findPurchaseOrder(c, db.DB)
+	if err != nil {
+		return utils.Response(c, httpStatus, "Client error", nil, err, errorDetails)
+	}
+
+	result := db.DB.Model(&models.PurchaseOrder{}).
+		Where("id = ? AND status IN ?", order.ID, from).
+		Updates(map[string]interface{}{"status": status, timestampColumn: time.Now()})
+	if result.Error != nil {
+		errorDetails["database"] = "Failed to update purchase order"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, result.Error, errorDetails)
+	}
+	if result.RowsAffected == 0 {
+		errorDetails["status"] = fmt.Sprintf("Purchase order with status %s cannot be %s", order.Status, status)
+		return utils.Response(c, http.StatusConflict, "Client error", nil, errPurchaseOrderStatus, errorDetails)
+	}
+
+	preloadPurchaseOrder(db.DB).First(&order, "id = ?", order.ID)
+	return utils.Response(c, http.StatusOK, "Purchase order "+status, dto.ConvertToPurchaseOrderResponse(order), nil, nil)
+}
+
+// ReceivePurchaseOrder memposting penerimaan barang: stok bertambah, harga pokok produk
+// dihitung ulang dengan rata-rata bergerak, dan status PO mengikuti sisa barang yang belum datang.
+// Pengiriman sebagian dicatat sebagai penerimaan terpisah.
+func ReceivePurchaseOrder(c echo.Context) error {
+	var (
+		req     dto.GoodsReceiptRequest
+		receipt models.GoodsReceipt
+	)
+
+	order, status, errorDetails, err := findPurchaseOrder(c, db.DB)
+	if err != nil {
+		return utils.Response(c, status, "Client error", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	userID := currentUserID(c)
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		// PO dikunci agar dua penerimaan bersamaan tidak melebihi jumlah pesanan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").Preload("Lines.Product", unscoped).
+			First(&order, "id = ?", order.ID).Error; err != nil {
+			return err
+		}
+		if order.Status != models.PurchaseOrderSent && order.Status != models.PurchaseOrderPartiallyReceived {
+			return errPurchaseOrderStatus
+		}
+
+		lines := make(map[uuid.UUID]*models.PurchaseOrderLine, len(order.Lines))
+		for i := range order.Lines {
+			lines[order.Lines[i].ID] = &order.Lines[i]
+		}
+
+		receipt = models.GoodsReceipt{PurchaseOrderID: order.ID, Note: req.Note, ReceivedBy: userID}
+		seen := make(map[uuid.UUID]bool, len(req.Lines))
+		for i, reqLine := range req.Lines {
+			key := fmt.Sprintf("lines[%d]", i)
+			line, ok := lines[reqLine.LineID]
+			switch {
+			case !ok:
+				errorDetails[key+".line_id"] = "Line does not belong to this purchase order"
+				continue
+			case seen[reqLine.LineID]:
+				errorDetails[key+".line_id"] = "Line is listed more than once"
+				continue
+			case reqLine.Quantity > line.Remaining():
+				errorDetails[key+".quantity"] = fmt.Sprintf("Quantity exceeds the remaining %s", line.Remaining())
+				continue
+			case line.Product.DeletedAt.Valid:
+				errorDetails[key+".line_id"] = "Product is archived, restore it before receiving goods"
+				continue
+			case !reqLine.Quantity.FitsPrecision(line.Product.Precision):
+				errorDetails[key+".quantity"] = fmt.Sprintf("Quantity allows at most %d decimal places", line.Product.Precision)
+				continue
+			case line.Product.TrackLots && strings.TrimSpace(reqLine.LotNumber) == "":
+				errorDetails[key+".lot_number"] = "Lot number is required for lot-tracked products"
+				continue
+			case line.Product.TrackLots && reqLine.ExpiresAt == "":
+				errorDetails[key+".expires_at"] = "Expiry date is required for lot-tracked products"
+				continue
+			}
+			seen[reqLine.LineID] = true
+
+			var expiresAt *time.Time
+			if reqLine.ExpiresAt != "" {
+				t, err := time.Parse("2006-01-02", reqLine.ExpiresAt)
+				if err != nil {
+					errorDetails[key+".expires_at"] = "Expiry date must use YYYY-MM-DD"
+					continue
+				}
+				expiresAt = &t
+			}
+
+			unitCost := line.UnitCost
+			if reqLine.UnitCost != nil {
+				unitCost = *reqLine.UnitCost
+			}
+			receipt.Lines = append(receipt.Lines, models.GoodsReceiptLine{
+				PurchaseOrderLineID: line.ID,
+				Quantity:            reqLine.Quantity,
+				UnitCost:            unitCost,
+				LotNumber:           strings.TrimSpace(reqLine.LotNumber),
+				ExpiresAt:           expiresAt,
+			})
+		}
+		if len(errorDetails) > 0 {
+			return errReceiptInvalid
+		}
+
+		if err := tx.Create(&receipt).Error; err != nil {
+			return err
+		}
+
+		for _, receiptLine := range receipt.Lines {
+			line := lines[receiptLine.PurchaseOrderLineID]
+			if err := postReceiptLine(tx, order, *line, receiptLine, userID); err != nil {
+				return err
+			}
+			line.ReceivedQuantity += receiptLine.Quantity
+			if err := tx.Model(line).Update("received_quantity", line.ReceivedQuantity).Error; err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{"status": models.PurchaseOrderPartiallyReceived}
+		if order.FullyReceived() {
+			updates = map[string]interface{}{"status": models.PurchaseOrderReceived, "received_at": time.Now()}
+		}
+		return tx.Model(&order).Updates(updates).Error
+	})
+	switch {
+	case errors.Is(err, errReceiptInvalid):
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	case errors.Is(err, errPurchaseOrderStatus):
+		errorDetails["status"] = fmt.Sprintf("Goods cannot be received for a purchase order with status %s", order.Status)
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		errorDetails["id"] = "Purchase order not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to post goods receipt"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	preloadPurchaseOrder(db.DB).Preload("Receipts.Lines").First(&order, "id = ?", order.ID)
+	responseData := map[string]interface{}{
+		"receipt":        receipt,
+		"purchase_order": dto.ConvertToPurchaseOrderResponse(order),
+	}
+	return utils.Response(c, http.StatusCreated, "Goods receipt posted successfully", responseData, nil, nil)
+}
+
+// postReceiptLine menambah stok satu baris penerimaan dan memperbarui harga pokok produk.
+// Produk dikunci agar stok yang dipakai untuk rata-rata tidak berubah di tengah perhitungan.
+func postReceiptLine(tx *gorm.DB, order models.PurchaseOrder, line models.PurchaseOrderLine, receiptLine models.GoodsReceiptLine, userID *uuid.UUID) error {
+	var product models.Product
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", line.ProductID).Error; err != nil {
+		return err
+	}
+
+	// Harga pokok disimpan per produk, untuk produk bervarian stok yang dihitung adalah total semua varian
+	onHand := product.Stock
+	if line.VariantID != nil {
+		// SUM numeric dikembalikan driver sebagai string, Quantity yang mengurainya
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).
+			Select("COALESCE(SUM(stock), 0)").Scan(&onHand).Error; err != nil {
+			return err
+		}
+	}
+	cost := models.MovingAverageCost(onHand, product.CostPrice, receiptLine.Quantity, receiptLine.UnitCost)
+
+	err := adjustStock(tx, models.StockMovement{
+		ProductID:   line.ProductID,
+		VariantID:   line.VariantID,
+		LocationID:  order.LocationID,
+		Delta:       receiptLine.Quantity,
+		Reason:      models.StockReasonReceipt,
+		ReferenceID: receiptLine.GoodsReceiptID.String(),
+		Note:        order.Number,
+		UserID:      userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if product.TrackLots {
+		if err := createReceiptLot(tx, order, line, receiptLine); err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Model(&product).Update("cost_price", cost).Error
+}
+
+// createReceiptLot mencatat barang yang diterima sebagai lot baru di lokasi tujuan PO
+func createReceiptLot(tx *gorm.DB, order models.PurchaseOrder, line models.PurchaseOrderLine, receiptLine models.GoodsReceiptLine) error {
+	locationID := order.LocationID
+	if locationID == nil {
+		defaultID, err := defaultLocationID(tx)
+		if err != nil || defaultID == nil {
+			return err
+		}
+		locationID = defaultID
+	}
+
+	lot := models.StockLot{
+		ProductID:        line.ProductID,
+		VariantID:        line.VariantID,
+		LocationID:       *locationID,
+		LotNumber:        receiptLine.LotNumber,
+		ExpiresAt:        receiptLine.ExpiresAt,
+		ReceivedQuantity: receiptLine.Quantity,
+		ReceiptLineID:    &receiptLine.ID,
+	}
+	if err := tx.Create(&lot).Error; err != nil {
+		return err
+	}
+	return changeLot(tx, lot.ID, receiptLine.Quantity, models.StockReasonReceipt, receiptLine.GoodsReceiptID.String())
+}
+
+// findPurchaseOrder mengambil PO dari parameter :id. Status HTTP dan detail error
+// dikembalikan agar setiap handler bisa langsung membalas.
+func findPurchaseOrder(c echo.Context, query *gorm.DB) (models.PurchaseOrder, int, dto.ErrorDetails, error) {
+	var (
+		order        models.PurchaseOrder
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return order, http.StatusBadRequest, errorDetails, err
+	}
+
+	if err := query.First(&order, "id = ?", orderID).Error; err != nil {
+		errorDetails["id"] = "Purchase order not found"
+		return order, http.StatusNotFound, errorDetails, err
+	}
+
+	return order, http.StatusOK, errorDetails, nil
+}
+
+// applyPurchaseOrderRequest memvalidasi supplier, lokasi tujuan, dan produk pada request
+// lalu mengisi field PO beserta barisnya
+func applyPurchaseOrderRequest(order *models.PurchaseOrder, req dto.PurchaseOrderRequest) (int, dto.ErrorDetails) {
+	errorDetails := make(dto.ErrorDetails)
+
+	var supplier models.Supplier
+	if err := db.DB.First(&supplier, "id = ?", req.SupplierID).Error; err != nil {
+		errorDetails["supplier_id"] = "Supplier not found"
+		return http.StatusNotFound, errorDetails
+	}
+
+	if req.LocationID != nil {
+		if err := db.DB.First(&models.Location{}, "id = ?", *req.LocationID).Error; err != nil {
+			errorDetails["location_id"] = "Location not found"
+			return http.StatusNotFound, errorDetails
+		}
+	}
+
+	var expectedAt *time.Time
+	if req.ExpectedAt != "" {
+		t, err := dto.ParseEffectiveAt(req.ExpectedAt, storeLocation)
+		if err != nil {
+			errorDetails["expected_at"] = "Expected date must use YYYY-MM-DD, YYYY-MM-DD HH:MM, or RFC3339"
+			return http.StatusBadRequest, errorDetails
+		}
+		expectedAt = &t
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	var products []models.Product
+	if err := db.DB.Preload("Variants").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		errorDetails["database"] = "Failed to load products"
+		return http.StatusInternalServerError, errorDetails
+	}
+	productByID := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		productByID[product.ID] = product
+	}
+
+	lines := make([]models.PurchaseOrderLine, 0, len(req.Lines))
+	for i, reqLine := range req.Lines {
+		key := fmt.Sprintf("lines[%d]", i)
+		product, ok := productByID[reqLine.ProductID]
+		if !ok {
+			errorDetails[key+".product_id"] = "Product not found"
+			continue
+		}
+		if field, message := validateStockLine(product, reqLine.VariantID, reqLine.Quantity); field != "" {
+			errorDetails[key+"."+field] = message
+			continue
+		}
+
+		lines = append(lines, models.PurchaseOrderLine{
+			PurchaseOrderID: order.ID,
+			LineNo:          i + 1,
+			ProductID:       product.ID,
+			VariantID:       reqLine.VariantID,
+			Quantity:        reqLine.Quantity,
+			UnitCost:        reqLine.UnitCost,
+		})
+	}
+	if len(errorDetails) > 0 {
+		return http.StatusBadRequest, errorDetails
+	}
+
+	order.SupplierID = supplier.ID
+	order.LocationID = req.LocationID
+	order.Note = req.Note
+	order.ExpectedAt = expectedAt
+	order.Lines = lines
+	return http.StatusOK, nil
+}
+
+// nextPurchaseOrderNumber membuat nomor PO berurutan per hari, contoh PO-20261019-0003.
+// Advisory lock mencegah dua PO mendapat nomor yang sama saat dibuat bersamaan. Nomor
+// berikutnya diambil dari nomor terbesar, bukan jumlah PO, karena PO draft bisa dihapus.
+func nextPurchaseOrderNumber(tx *gorm.DB, now time.Time) (string, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('purchase_order_number'))").Error; err != nil {
+		return "", err
+	}
+
+	prefix := fmt.Sprintf("PO-%s-", now.In(storeLocation).Format("20060102"))
+	var last int
+	if err := tx.Model(&models.PurchaseOrder{}).
+		Select("COALESCE(MAX(CAST(SUBSTRING(number FROM ?) AS INTEGER)), 0)", len(prefix)+1).
+		Where("number LIKE ?", prefix+"%").
+		Scan(&last).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, last+1), nil
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/queue"
+	"aro-shop/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// GetReorderSuggestions menyarankan jumlah pemesanan ulang dari rata-rata penjualan harian
+// selama ?window_days= hari dan lead time supplier terakhir produk. Default hanya produk
+// yang perlu dipesan, ?include_all=true menampilkan semua; filter ?supplier_id= opsional.
+func GetReorderSuggestions(c echo.Context) error {
+	var (
+		products     []models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	windowDays := cfg.ReorderSalesWindowDays
+	if windowStr := c.QueryParam("window_days"); windowStr != "" {
+		parsed, err := strconv.Atoi(windowStr)
+		if err != nil || parsed < 1 || parsed > 365 {
+			errorDetails["window_days"] = "Window must be between 1 and 365 days"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		windowDays = parsed
+	}
+
+	var supplierFilter *uuid.UUID
+	if supplierIDStr := c.QueryParam("supplier_id"); supplierIDStr != "" {
+		supplierID, err := uuid.Parse(supplierIDStr)
+		if err != nil {
+			errorDetails["supplier_id"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		supplierFilter = &supplierID
+	}
+	includeAll := c.QueryParam("include_all") == "true"
+
+	if err := db.DB.Preload("Variants").Where("type <> ?", models.ProductTypeBundle).Order("name ASC").Find(&products).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch products"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	since := time.Now().AddDate(0, 0, -windowDays)
+	sold, err := quantitiesByProduct(db.DB.Model(&models.StockMovement{}).
+		Select("product_id, -SUM(delta) AS quantity").
+		Where("reason IN ? AND created_at >= ?", []string{models.StockReasonSale, models.StockReasonRefund}, since))
+	if err != nil {
+		errorDetails["database"] = "Failed to calculate sales"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	onOrder, err := quantitiesByProduct(db.DB.Table("purchase_order_lines AS l").
+		Select("l.product_id, SUM(l.quantity - l.received_quantity) AS quantity").
+		Joins("JOIN purchase_orders o ON o.id = l.purchase_order_id").
+		Where("o.status IN ?", []string{models.PurchaseOrderSent, models.PurchaseOrderPartiallyReceived}))
+	if err != nil {
+		errorDetails["database"] = "Failed to calculate open purchase orders"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	suppliers, err := latestProductSuppliers(db.DB)
+	if err != nil {
+		errorDetails["database"] = "Failed to load suppliers"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	suggestions := make([]dto.ReorderSuggestion, 0)
+	for _, product := range products {
+		supplier, ok := suppliers[product.ID]
+		if supplierFilter != nil && (!ok || supplier.ID != *supplierFilter) {
+			continue
+		}
+
+		leadTime := cfg.ReorderLeadTimeDays
+		var supplierRef *models.Supplier
+		if ok {
+			supplierRef = &supplier
+			if supplier.LeadTimeDays > 0 {
+				leadTime = supplier.LeadTimeDays
+			}
+		}
+
+		suggestion := dto.BuildReorderSuggestion(product, sold[product.ID], onOrder[product.ID], windowDays, leadTime, supplierRef)
+		if suggestion.NeedsReorder || (includeAll && (product.ReorderPoint > 0 || suggestion.SoldInWindow > 0)) {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	// Produk yang perlu dipesan tampil lebih dulu
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].NeedsReorder && !suggestions[j].NeedsReorder
+	})
+
+	responseData := map[string]interface{}{
+		"window_days": windowDays,
+		"suggestions": suggestions,
+	}
+	return utils.Response(c, http.StatusOK, "Reorder suggestions fetched successfully", responseData, nil, nil)
+}
+
+// quantitiesByProduct menjalankan query yang memilih product_id dan quantity per produk
+func quantitiesByProduct(query *gorm.DB) (map[uuid.UUID]models.Quantity, error) {
+	var rows []struct {
+		ProductID uuid.UUID
+		Quantity  models.Quantity
+	}
+	if err := query.Group("product_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uuid.UUID]models.Quantity, len(rows))
+	for _, row := range rows {
+		quantities[row.ProductID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+// latestProductSuppliers mengembalikan supplier PO terakhir (bukan draft) untuk setiap produk
+func latestProductSuppliers(tx *gorm.DB) (map[uuid.UUID]models.Supplier, error) {
+	var rows []struct {
+		ProductID  uuid.UUID
+		SupplierID uuid.UUID
+	}
+	err := tx.Raw(`SELECT DISTINCT ON (l.product_id) l.product_id, o.supplier_id
+		FROM purchase_order_lines l
+		JOIN purchase_orders o ON o.id = l.purchase_order_id
+		WHERE o.status <> ?
+		ORDER BY l.product_id, o.created_at DESC`, models.PurchaseOrderDraft).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var suppliers []models.Supplier
+	if err := tx.Unscoped().Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	supplierByID := make(map[uuid.UUID]models.Supplier, len(suppliers))
+	for _, supplier := range suppliers {
+		supplierByID[supplier.ID] = supplier
+	}
+
+	result := make(map[uuid.UUID]models.Supplier, len(rows))
+	for _, row := range rows {
+		if supplier, ok := supplierByID[row.SupplierID]; ok {
+			result[row.ProductID] = supplier
+		}
+	}
+	return result, nil
+}
+
+// soldByProduct menjumlahkan kuantitas terjual per produk dari item transaksi,
+// bundle dihitung dari komponennya
+func soldByProduct(items []models.TransactionItem) map[uuid.UUID]models.Quantity {
+	sold := make(map[uuid.UUID]models.Quantity)
+	for _, item := range items {
+		if len(item.Components) > 0 {
+			for _, component := range item.Components {
+				sold[component.ProductID] += component.Quantity
+			}
+			continue
+		}
+		sold[item.ProductID] += item.Quantity
+	}
+	return sold
+}
+
+// lowStockAlerts dipanggil di dalam transaksi setelah stok dikurangi dan mengembalikan
+// pesan untuk produk yang stoknya baru saja turun di bawah reorder_point
+func lowStockAlerts(tx *gorm.DB, sold map[uuid.UUID]models.Quantity) ([]string, error) {
+	productIDs := make([]uuid.UUID, 0, len(sold))
+	for productID := range sold {
+		productIDs = append(productIDs, productID)
+	}
+
+	var products []models.Product
+	if err := tx.Preload("Variants").Where("id IN ? AND reorder_point > 0", productIDs).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	for _, product := range products {
+		if !product.CrossedReorderPoint(sold[product.ID]) {
+			continue
+		}
+		message := fmt.Sprintf("Stok %s menipis: tersisa %s %s, di bawah titik pemesanan ulang %s %s",
+			product.Name, product.OnHand(), product.Unit, product.ReorderPoint, product.Unit)
+		if product.ReorderQty > 0 {
+			message += fmt.Sprintf(". Saran pesan %s %s", product.ReorderQty, product.Unit)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// publishLowStockAlerts mengirim peringatan stok menipis ke antrian notifikasi. Kegagalan
+// hanya dicatat di log karena pembayaran sudah tersimpan.
+func publishLowStockAlerts(messages []string) {
+	for _, message := range messages {
+		if err := queue.PublishNotification(message); err != nil {
+			log.Printf("Gagal mengirim peringatan stok menipis: %v", err)
+		}
+	}
+}
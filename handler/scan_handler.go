@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"aro-shop/barcode"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ScanProduct mencari produk dari hasil scan barcode. Barcode timbangan (EAN-13 berawalan 2)
+// dicari berdasarkan PLU dan kuantitasnya diambil dari barcode, selain itu dicari
+// berdasarkan barcode atau SKU produk maupun varian dengan kuantitas 1.
+func ScanProduct(c echo.Context) error {
+	var (
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+		code         = strings.TrimSpace(c.QueryParam("barcode"))
+	)
+
+	if code == "" {
+		errorDetails["barcode"] = "Barcode is required"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	locationID, status, locationErrors, err := queryLocationID(c)
+	if err != nil {
+		return utils.Response(c, status, "Invalid query parameters", nil, err, locationErrors)
+	}
+
+	if barcode.IsScaleBarcode(code) {
+		scale, err := barcode.ParseScaleBarcode(code, barcode.ScaleMode(cfg.ScaleBarcodeMode))
+		if err == nil {
+			if err := preloadProductDetails(db.DB).First(&product, "plu = ?", scale.ItemCode).Error; err == nil {
+				return scanScaleProduct(c, product, scale, locationID)
+			}
+		} else if !errors.Is(err, barcode.ErrEAN13Checksum) {
+			errorDetails["barcode"] = err.Error()
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+		// Barcode berawalan 2 yang tidak cocok dengan PLU diperlakukan sebagai barcode biasa
+	}
+
+	var variant models.ProductVariant
+	if err := db.DB.Where("barcode = ? OR sku = ?", code, code).First(&variant).Error; err == nil {
+		if err := preloadProductDetails(db.DB).First(&product, "id = ?", variant.ProductID).Error; err != nil {
+			errorDetails["barcode"] = "Product not found"
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+		}
+
+		price := variant.EffectivePrice(product.Price)
+		return scanResponse(c, product, locationID, dto.ScanResponse{
+			VariantID: &variant.ID,
+			Quantity:  models.NewQuantity(1),
+			Unit:      product.Unit,
+			Price:     price,
+			SubTotal:  price,
+		})
+	}
+
+	if err := preloadProductDetails(db.DB).Where("barcode = ? OR sku = ?", code, code).First(&product).Error; err != nil {
+		errorDetails["barcode"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	return scanResponse(c, product, locationID, dto.ScanResponse{
+		Quantity: models.NewQuantity(1),
+		Unit:     product.Unit,
+		Price:    product.Price,
+		SubTotal: product.Price,
+	})
+}
+
+func scanScaleProduct(c echo.Context, product models.Product, scale *barcode.ScaleBarcode, locationID *uuid.UUID) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	var (
+		quantity models.Quantity
+		subTotal float64
+	)
+	switch scale.Mode {
+	case barcode.ScaleModePrice:
+		// Barcode berisi harga total, kuantitas dihitung balik dari harga satuan
+		if product.Price <= 0 {
+			errorDetails["barcode"] = "Product price is not set"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+		}
+		subTotal = float64(scale.Value)
+		quantity = models.QuantityFromFloat(subTotal / product.Price).RoundTo(product.Precision)
+	default:
+		quantity = models.QuantityFromScaleValue(product.Unit, scale.Value).RoundTo(product.Precision)
+		subTotal = math.Round(product.Price*quantity.Float64()*100) / 100
+	}
+
+	if quantity <= 0 {
+		errorDetails["barcode"] = "Scale barcode contains no quantity"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	return scanResponse(c, product, locationID, dto.ScanResponse{
+		Quantity: quantity,
+		Unit:     product.Unit,
+		Price:    product.Price,
+		SubTotal: subTotal,
+		Scale:    true,
+	})
+}
+
+// scanResponse melengkapi hasil scan dengan data produk, termasuk stok lokal bila diminta
+func scanResponse(c echo.Context, product models.Product, locationID *uuid.UUID, scan dto.ScanResponse) error {
+	responses, err := productResponsesAt([]models.Product{product}, locationID)
+	if err != nil {
+		errorDetails := dto.ErrorDetails{"database": "Failed to load location stock"}
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	scan.Product = responses[0]
+	return utils.Response(c, http.StatusOK, "Product found", scan, nil, nil)
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"aro-shop/auth"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errRefreshTokenInvalid = errors.New("refresh token tidak valid atau sudah kedaluwarsa")
+
+// RefreshToken menukar refresh token dengan access token dan refresh token baru.
+// Refresh token lama langsung tidak berlaku; jika dipakai lagi, seluruh keluarga token
+// dari login yang sama dicabut sehingga pencuri maupun pemilik asli harus login ulang.
+func RefreshToken(c echo.Context) error {
+	var req dto.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails := utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation error", nil, err, errorDetails)
+	}
+
+	var (
+		response dto.TokenResponse
+		reused   *models.RefreshToken
+		now      = time.Now()
+	)
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&current, "token_hash = ?", auth.HashRefreshToken(req.RefreshToken)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errRefreshTokenInvalid
+			}
+			return err
+		}
+
+		if current.UsedAt != nil {
+			reused = &current
+			return revokeRefreshFamily(tx, current.FamilyID, now)
+		}
+		if !current.Usable(now) {
+			return errRefreshTokenInvalid
+		}
+
+		var user models.User
+		if err := tx.First(&user, "id = ?", current.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errRefreshTokenInvalid
+			}
+			return err
+		}
+
+		// Rotasi tidak memperpanjang masa berlaku keluarga token
+		issued, next, err := issueTokens(tx, user, current.FamilyID, current.ExpiresAt, now)
+		if err != nil {
+			return err
+		}
+		response = issued
+
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"used_at":        now,
+			"replaced_by_id": next.ID,
+		}).Error
+	})
+
+	errorDetails := make(dto.ErrorDetails)
+	switch {
+	case errors.Is(err, errRefreshTokenInvalid):
+		errorDetails["refresh_token"] = err.Error()
+		return utils.Response(c, http.StatusUnauthorized, "Unauthorized", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to refresh token"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	case reused != nil:
+		log.Printf("⚠️ Refresh token dipakai ulang: user=%s family=%s, semua sesi keluarga ini dicabut", reused.UserID, reused.FamilyID)
+		errorDetails["refresh_token"] = "Refresh token sudah pernah dipakai, silakan login ulang"
+		return utils.Response(c, http.StatusUnauthorized, "Unauthorized", nil, nil, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Token refreshed successfully", response, nil, nil)
+}
+
+// Logout mencabut access token yang sedang dipakai sampai masa berlakunya habis.
+// refresh_token opsional di body ikut dicabut beserta keluarganya.
+func Logout(c echo.Context) error {
+	var req dto.LogoutRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request", nil, err, nil)
+	}
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return utils.Response(c, http.StatusUnauthorized, "Unauthorized", nil, nil, nil)
+	}
+	session, err := auth.SessionFromToken(token)
+	if err != nil {
+		return utils.Response(c, http.StatusUnauthorized, "Unauthorized", nil, err, nil)
+	}
+
+	if req.RefreshToken != "" {
+		// Refresh token milik user lain diabaikan tanpa memberi tahu keberadaannya
+		var refresh models.RefreshToken
+		err := db.DB.Where("token_hash = ? AND user_id = ?", auth.HashRefreshToken(req.RefreshToken), session.UserID).
+			First(&refresh).Error
+		if err == nil {
+			err = revokeRefreshFamily(db.DB, refresh.FamilyID, time.Now())
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.Response(c, http.StatusInternalServerError, "Failed to revoke refresh token", nil, err, nil)
+		}
+	}
+
+	if err := auth.Logout(session); err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to logout", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Logout successful", nil, nil, nil)
+}
+
+// RevokeUserSessions mencabut semua sesi user: TokenVersion dinaikkan sehingga semua
+// access token lama ditolak JWTMiddleware, dan semua refresh token-nya dicabut.
+func RevokeUserSessions(c echo.Context) error {
+	var user models.User
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("token_version").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		return revokeUserRefreshTokens(tx, user.ID, time.Now())
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.Response(c, http.StatusNotFound, "User not found", nil, err, nil)
+	} else if err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to revoke sessions", nil, err, nil)
+	}
+
+	// Versi di database sudah naik; jika Redis gagal, ulangi request agar versi tersebar
+	if err := auth.SetTokenVersion(user.ID, user.TokenVersion); err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to propagate session revocation", nil, err, nil)
+	}
+
+	responseData := map[string]interface{}{
+		"user_id":       user.ID,
+		"token_version": user.TokenVersion,
+	}
+	return utils.Response(c, http.StatusOK, "User sessions revoked successfully", responseData, nil, nil)
+}
+
+// issueTokens membuat access token dan menyimpan refresh token baru dalam keluarga familyID
+func issueTokens(tx *gorm.DB, user models.User, familyID uuid.UUID, refreshExpiresAt, now time.Time) (dto.TokenResponse, models.RefreshToken, error) {
+	accessToken, expiresAt, err := auth.IssueAccessToken(user.ID, string(user.Role), user.TokenVersion, now)
+	if err != nil {
+		return dto.TokenResponse{}, models.RefreshToken{}, err
+	}
+
+	plain, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return dto.TokenResponse{}, models.RefreshToken{}, err
+	}
+	refresh := models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: refreshExpiresAt,
+	}
+	if err := tx.Create(&refresh).Error; err != nil {
+		return dto.TokenResponse{}, models.RefreshToken{}, err
+	}
+
+	response := dto.TokenResponse{
+		Token:            accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(expiresAt.Sub(now).Seconds()),
+		ExpiresAt:        expiresAt,
+		RefreshToken:     plain,
+		RefreshExpiresAt: refreshExpiresAt,
+	}
+	return response, refresh, nil
+}
+
+// revokeRefreshFamily mencabut semua refresh token yang masih aktif dalam satu keluarga
+func revokeRefreshFamily(tx *gorm.DB, familyID uuid.UUID, now time.Time) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+// revokeUserRefreshTokens mencabut semua refresh token aktif milik user
+func revokeUserRefreshTokens(tx *gorm.DB, userID uuid.UUID, now time.Time) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"aro-shop/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errInsufficientStock = errors.New("stok tidak mencukupi")
+	errExpiredLot        = errors.New("stok yang tersisa sudah kedaluwarsa")
+)
+
+// adjustStock menambah atau mengurangi stok produk (atau varian) sebesar movement.Delta
+// lalu mencatatnya di ledger stock_movements dalam transaksi yang sama. Pengurangan yang
+// membuat stok negatif, baik total maupun di lokasi movement, ditolak dengan errInsufficientStock.
+func adjustStock(tx *gorm.DB, movement models.StockMovement) error {
+	if movement.Delta == 0 {
+		return nil
+	}
+
+	query := tx.Model(&models.Product{}).Where("id = ?", movement.ProductID)
+	if movement.VariantID != nil {
+		query = tx.Model(&models.ProductVariant{}).Where("id = ?", *movement.VariantID)
+	}
+	if movement.Delta < 0 {
+		query = query.Where("stock >= ?", -movement.Delta)
+	}
+
+	result := query.Update("stock", gorm.Expr("stock + ?", movement.Delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w untuk produk %s", errInsufficientStock, movement.ProductID)
+	}
+
+	return postStockMovement(tx, movement, true)
+}
+
+// recordStockMovement mencatat perubahan stok yang sudah disimpan dengan cara lain,
+// misalnya stok awal produk baru dari form atau import. Delta nol tidak dicatat.
+func recordStockMovement(tx *gorm.DB, movement models.StockMovement) error {
+	return postStockMovement(tx, movement, false)
+}
+
+// postStockMovement menerapkan delta ke stok lokasi lalu menulis ledger. Movement tanpa
+// lokasi dibebankan ke lokasi default. Dengan strict, stok lokasi tidak boleh menjadi negatif.
+// Pengurangan juga mengurangi sisa lot di lokasi tersebut.
+func postStockMovement(tx *gorm.DB, movement models.StockMovement, strict bool) error {
+	if movement.Delta == 0 {
+		return nil
+	}
+
+	if movement.LocationID == nil {
+		locationID, err := defaultLocationID(tx)
+		if err != nil {
+			return err
+		}
+		movement.LocationID = locationID
+	}
+	if movement.LocationID != nil {
+		if err := changeLocationStock(tx, movement, strict); err != nil {
+			return err
+		}
+		if movement.Delta < 0 {
+			if err := consumeLots(tx, movement); err != nil {
+				return err
+			}
+		}
+	}
+
+	movement.ID = 0
+	return tx.Create(&movement).Error
+}
+
+// changeLocationStock mengubah stok item di lokasi movement. Baris stok lokasi dibuat
+// otomatis saat item pertama kali masuk ke lokasi tersebut.
+func changeLocationStock(tx *gorm.DB, movement models.StockMovement, strict bool) error {
+	if movement.Delta < 0 && strict {
+		query := tx.Model(&models.LocationStock{}).
+			Where("location_id = ? AND product_id = ?", *movement.LocationID, movement.ProductID).
+			Where("quantity >= ?", -movement.Delta)
+		if movement.VariantID != nil {
+			query = query.Where("variant_id = ?", *movement.VariantID)
+		} else {
+			query = query.Where("variant_id IS NULL")
+		}
+
+		result := query.Update("quantity", gorm.Expr("quantity + ?", movement.Delta))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w untuk produk %s di lokasi %s", errInsufficientStock, movement.ProductID, *movement.LocationID)
+		}
+		return nil
+	}
+
+	return tx.Exec(`INSERT INTO location_stocks (location_id, product_id, variant_id, quantity, updated_at)
+		VALUES (?, ?, ?, ?, now())
+		ON CONFLICT (location_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'))
+		DO UPDATE SET quantity = location_stocks.quantity + EXCLUDED.quantity, updated_at = now()`,
+		*movement.LocationID, movement.ProductID, movement.VariantID, movement.Delta).Error
+}
+
+// consumeLots mengurangi sisa lot secara FEFO (kedaluwarsa paling awal lebih dulu) sebesar
+// pengurangan movement, baru setelah itu stok tanpa lot. Penjualan melewati lot yang sudah
+// kedaluwarsa dan ditolak dengan errExpiredLot jika stok tanpa lot tidak cukup menutup sisanya.
+func consumeLots(tx *gorm.DB, movement models.StockMovement) error {
+	var lots []models.StockLot
+	query := lotQuery(tx.Clauses(clause.Locking{Strength: "UPDATE"}), *movement.LocationID, movement.ProductID, movement.VariantID)
+	if err := query.Where("quantity > 0").Order("expires_at ASC NULLS LAST, created_at ASC").Find(&lots).Error; err != nil {
+		return err
+	}
+	if len(lots) == 0 {
+		return nil
+	}
+
+	sale := movement.Reason == models.StockReasonSale
+	today := time.Now().In(storeLocation)
+	remaining := -movement.Delta
+	var skipped models.Quantity
+	for _, lot := range lots {
+		if remaining == 0 {
+			break
+		}
+		if sale && lot.ExpiredOn(today) {
+			skipped += lot.Quantity
+			continue
+		}
+		take := min(lot.Quantity, remaining)
+		if err := changeLot(tx, lot.ID, -take, movement.Reason, movement.ReferenceID); err != nil {
+			return err
+		}
+		remaining -= take
+	}
+	if !sale || remaining == 0 || skipped == 0 {
+		return nil
+	}
+
+	// Sisa penjualan diambil dari stok tanpa lot; jika stok lokasi tinggal lot kedaluwarsa
+	// berarti barang yang terjual berasal dari lot tersebut
+	var stock []models.Quantity
+	locationQuery := tx.Model(&models.LocationStock{}).Where("location_id = ? AND product_id = ?", *movement.LocationID, movement.ProductID)
+	if movement.VariantID != nil {
+		locationQuery = locationQuery.Where("variant_id = ?", *movement.VariantID)
+	} else {
+		locationQuery = locationQuery.Where("variant_id IS NULL")
+	}
+	if err := locationQuery.Pluck("quantity", &stock).Error; err != nil {
+		return err
+	}
+	if len(stock) == 0 || stock[0] < skipped {
+		return fmt.Errorf("%w untuk produk %s", errExpiredLot, movement.ProductID)
+	}
+	return nil
+}
+
+// changeLot mengubah sisa lot dan mencatatnya di riwayat lot
+func changeLot(tx *gorm.DB, lotID uuid.UUID, delta models.Quantity, reason, referenceID string) error {
+	if err := tx.Model(&models.StockLot{}).Where("id = ?", lotID).Update("quantity", gorm.Expr("quantity + ?", delta)).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.StockLotMovement{LotID: lotID, Delta: delta, Reason: reason, ReferenceID: referenceID}).Error
+}
+
+// lotQuery membatasi query lot pada satu lokasi dan produk (atau varian)
+func lotQuery(query *gorm.DB, locationID, productID uuid.UUID, variantID *uuid.UUID) *gorm.DB {
+	query = query.Where("location_id = ? AND product_id = ?", locationID, productID)
+	if variantID != nil {
+		return query.Where("variant_id = ?", *variantID)
+	}
+	return query.Where("variant_id IS NULL")
+}
+
+// defaultLocationID mengembalikan lokasi default, nil jika belum ada lokasi sama sekali
+func defaultLocationID(tx *gorm.DB) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := tx.Model(&models.Location{}).Where("is_default = ?", true).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
+
+// deductItemStock mengurangi stok untuk satu item transaksi yang sudah dibayar di lokasi
+// transaksi. Bundle mengurangi stok setiap komponennya, varian mengurangi stok varian,
+// selain itu stok produk yang dikurangi. Setiap pengurangan dicatat sebagai penjualan.
+func deductItemStock(tx *gorm.DB, item models.TransactionItem, userID, locationID *uuid.UUID) error {
+	sale := models.StockMovement{
+		LocationID:  locationID,
+		Reason:      models.StockReasonSale,
+		ReferenceID: item.TransactionID.String(),
+		UserID:      userID,
+	}
+
+	if len(item.Components) > 0 {
+		for _, component := range item.Components {
+			movement := sale
+			movement.ProductID = component.ProductID
+			movement.Delta = -component.Quantity
+			if err := adjustStock(tx, movement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	movement := sale
+	movement.ProductID = item.ProductID
+	movement.VariantID = item.VariantID
+	movement.Delta = -item.Quantity
+	return adjustStock(tx, movement)
+}
+
+// validateStockLine memeriksa produk yang stoknya akan dipindahkan atau ditambah: bundle tidak
+// punya stok sendiri, produk bervarian wajib menyebut varian, dan kuantitas mengikuti presisi
+// produk. Variants harus sudah di-preload. Mengembalikan field dan pesan, kosong jika valid.
+func validateStockLine(product models.Product, variantID *uuid.UUID, quantity models.Quantity) (string, string) {
+	if product.IsBundle() {
+		return "product_id", "Bundle stock comes from its components, use the components instead"
+	}
+	if len(product.Variants) > 0 && variantID == nil {
+		return "variant_id", "variant_id is required for products with variants"
+	}
+	if variantID != nil {
+		found := false
+		for _, variant := range product.Variants {
+			found = found || variant.ID == *variantID
+		}
+		if !found {
+			return "variant_id", "Variant not found"
+		}
+	}
+	if !quantity.FitsPrecision(product.Precision) {
+		return "quantity", fmt.Sprintf("Quantity allows at most %d decimal places", product.Precision)
+	}
+	return "", ""
+}
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errAdjustmentNotPending = errors.New("penyesuaian stok sudah diproses")
+
+// CreateStockAdjustment menambah atau mengurangi stok dengan kode alasan. Penyesuaian yang
+// melebihi ambang batas disimpan sebagai pending dan baru mengubah stok setelah disetujui superAdmin.
+func CreateStockAdjustment(c echo.Context) error {
+	var (
+		req          dto.StockAdjustmentRequest
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if message := req.ValidateDirection(); message != "" {
+		errorDetails["quantity"] = message
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	if err := db.DB.Preload("Variants").First(&product, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	// Stok bundle berasal dari komponennya, jadi yang disesuaikan adalah komponennya
+	if product.IsBundle() {
+		errorDetails["id"] = "Bundle stock cannot be adjusted, adjust its components instead"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+	if len(product.Variants) > 0 && req.VariantID == nil {
+		errorDetails["variant_id"] = "variant_id is required for products with variants"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+	if req.VariantID != nil {
+		found := false
+		for _, variant := range product.Variants {
+			found = found || variant.ID == *req.VariantID
+		}
+		if !found {
+			errorDetails["variant_id"] = "Variant not found"
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetails)
+		}
+	}
+	if !req.Quantity.FitsPrecision(product.Precision) {
+		errorDetails["quantity"] = fmt.Sprintf("Quantity allows at most %d decimal places", product.Precision)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+	if req.LocationID != nil {
+		if err := db.DB.First(&models.Location{}, "id = ?", *req.LocationID).Error; err != nil {
+			errorDetails["location_id"] = "Location not found"
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+		}
+	}
+
+	adjustment := models.StockAdjustment{
+		ProductID:   product.ID,
+		Product:     product,
+		VariantID:   req.VariantID,
+		LocationID:  req.LocationID,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		Note:        req.Note,
+		Status:      models.AdjustmentStatusApplied,
+		RequestedBy: currentUserID(c),
+	}
+	if adjustment.NeedsApproval(models.NewQuantity(cfg.AdjustmentApprovalThreshold)) {
+		adjustment.Status = models.AdjustmentStatusPending
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Product").Create(&adjustment).Error; err != nil {
+			return err
+		}
+		if adjustment.Status == models.AdjustmentStatusApplied {
+			return applyStockAdjustment(tx, adjustment)
+		}
+		return nil
+	})
+	if errors.Is(err, errInsufficientStock) {
+		errorDetails["quantity"] = "Stock is not sufficient for this adjustment"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	}
+	if err != nil {
+		errorDetails["database"] = "Failed to save stock adjustment"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	response := dto.ConvertToStockAdjustmentResponse(adjustment)
+	if adjustment.Status == models.AdjustmentStatusPending {
+		return utils.Response(c, http.StatusAccepted, "Stock adjustment is waiting for superAdmin approval", response, nil, nil)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusCreated, "Stock adjusted successfully", response, nil, nil)
+}
+
+// GetStockAdjustments menampilkan penyesuaian stok untuk admin dan superAdmin,
+// filter ?status= dan ?product_id=
+func GetStockAdjustments(c echo.Context) error {
+	var (
+		adjustments  []models.StockAdjustment
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	if role := currentUserRole(c); role != models.RoleAdmin && role != models.RoleSuperAdmin {
+		return utils.Response(c, http.StatusForbidden, "Kamu bukan admin", nil, nil, nil)
+	}
+
+	query := db.DB.Preload("Product", unscoped)
+	switch status := c.QueryParam("status"); status {
+	case "":
+	case models.AdjustmentStatusPending, models.AdjustmentStatusApplied, models.AdjustmentStatusRejected:
+		query = query.Where("status = ?", status)
+	default:
+		errorDetails["status"] = "Status must be one of pending, applied, rejected"
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+	if productIDStr := c.QueryParam("product_id"); productIDStr != "" {
+		productID, err := uuid.Parse(productIDStr)
+		if err != nil {
+			errorDetails["product_id"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		query = query.Where("product_id = ?", productID)
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&adjustments).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch stock adjustments"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	responses := make([]dto.StockAdjustmentResponse, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		responses = append(responses, dto.ConvertToStockAdjustmentResponse(adjustment))
+	}
+
+	responseData := map[string]interface{}{
+		"adjustments": responses,
+		"pagination":  map[string]interface{}{"page": page, "limit": limit},
+	}
+	return utils.Response(c, http.StatusOK, "Stock adjustments fetched successfully", responseData, nil, nil)
+}
+
+// ApproveStockAdjustment menerapkan penyesuaian pending (khusus superAdmin)
+func ApproveStockAdjustment(c echo.Context) error {
+	return reviewStockAdjustment(c, models.AdjustmentStatusApplied)
+}
+
+// RejectStockAdjustment menolak penyesuaian pending tanpa mengubah stok (khusus superAdmin)
+func RejectStockAdjustment(c echo.Context) error {
+	return reviewStockAdjustment(c, models.AdjustmentStatusRejected)
+}
+
+func reviewStockAdjustment(c echo.Context, status string) error {
+	var (
+		req          dto.StockAdjustmentReviewRequest
+		adjustment   models.StockAdjustment
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	adjustmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	// Body boleh kosong, catatan review bersifat opsional
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+		}
+		if err := validate.Struct(req); err != nil {
+			errorDetails = utils.ParseValidationErrors(err)
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		// Baris dikunci agar penyesuaian tidak disetujui dua kali secara bersamaan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&adjustment, "id = ?", adjustmentID).Error; err != nil {
+			return err
+		}
+		if adjustment.Status != models.AdjustmentStatusPending {
+			return errAdjustmentNotPending
+		}
+
+		if status == models.AdjustmentStatusApplied {
+			if err := applyStockAdjustment(tx, adjustment); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		adjustment.Status = status
+		adjustment.ReviewedBy = currentUserID(c)
+		adjustment.ReviewedAt = &now
+		adjustment.ReviewNote = req.Note
+		return tx.Model(&adjustment).Updates(map[string]interface{}{
+			"status":      adjustment.Status,
+			"reviewed_by": adjustment.ReviewedBy,
+			"reviewed_at": adjustment.ReviewedAt,
+			"review_note": adjustment.ReviewNote,
+		}).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		errorDetails["id"] = "Stock adjustment not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	case errors.Is(err, errAdjustmentNotPending):
+		errorDetails["status"] = fmt.Sprintf("Stock adjustment is already %s", adjustment.Status)
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case errors.Is(err, errInsufficientStock):
+		errorDetails["quantity"] = "Stock is not sufficient for this adjustment"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to review stock adjustment"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	if status == models.AdjustmentStatusApplied {
+		go cache.ResetRedisCache(cachedDataProducts...)
+	}
+
+	db.DB.Unscoped().First(&adjustment.Product, "id = ?", adjustment.ProductID)
+	return utils.Response(c, http.StatusOK, "Stock adjustment "+status, dto.ConvertToStockAdjustmentResponse(adjustment), nil, nil)
+}
+
+// applyStockAdjustment mengubah stok dan mencatatnya di ledger dengan referensi ke penyesuaian
+func applyStockAdjustment(tx *gorm.DB, adjustment models.StockAdjustment) error {
+	note := adjustment.Reason
+	if adjustment.Note != "" {
+		note += ": " + adjustment.Note
+	}
+	return adjustStock(tx, models.StockMovement{
+		ProductID:   adjustment.ProductID,
+		VariantID:   adjustment.VariantID,
+		LocationID:  adjustment.LocationID,
+		Delta:       adjustment.Quantity,
+		Reason:      models.StockReasonAdjustment,
+		ReferenceID: adjustment.ID.String(),
+		Note:        note,
+		UserID:      adjustment.RequestedBy,
+	})
+}
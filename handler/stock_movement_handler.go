@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// stockMovementReasons dipakai untuk validasi filter ?reason=
+var stockMovementReasons = map[string]bool{
+	models.StockReasonSale:       true,
+	models.StockReasonRefund:     true,
+	models.StockReasonAdjustment: true,
+	models.StockReasonReceipt:    true,
+	models.StockReasonTransfer:   true,
+	models.StockReasonStocktake:  true,
+}
+
+// GetProductStockMovements menampilkan ledger stok produk (terbaru lebih dulu) beserta saldo
+// berjalan. Stok yang sudah ada sebelum ledger dibuat dihitung sebagai saldo awal, yaitu stok
+// sekarang dikurangi total seluruh delta.
+func GetProductStockMovements(c echo.Context) error {
+	var (
+		product      models.Product
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := db.DB.Unscoped().Preload("Variants").First(&product, "id = ?", productID).Error; err != nil {
+		errorDetails["id"] = "Product not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	// Saldo produk dengan varian adalah total stok semua varian, kecuali difilter per varian
+	scope := db.DB.Model(&models.StockMovement{}).Where("product_id = ?", productID)
+	currentStock := product.Stock
+	if len(product.Variants) > 0 {
+		currentStock = 0
+		for _, variant := range product.Variants {
+			currentStock += variant.Stock
+		}
+	}
+
+	var variantID *uuid.UUID
+	if variantIDStr := c.QueryParam("variant_id"); variantIDStr != "" {
+		id, err := uuid.Parse(variantIDStr)
+		if err != nil {
+			errorDetails["variant_id"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		found := false
+		for _, variant := range product.Variants {
+			if variant.ID == id {
+				currentStock, found = variant.Stock, true
+			}
+		}
+		if !found {
+			errorDetails["variant_id"] = "Variant not found"
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetails)
+		}
+		variantID = &id
+		scope = scope.Where("variant_id = ?", id)
+	}
+
+	reason := c.QueryParam("reason")
+	if reason != "" && !stockMovementReasons[reason] {
+		errorDetails["reason"] = "Reason must be one of sale, refund, adjustment, receipt, transfer, stocktake"
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var totalDelta models.Quantity
+	if err := scope.Session(&gorm.Session{}).Select("COALESCE(SUM(delta), 0)").Scan(&totalDelta).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch stock movements"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	openingBalance := currentStock - totalDelta
+
+	// Saldo kumulatif dihitung oleh window function sebelum filter alasan dan pagination,
+	// sehingga saldo tetap benar walaupun hanya sebagian baris yang ditampilkan
+	var rows []struct {
+		models.StockMovement
+		Cumulative models.Quantity
+	}
+	ledger := scope.Session(&gorm.Session{}).Select("*, SUM(delta) OVER (ORDER BY id) AS cumulative")
+	query := db.DB.Table("(?) AS ledger", ledger)
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		errorDetails["database"] = "Failed to count stock movements"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	if err := query.Order("id DESC").Limit(limit).Offset((page - 1) * limit).Find(&rows).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch stock movements"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	// Nama user dimuat terpisah karena hasil query berasal dari subquery
+	userNames := make(map[uuid.UUID]string)
+	var userIDs []uuid.UUID
+	for _, row := range rows {
+		if row.UserID != nil {
+			userIDs = append(userIDs, *row.UserID)
+		}
+	}
+	if len(userIDs) > 0 {
+		var users []models.User
+		db.DB.Select("id, name").Where("id IN ?", userIDs).Find(&users)
+		for _, user := range users {
+			userNames[user.ID] = user.Name
+		}
+	}
+
+	movements := make([]dto.StockMovementResponse, 0, len(rows))
+	for _, row := range rows {
+		response := dto.ConvertToStockMovementResponse(row.StockMovement, openingBalance+row.Cumulative)
+		if row.UserID != nil {
+			response.UserName = userNames[*row.UserID]
+		}
+		movements = append(movements, response)
+	}
+
+	responseData := map[string]interface{}{
+		"product_id":      product.ID,
+		"variant_id":      variantID,
+		"current_stock":   currentStock,
+		"opening_balance": openingBalance,
+		"movements":       movements,
+		"pagination": map[string]interface{}{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	}
+	return utils.Response(c, http.StatusOK, "Stock movements fetched successfully", responseData, nil, nil)
+}
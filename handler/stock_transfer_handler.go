@@ -0,0 +1,358 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errTransferStatus    = errors.New("status transfer tidak mengizinkan aksi ini")
+	errTransferForbidden = errors.New("transfer hanya bisa diterima oleh lokasi tujuan")
+)
+
+// preloadStockTransfer memuat relasi yang dibutuhkan untuk response transfer
+func preloadStockTransfer(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("FromLocation", unscoped).
+		Preload("ToLocation", unscoped).
+		Preload("Lines.Product", unscoped).
+		Preload("Lines.Variant.OptionValues")
+}
+
+// GetStockTransfers menampilkan transfer terbaru, filter ?status= dan ?location_id=
+// (asal maupun tujuan)
+func GetStockTransfers(c echo.Context) error {
+	var (
+		transfers    []models.StockTransfer
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	query := preloadStockTransfer(db.DB)
+	switch status := c.QueryParam("status"); status {
+	case "":
+	case models.TransferInTransit, models.TransferReceived, models.TransferCancelled:
+		query = query.Where("status = ?", status)
+	default:
+		errorDetails["status"] = "Status must be one of in_transit, received, cancelled"
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
+	if locationIDStr := c.QueryParam("location_id"); locationIDStr != "" {
+		locationID, err := uuid.Parse(locationIDStr)
+		if err != nil {
+			errorDetails["location_id"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		query = query.Where("from_location_id = ? OR to_location_id = ?", locationID, locationID)
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&transfers).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch stock transfers"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	responses := make([]dto.StockTransferResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		responses = append(responses, dto.ConvertToStockTransferResponse(transfer))
+	}
+
+	responseData := map[string]interface{}{
+		"transfers":  responses,
+		"pagination": map[string]interface{}{"page": page, "limit": limit},
+	}
+	return utils.Response(c, http.StatusOK, "Stock transfers fetched successfully", responseData, nil, nil)
+}
+
+func GetStockTransfer(c echo.Context) error {
+	transfer, status, errorDetails, err := findStockTransfer(c, preloadStockTransfer(db.DB))
+	if err != nil {
+		return utils.Response(c, status, "Client error", nil, err, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Stock transfer fetched successfully", dto.ConvertToStockTransferResponse(transfer), nil, nil)
+}
+
+// CreateStockTransfer mengirim barang antar lokasi. Stok lokasi asal langsung berkurang
+// sehingga barang dalam perjalanan tidak ikut terjual, dan transfer berstatus in_transit.
+func CreateStockTransfer(c echo.Context) error {
+	var (
+		req          dto.StockTransferRequest
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	for field, locationID := range map[string]uuid.UUID{"from_location_id": req.FromLocationID, "to_location_id": req.ToLocationID} {
+		if err := db.DB.First(&models.Location{}, "id = ?", locationID).Error; err != nil {
+			errorDetails[field] = "Location not found"
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+		}
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	var products []models.Product
+	if err := db.DB.Preload("Variants").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		errorDetails["database"] = "Failed to load products"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+	productByID := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		productByID[product.ID] = product
+	}
+
+	transfer := models.StockTransfer{
+		FromLocationID: req.FromLocationID,
+		ToLocationID:   req.ToLocationID,
+		Status:         models.TransferInTransit,
+		Note:           req.Note,
+		CreatedBy:      currentUserID(c),
+	}
+	for i, reqLine := range req.Lines {
+		key := fmt.Sprintf("lines[%d]", i)
+		product, ok := productByID[reqLine.ProductID]
+		if !ok {
+			errorDetails[key+".product_id"] = "Product not found"
+			continue
+		}
+		if field, message := validateStockLine(product, reqLine.VariantID, reqLine.Quantity); field != "" {
+			errorDetails[key+"."+field] = message
+			continue
+		}
+		transfer.Lines = append(transfer.Lines, models.StockTransferLine{
+			ProductID: product.ID,
+			VariantID: reqLine.VariantID,
+			Quantity:  reqLine.Quantity,
+		})
+	}
+	if len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, nil, errorDetails)
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("FromLocation", "ToLocation").Create(&transfer).Error; err != nil {
+			return err
+		}
+		return moveTransferStock(tx, transfer, transfer.FromLocationID, -1, transfer.CreatedBy)
+	})
+	switch {
+	case errors.Is(err, errInsufficientStock):
+		errorDetails["lines"] = "Not enough stock at the source location"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to create stock transfer"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	preloadStockTransfer(db.DB).First(&transfer, "id = ?", transfer.ID)
+	return utils.Response(c, http.StatusCreated, "Stock transfer created successfully", dto.ConvertToStockTransferResponse(transfer), nil, nil)
+}
+
+// ReceiveStockTransfer mengonfirmasi barang sudah tiba dan menambah stok lokasi tujuan.
+// Admin boleh menerima transfer mana pun, staf lain hanya transfer ke outlet tempatnya bertugas.
+func ReceiveStockTransfer(c echo.Context) error {
+	transfer, status, errorDetails, err := findStockTransfer(c, db.DB)
+	if err != nil {
+		return utils.Response(c, status, "Client error", nil, err, errorDetails)
+	}
+
+	userID := currentUserID(c)
+	if role := currentUserRole(c); role != models.RoleAdmin && role != models.RoleSuperAdmin {
+		var user models.User
+		if userID == nil || db.DB.First(&user, "id = ?", *userID).Error != nil ||
+			user.LocationID == nil || *user.LocationID != transfer.ToLocationID {
+			errorDetails["id"] = "Only staff at the destination location can receive this transfer"
+			return utils.Response(c, http.StatusForbidden, "Forbidden", nil, errTransferForbidden, errorDetails)
+		}
+	}
+
+	return finishStockTransfer(c, transfer, models.TransferReceived, func(tx *gorm.DB, transfer models.StockTransfer) error {
+		return moveTransferStock(tx, transfer, transfer.ToLocationID, 1, userID)
+	})
+}
+
+// CancelStockTransfer membatalkan transfer yang masih dalam perjalanan dan
+// mengembalikan stoknya ke lokasi asal
+func CancelStockTransfer(c echo.Context) error {
+	transfer, status, errorDetails, err := findStockTransfer(c, db.DB)
+	if err != nil {
+		return utils.Response(c, status, "Client error", nil, err, errorDetails)
+	}
+
+	userID := currentUserID(c)
+	return finishStockTransfer(c, transfer, models.TransferCancelled, func(tx *gorm.DB, transfer models.StockTransfer) error {
+		return moveTransferStock(tx, transfer, transfer.FromLocationID, 1, userID)
+	})
+}
+
+// finishStockTransfer mengunci transfer in_transit, menjalankan post, lalu mengubah statusnya
+func finishStockTransfer(c echo.Context, transfer models.StockTransfer, status string, post func(tx *gorm.DB, transfer models.StockTransfer) error) error {
+	errorDetails := make(dto.ErrorDetails)
+	userID := currentUserID(c)
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&transfer, "id = ?", transfer.ID).Error; err != nil {
+			return err
+		}
+		if transfer.Status != models.TransferInTransit {
+			return errTransferStatus
+		}
+		if err := post(tx, transfer); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"status": status}
+		if status == models.TransferReceived {
+			updates["received_by"] = userID
+			updates["received_at"] = time.Now()
+		}
+		return tx.Model(&transfer).Updates(updates).Error
+	})
+	switch {
+	case errors.Is(err, errTransferStatus):
+		errorDetails["status"] = fmt.Sprintf("Transfer with status %s cannot be %s", transfer.Status, status)
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to update stock transfer"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	preloadStockTransfer(db.DB).First(&transfer, "id = ?", transfer.ID)
+	return utils.Response(c, http.StatusOK, "Stock transfer "+status, dto.ConvertToStockTransferResponse(transfer), nil, nil)
+}
+
+// moveTransferStock memposting setiap baris transfer ke lokasi dengan arah sign:
+// -1 saat barang keluar dari lokasi asal, +1 saat barang masuk. Lot yang terambil saat
+// keluar ikut dipindahkan ke lokasi barang masuk.
+func moveTransferStock(tx *gorm.DB, transfer models.StockTransfer, locationID uuid.UUID, sign models.Quantity, userID *uuid.UUID) error {
+	for _, line := range transfer.Lines {
+		movement := models.StockMovement{
+			ProductID:   line.ProductID,
+			VariantID:   line.VariantID,
+			LocationID:  &locationID,
+			Delta:       sign * line.Quantity,
+			Reason:      models.StockReasonTransfer,
+			ReferenceID: transfer.ID.String(),
+			UserID:      userID,
+		}
+		if err := adjustStock(tx, movement); err != nil {
+			return err
+		}
+	}
+	if sign > 0 {
+		return moveTransferLots(tx, transfer, locationID)
+	}
+	return nil
+}
+
+// moveTransferLots mengembalikan lot yang diambil dari lokasi asal saat transfer dikirim.
+// Di lokasi asal (transfer dibatalkan) sisa lot semula ditambah kembali, di lokasi lain
+// lot dengan nomor dan tanggal kedaluwarsa yang sama ditambah atau dibuat baru.
+func moveTransferLots(tx *gorm.DB, transfer models.StockTransfer, locationID uuid.UUID) error {
+	var movements []models.StockLotMovement
+	err := tx.Preload("Lot").
+		Where("reason = ? AND reference_id = ? AND delta < 0", models.StockReasonTransfer, transfer.ID.String()).
+		Order("id ASC").Find(&movements).Error
+	if err != nil {
+		return err
+	}
+
+	for _, movement := range movements {
+		source := movement.Lot
+		if source.LocationID != transfer.FromLocationID {
+			continue
+		}
+
+		target := source
+		if locationID != source.LocationID {
+			var lots []models.StockLot
+			query := lotQuery(tx.Clauses(clause.Locking{Strength: "UPDATE"}), locationID, source.ProductID, source.VariantID).
+				Where("lot_number = ?", source.LotNumber)
+			if source.ExpiresAt != nil {
+				query = query.Where("expires_at = ?", *source.ExpiresAt)
+			} else {
+				query = query.Where("expires_at IS NULL")
+			}
+			if err := query.Limit(1).Find(&lots).Error; err != nil {
+				return err
+			}
+
+			if len(lots) > 0 {
+				target = lots[0]
+			} else {
+				target = models.StockLot{
+					ProductID:        source.ProductID,
+					VariantID:        source.VariantID,
+					LocationID:       locationID,
+					LotNumber:        source.LotNumber,
+					ExpiresAt:        source.ExpiresAt,
+					ReceivedQuantity: -movement.Delta,
+					ReceiptLineID:    source.ReceiptLineID,
+				}
+				if err := tx.Create(&target).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := changeLot(tx, target.ID, -movement.Delta, models.StockReasonTransfer, transfer.ID.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findStockTransfer mengambil transfer dari parameter :id
+func findStockTransfer(c echo.Context, query *gorm.DB) (models.StockTransfer, int, dto.ErrorDetails, error) {
+	var (
+		transfer     models.StockTransfer
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	transferID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return transfer, http.StatusBadRequest, errorDetails, err
+	}
+
+	if err := query.First(&transfer, "id = ?", transferID).Error; err != nil {
+		errorDetails["id"] = "Stock transfer not found"
+		return transfer, http.StatusNotFound, errorDetails, err
+	}
+
+	return transfer, http.StatusOK, errorDetails, nil
+}
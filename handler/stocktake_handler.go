@@ -0,0 +1,635 @@
+package handler
+
+import (
+	"aro-shop/cache"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errStocktakeOpen    = errors.New("masih ada sesi stock opname yang berjalan")
+	errStocktakeClosed  = errors.New("sesi stock opname sudah ditutup")
+	errStocktakeNoItems = errors.New("tidak ada produk untuk dihitung")
+
+	errInvalidCountPrecision = errors.New("jumlah hitungan melebihi presisi produk")
+)
+
+// StartStocktake memulai sesi stock opname di satu lokasi. Stok lokasi setiap produk
+// (atau varian) dibekukan sebagai stok yang diharapkan, bersama harga pokok untuk menilai selisih.
+func StartStocktake(c echo.Context) error {
+	var (
+		req          dto.StocktakeRequest
+		stocktake    models.Stocktake
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	if req.CategoryID != nil {
+		if err := db.DB.First(&models.Category{}, "id = ?", *req.CategoryID).Error; err != nil {
+			errorDetails["category_id"] = "Category not found"
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+		}
+	}
+
+	locationID := req.LocationID
+	if locationID != nil {
+		if err := db.DB.First(&models.Location{}, "id = ?", *locationID).Error; err != nil {
+			errorDetails["location_id"] = "Location not found"
+			return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+		}
+	} else {
+		defaultID, err := defaultLocationID(db.DB)
+		if err != nil || defaultID == nil {
+			errorDetails["location_id"] = "No default location, location_id is required"
+			return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+		}
+		locationID = defaultID
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var openCount int64
+		if err := tx.Model(&models.Stocktake{}).Where("status = ? AND location_id = ?", models.StocktakeOpen, *locationID).Count(&openCount).Error; err != nil {
+			return err
+		}
+		if openCount > 0 {
+			return errStocktakeOpen
+		}
+
+		// Posisi ledger dan stok dibaca bersamaan agar keduanya konsisten
+		cursor, err := stockLedgerCursor(tx)
+		if err != nil {
+			return err
+		}
+
+		var products []models.Product
+		query := tx.Preload("Variants").Where("type <> ?", models.ProductTypeBundle)
+		if req.CategoryID != nil {
+			query = query.Where("category_id IN ("+categoryTreeSQL+")", []uuid.UUID{*req.CategoryID})
+		}
+		if err := query.Find(&products).Error; err != nil {
+			return err
+		}
+		levels, err := locationStockLevels(tx, *locationID, nil)
+		if err != nil {
+			return err
+		}
+
+		var items []models.StocktakeItem
+		for _, product := range products {
+			if len(product.Variants) == 0 {
+				expected := levels[dto.LocationStockKey{ProductID: product.ID}]
+				items = append(items, models.StocktakeItem{ProductID: product.ID, ExpectedQuantity: expected, CostPrice: product.CostPrice})
+				continue
+			}
+			for _, variant := range product.Variants {
+				variantID := variant.ID
+				expected := levels[dto.LocationStockKey{ProductID: product.ID, VariantID: variant.ID}]
+				items = append(items, models.StocktakeItem{ProductID: product.ID, VariantID: &variantID, ExpectedQuantity: expected, CostPrice: product.CostPrice})
+			}
+		}
+		if len(items) == 0 {
+			return errStocktakeNoItems
+		}
+
+		stocktake = models.Stocktake{
+			Status:         models.StocktakeOpen,
+			Note:           req.Note,
+			CategoryID:     req.CategoryID,
+			LocationID:     locationID,
+			MovementCursor: cursor,
+			StartedBy:      currentUserID(c),
+		}
+		if err := tx.Create(&stocktake).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].StocktakeID = stocktake.ID
+		}
+		if err := tx.Omit("Product", "Variant").CreateInBatches(&items, 500).Error; err != nil {
+			return err
+		}
+		stocktake.Items = items
+		return nil
+	})
+	switch {
+	case errors.Is(err, errStocktakeOpen):
+		errorDetails["status"] = "Another stocktake is still open at this location, finalize or cancel it first"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case errors.Is(err, errStocktakeNoItems):
+		errorDetails["category_id"] = "No products to count"
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to start stocktake"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusCreated, "Stocktake started successfully", dto.ConvertToStocktakeResponse(stocktake), nil, nil)
+}
+
+// GetStocktakes menampilkan daftar sesi stock opname terbaru
+func GetStocktakes(c echo.Context) error {
+	var (
+		stocktakes   []models.Stocktake
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	// Ringkasan hanya butuh jumlah hitungan per item, bukan seluruh kolom
+	err := db.DB.Preload("Items", func(q *gorm.DB) *gorm.DB { return q.Select("id", "stocktake_id", "counts") }).
+		Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&stocktakes).Error
+	if err != nil {
+		errorDetails["database"] = "Failed to fetch stocktakes"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	responses := make([]dto.StocktakeResponse, 0, len(stocktakes))
+	for _, stocktake := range stocktakes {
+		responses = append(responses, dto.ConvertToStocktakeResponse(stocktake))
+	}
+
+	responseData := map[string]interface{}{
+		"stocktakes": responses,
+		"pagination": map[string]interface{}{"page": page, "limit": limit},
+	}
+	return utils.Response(c, http.StatusOK, "Stocktakes fetched successfully", responseData, nil, nil)
+}
+
+// SubmitStocktakeCount mencatat hitungan petugas. Hitungan untuk item yang sama dijumlahkan.
+// Stok yang diharapkan tidak ditampilkan agar petugas menghitung tanpa patokan.
+func SubmitStocktakeCount(c echo.Context) error {
+	var (
+		req          dto.StocktakeCountRequest
+		item         models.StocktakeItem
+		count        models.StocktakeCount
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	stocktakeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errorDetails = utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	}
+
+	productID, variantID, status, errorDetails := resolveStocktakeProduct(req)
+	if len(errorDetails) > 0 {
+		return utils.Response(c, status, "Client error", nil, nil, errorDetails)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		// Sesi dikunci bersama (FOR SHARE) agar hitungan tidak masuk saat sesi sedang difinalisasi
+		var stocktake models.Stocktake
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&stocktake, "id = ?", stocktakeID).Error; err != nil {
+			return err
+		}
+		if stocktake.Status != models.StocktakeOpen {
+			return errStocktakeClosed
+		}
+
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Product").
+			Where("stocktake_id = ? AND product_id = ?", stocktakeID, productID)
+		if variantID != nil {
+			query = query.Where("variant_id = ?", *variantID)
+		} else {
+			query = query.Where("variant_id IS NULL")
+		}
+		if err := query.First(&item).Error; err != nil {
+			return err
+		}
+		if !req.Quantity.FitsPrecision(item.Product.Precision) {
+			return errInvalidCountPrecision
+		}
+
+		cursor, err := stockLedgerCursor(tx)
+		if err != nil {
+			return err
+		}
+
+		count = models.StocktakeCount{
+			StocktakeID: stocktakeID,
+			ItemID:      item.ID,
+			Quantity:    *req.Quantity,
+			Note:        req.Note,
+			CountedBy:   currentUserID(c),
+		}
+		if err := tx.Create(&count).Error; err != nil {
+			return err
+		}
+
+		item.CountedQuantity += count.Quantity
+		item.Counts++
+		item.CountCursor = cursor
+		return tx.Model(&item).Updates(map[string]interface{}{
+			"counted_quantity": item.CountedQuantity,
+			"counts":           item.Counts,
+			"count_cursor":     item.CountCursor,
+		}).Error
+	})
+	switch {
+	case errors.Is(err, errStocktakeClosed):
+		errorDetails["id"] = "Stocktake is no longer open"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case errors.Is(err, errInvalidCountPrecision):
+		errorDetails["quantity"] = fmt.Sprintf("Quantity allows at most %d decimal places", item.Product.Precision)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errorDetails)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		errorDetails["id"] = "Stocktake or product is not part of this stocktake"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to save count"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	responseData := map[string]interface{}{
+		"count":            count,
+		"product_id":       item.ProductID,
+		"variant_id":       item.VariantID,
+		"product_name":     item.Product.Name,
+		"counted_quantity": item.CountedQuantity,
+		"counts":           item.Counts,
+	}
+	return utils.Response(c, http.StatusCreated, "Count saved successfully", responseData, nil, nil)
+}
+
+// GetStocktakeCounts menampilkan hitungan yang sudah masuk. Kasir hanya melihat hitungannya
+// sendiri, admin melihat semua hitungan (filter ?item_id=).
+func GetStocktakeCounts(c echo.Context) error {
+	var (
+		counts       []models.StocktakeCount
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	stocktakeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	query := db.DB.Preload("User").Where("stocktake_id = ?", stocktakeID)
+	if role := currentUserRole(c); role != models.RoleAdmin && role != models.RoleSuperAdmin {
+		query = query.Where("counted_by = ?", currentUserID(c))
+	}
+	if itemIDStr := c.QueryParam("item_id"); itemIDStr != "" {
+		itemID, err := uuid.Parse(itemIDStr)
+		if err != nil {
+			errorDetails["item_id"] = "Invalid UUID format"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		query = query.Where("item_id = ?", itemID)
+	}
+
+	if err := query.Order("created_at DESC").Find(&counts).Error; err != nil {
+		errorDetails["database"] = "Failed to fetch counts"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Counts fetched successfully", counts, nil, nil)
+}
+
+// DeleteStocktakeCount membatalkan hitungan yang salah selama sesi masih open.
+// Kasir hanya boleh membatalkan hitungannya sendiri.
+func DeleteStocktakeCount(c echo.Context) error {
+	var (
+		count        models.StocktakeCount
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	stocktakeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+	countID, err := uuid.Parse(c.Param("count_id"))
+	if err != nil {
+		errorDetails["count_id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	isAdmin := currentUserRole(c) == models.RoleAdmin || currentUserRole(c) == models.RoleSuperAdmin
+	userID := currentUserID(c)
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var stocktake models.Stocktake
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&stocktake, "id = ?", stocktakeID).Error; err != nil {
+			return err
+		}
+		if stocktake.Status != models.StocktakeOpen {
+			return errStocktakeClosed
+		}
+
+		query := tx.Where("id = ? AND stocktake_id = ?", countID, stocktakeID)
+		if !isAdmin {
+			query = query.Where("counted_by = ?", userID)
+		}
+		if err := query.First(&count).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&count).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.StocktakeItem{}).Where("id = ?", count.ItemID).Updates(map[string]interface{}{
+			"counted_quantity": gorm.Expr("counted_quantity - ?", count.Quantity),
+			"counts":           gorm.Expr("counts - 1"),
+		}).Error
+	})
+	switch {
+	case errors.Is(err, errStocktakeClosed):
+		errorDetails["id"] = "Stocktake is no longer open"
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		errorDetails["count_id"] = "Count not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to delete count"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Count deleted successfully", nil, nil, nil)
+}
+
+// GetStocktakeVariance menampilkan selisih per item dalam kuantitas dan nilai harga pokok.
+// ?only_variance=true hanya menampilkan item yang selisih atau belum dihitung.
+func GetStocktakeVariance(c echo.Context) error {
+	var (
+		stocktake    models.Stocktake
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	stocktakeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	if err := preloadStocktakeItems(db.DB).First(&stocktake, "id = ?", stocktakeID).Error; err != nil {
+		errorDetails["id"] = "Stocktake not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	}
+
+	deltas, err := stocktakeMovementDeltas(db.DB, stocktake, 0)
+	if err != nil {
+		errorDetails["database"] = "Failed to calculate stock movements"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	onlyVariance := c.QueryParam("only_variance") == "true"
+	lines := make([]dto.StocktakeVarianceLine, 0, len(stocktake.Items))
+	for _, item := range stocktake.Items {
+		line := dto.BuildStocktakeVarianceLine(item, deltas[item.ID])
+		if onlyVariance && line.CountedQuantity != nil && line.Variance == 0 {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return utils.Response(c, http.StatusOK, "Stocktake variance fetched successfully", dto.BuildStocktakeVarianceReport(stocktake, lines), nil, nil)
+}
+
+// FinalizeStocktake memposting selisih setiap item ke stok sebagai movement stocktake.
+// Selisih dihitung terhadap stok yang seharusnya ada saat item dihitung, lalu ditambahkan
+// ke stok sekarang, sehingga penjualan selama penghitungan tidak ikut dianggap selisih.
+func FinalizeStocktake(c echo.Context) error {
+	var (
+		req          dto.StocktakeFinalizeRequest
+		stocktake    models.Stocktake
+		failedItem   dto.StocktakeVarianceLine
+		errorDetails = make(dto.ErrorDetails)
+	)
+
+	stocktakeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	// Body boleh kosong, default item yang tidak dihitung dilewati
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+		}
+	}
+
+	userID := currentUserID(c)
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stocktake, "id = ?", stocktakeID).Error; err != nil {
+			return err
+		}
+		if stocktake.Status != models.StocktakeOpen {
+			return errStocktakeClosed
+		}
+		if err := preloadStocktakeItems(tx).First(&stocktake, "id = ?", stocktakeID).Error; err != nil {
+			return err
+		}
+
+		// Item yang tidak dihitung dianggap kosong per posisi ledger saat finalisasi
+		cursor, err := stockLedgerCursor(tx)
+		if err != nil {
+			return err
+		}
+		deltas, err := stocktakeMovementDeltas(tx, stocktake, cursor)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range stocktake.Items {
+			line := dto.BuildStocktakeVarianceLine(item, deltas[item.ID])
+			variance := line.Variance
+			if !item.Counted() {
+				if !req.ZeroUncounted {
+					continue
+				}
+				variance = -(item.ExpectedQuantity + deltas[item.ID])
+			}
+
+			err := adjustStock(tx, models.StockMovement{
+				ProductID:   item.ProductID,
+				VariantID:   item.VariantID,
+				LocationID:  stocktake.LocationID,
+				Delta:       variance,
+				Reason:      models.StockReasonStocktake,
+				ReferenceID: stocktake.ID.String(),
+				Note:        "Stock opname",
+				UserID:      userID,
+			})
+			if err != nil {
+				failedItem = line
+				return err
+			}
+			if err := tx.Model(&models.StocktakeItem{}).Where("id = ?", item.ID).Update("posted_variance", variance).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		stocktake.Status = models.StocktakeFinalized
+		stocktake.FinalizedBy = userID
+		stocktake.FinalizedAt = &now
+		return tx.Model(&stocktake).Updates(map[string]interface{}{
+			"status":       stocktake.Status,
+			"finalized_by": stocktake.FinalizedBy,
+			"finalized_at": stocktake.FinalizedAt,
+		}).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		errorDetails["id"] = "Stocktake not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, err, errorDetails)
+	case errors.Is(err, errStocktakeClosed):
+		errorDetails["status"] = fmt.Sprintf("Stocktake is already %s", stocktake.Status)
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case errors.Is(err, errInsufficientStock):
+		// Stok sekarang lebih kecil dari selisih, biasanya karena barang terjual setelah dihitung ulang
+		errorDetails[failedItem.ItemID.String()] = fmt.Sprintf("%s: current stock is lower than the counted shortage, recount this item", strings.TrimSpace(failedItem.ProductName+" "+failedItem.VariantName))
+		return utils.Response(c, http.StatusConflict, "Client error", nil, err, errorDetails)
+	case err != nil:
+		errorDetails["database"] = "Failed to finalize stocktake"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+
+	return utils.Response(c, http.StatusOK, "Stocktake finalized successfully", dto.ConvertToStocktakeResponse(stocktake), nil, nil)
+}
+
+// CancelStocktake membatalkan sesi tanpa mengubah stok
+func CancelStocktake(c echo.Context) error {
+	errorDetails := make(dto.ErrorDetails)
+
+	stocktakeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorDetails["id"] = "Invalid UUID format"
+		return utils.Response(c, http.StatusBadRequest, "Invalid ID format", nil, err, errorDetails)
+	}
+
+	result := db.DB.Model(&models.Stocktake{}).
+		Where("id = ? AND status = ?", stocktakeID, models.StocktakeOpen).
+		Update("status", models.StocktakeCancelled)
+	if result.Error != nil {
+		errorDetails["database"] = "Failed to cancel stocktake"
+		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, result.Error, errorDetails)
+	}
+	if result.RowsAffected == 0 {
+		errorDetails["id"] = "Open stocktake not found"
+		return utils.Response(c, http.StatusNotFound, "Client error", nil, nil, errorDetails)
+	}
+
+	return utils.Response(c, http.StatusOK, "Stocktake cancelled successfully", nil, nil, nil)
+}
+
+func preloadStocktakeItems(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Items", func(q *gorm.DB) *gorm.DB { return q.Order("created_at ASC, id ASC") }).
+		Preload("Items.Product", unscoped).
+		Preload("Items.Variant.OptionValues")
+}
+
+// stockLedgerCursor mengembalikan ID movement terakhir. Tabel ledger dikunci SHARE ROW EXCLUSIVE
+// sampai transaksi selesai, sehingga transaksi yang sedang menulis ledger ditunggu dulu dan tidak
+// ada movement dengan ID lebih kecil yang baru muncul setelah posisi ini dibaca. Mode ini bentrok
+// dengan dirinya sendiri tetapi tetap mengizinkan transaksi pemegangnya menulis ledger, jadi dua
+// finalisasi saling menunggu bergantian alih-alih deadlock karena menaikkan kunci SHARE. Panggil
+// sebelum transaksi menulis movement dan sedekat mungkin dengan commit karena penjualan ikut menunggu.
+func stockLedgerCursor(tx *gorm.DB) (uint64, error) {
+	if err := tx.Exec("LOCK TABLE stock_movements IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+		return 0, err
+	}
+	var cursor uint64
+	err := tx.Model(&models.StockMovement{}).Select("COALESCE(MAX(id), 0)").Scan(&cursor).Error
+	return cursor, err
+}
+
+// stocktakeMovementDeltas menjumlahkan perubahan stok per item di lokasi sesi dari ledger antara
+// sesi dimulai dan hitungan terakhir item tersebut. Item yang belum dihitung memakai uncountedCursor.
+// Movement stocktake sendiri tidak ikut dihitung.
+func stocktakeMovementDeltas(tx *gorm.DB, stocktake models.Stocktake, uncountedCursor uint64) (map[uuid.UUID]models.Quantity, error) {
+	var rows []struct {
+		ItemID uuid.UUID
+		Delta  models.Quantity
+	}
+	err := tx.Raw(`SELECT i.id AS item_id, COALESCE(SUM(m.delta), 0) AS delta
+		FROM stocktake_items i
+		JOIN stock_movements m ON m.product_id = i.product_id
+			AND m.variant_id IS NOT DISTINCT FROM i.variant_id
+			AND m.id > ?
+			AND m.id <= CASE WHEN i.counts > 0 THEN i.count_cursor ELSE ? END
+			AND m.reason <> ?
+			AND m.location_id IS NOT DISTINCT FROM ?
+		WHERE i.stocktake_id = ?
+		GROUP BY i.id`, stocktake.MovementCursor, uncountedCursor, models.StockReasonStocktake, stocktake.LocationID, stocktake.ID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	deltas := make(map[uuid.UUID]models.Quantity, len(rows))
+	for _, row := range rows {
+		deltas[row.ItemID] = row.Delta
+	}
+	return deltas, nil
+}
+
+// resolveStocktakeProduct mencari produk yang dihitung dari barcode/SKU, atau dari
+// product_id dan variant_id pada request
+func resolveStocktakeProduct(req dto.StocktakeCountRequest) (uuid.UUID, *uuid.UUID, int, dto.ErrorDetails) {
+	errorDetails := make(dto.ErrorDetails)
+
+	if code := strings.TrimSpace(req.Barcode); code != "" {
+		var variant models.ProductVariant
+		if err := db.DB.Where("barcode = ? OR sku = ?", code, code).First(&variant).Error; err == nil {
+			return variant.ProductID, &variant.ID, http.StatusOK, nil
+		}
+		var product models.Product
+		if err := db.DB.Preload("Variants").Where("barcode = ? OR sku = ?", code, code).First(&product).Error; err != nil {
+			errorDetails["barcode"] = "Product not found"
+			return uuid.Nil, nil, http.StatusNotFound, errorDetails
+		}
+		if len(product.Variants) > 0 {
+			errorDetails["barcode"] = "Scan the variant barcode for products with variants"
+			return uuid.Nil, nil, http.StatusBadRequest, errorDetails
+		}
+		return product.ID, nil, http.StatusOK, nil
+	}
+
+	if req.ProductID == nil {
+		errorDetails["barcode"] = "Barcode or product_id is required"
+		return uuid.Nil, nil, http.StatusBadRequest, errorDetails
+	}
+	return *req.ProductID, req.VariantID, http.StatusOK, nil
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// GetSuppliers menampilkan daftar supplier, filter ?search= berdasarkan nama
+func GetSuppliers(c echo.Context) error {
+	var suppliers []models.Supplier
+
+	query := withArchived(db.DB, includeArchived(c))
+	if search := strings.TrimSpace(c.QueryParam("search")); search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+	if err := query.Order("name ASC").Find(&suppliers).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to fetch suppliers", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Suppliers retrieved successfully", suppliers, nil, nil)
+}
+
+func GetSupplier(c echo.Context) error {
+	var supplier models.Supplier
+
+	supplierID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	if err := withArchived(db.DB, includeArchived(c)).First(&supplier, "id = ?", supplierID).Error; err != nil {
+		return utils.Response(c, http.StatusNotFound, "Supplier not found", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Supplier retrieved successfully", supplier, nil, nil)
+}
+
+func CreateSupplier(c echo.Context) error {
+	var req dto.SupplierRequest
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errDetails := utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errDetails)
+	}
+
+	supplier := models.Supplier{}
+	applySupplierRequest(&supplier, req)
+	if err := db.DB.Create(&supplier).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to create supplier", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusCreated, "Supplier created successfully", supplier, nil, nil)
+}
+
+func UpdateSupplier(c echo.Context) error {
+	var (
+		req      dto.SupplierRequest
+		supplier models.Supplier
+	)
+
+	supplierID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	if err := db.DB.First(&supplier, "id = ?", supplierID).Error; err != nil {
+		return utils.Response(c, http.StatusNotFound, "Supplier not found", nil, err, nil)
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid request format", nil, err, nil)
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errDetails := utils.ParseValidationErrors(err)
+		return utils.Response(c, http.StatusBadRequest, "Validation failed", nil, err, errDetails)
+	}
+
+	applySupplierRequest(&supplier, req)
+	if err := db.DB.Save(&supplier).Error; err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to update supplier", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Supplier updated successfully", supplier, nil, nil)
+}
+
+// DeleteSupplier mengarsipkan supplier, PO lama tetap merujuk ke data ini
+func DeleteSupplier(c echo.Context) error {
+	supplierID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.Response(c, http.StatusBadRequest, "Invalid UUID format", nil, err, nil)
+	}
+
+	result := db.DB.Delete(&models.Supplier{}, "id = ?", supplierID)
+	if result.Error != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to delete supplier", nil, result.Error, nil)
+	}
+	if result.RowsAffected == 0 {
+		return utils.Response(c, http.StatusNotFound, "Supplier not found", nil, nil, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Supplier archived successfully", nil, nil, nil)
+}
+
+func applySupplierRequest(supplier *models.Supplier, req dto.SupplierRequest) {
+	supplier.Name = strings.TrimSpace(req.Name)
+	supplier.ContactName = req.ContactName
+	supplier.Phone = req.Phone
+	supplier.Email = req.Email
+	supplier.Address = req.Address
+	supplier.LeadTimeDays = req.LeadTimeDays
+	supplier.Note = req.Note
+}
@@ -28,7 +28,11 @@ var (
 
 func GetTransactions(c echo.Context) error {
 	cacheKeyPrefix := "transactions_page_"
-	errorDetails := make(dto.ErrorDetails)
+
+	cursorParams, errorDetails := dto.ParseCursorParams(c.QueryParams())
+	if len(errorDetails) > 0 {
+		return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, nil, errorDetails)
+	}
 
 	// Ambil parameter page dan limit dari query string
 	page, err := strconv.Atoi(c.QueryParam("page"))
@@ -41,6 +45,39 @@ func GetTransactions(c echo.Context) error {
 	}
 	offset := (page - 1) * limit
 
+	// Total data hanya dihitung jika diminta karena COUNT(*) mahal pada tabel besar
+	var total int64
+	if cursorParams.IncludeTotal {
+		if err := db.DB.Model(&models.Transaction{}).Count(&total).Error; err != nil {
+			errorDetails["database"] = "Failed to count transactions"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+	}
+
+	// Mode cursor tidak di-cache agar transaksi baru langsung terlihat tanpa duplikasi
+	if cursorParams.Enabled {
+		transactions, pagination, err := paginateByCursor(preloadTransactionDetails(db.DB), "transactions", cursorParams.Cursor, limit, func(t models.Transaction) (time.Time, uuid.UUID) {
+			return t.CreatedAt, t.ID
+		})
+		if errors.Is(err, dto.ErrInvalidCursor) {
+			errorDetails["cursor"] = "Invalid cursor"
+			return utils.Response(c, http.StatusBadRequest, "Invalid query parameters", nil, err, errorDetails)
+		}
+		if err != nil {
+			errorDetails["database"] = "Failed to fetch transactions"
+			return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
+		}
+		if cursorParams.IncludeTotal {
+			pagination.Total = &total
+		}
+
+		response := map[string]interface{}{
+			"transactions": MapTransactionsToResponse(transactions),
+			"pagination":   pagination,
+		}
+		return utils.Response(c, http.StatusOK, "Transactions retrieved successfully", response, nil, nil)
+	}
+
 	cacheKey := fmt.Sprintf("%s%d_%d", cacheKeyPrefix, page, limit)
 
 	// Cek apakah data ada di Redis
@@ -52,21 +89,9 @@ func GetTransactions(c echo.Context) error {
 		}
 	}
 
-	// Ambil total data untuk indexing
-	var total int64
-	if err := db.DB.Model(&models.Transaction{}).Count(&total).Error; err != nil {
-		errorDetails["database"] = "Failed to count transactions"
-		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
-	}
-
 	// Jika tidak ada di Redis, ambil dari database dengan pagination
 	var transactions []models.Transaction
-	if err := db.DB.
-		Preload("User").
-		Preload("Items").
-		Preload("Items.Product").
-		Preload("Payment").
-		Preload("Payment.PaymentMethod").
+	if err := preloadTransactionDetails(db.DB).
 		Limit(limit).
 		Offset(offset).
 		Find(&transactions).Error; err != nil {
@@ -82,14 +107,17 @@ func GetTransactions(c echo.Context) error {
 	cache.SetCache(cacheKey, string(dataJSON), 10*time.Minute)
 
 	// Struktur respons dengan pagination
+	pagination := map[string]interface{}{
+		"current_page": page,
+		"per_page":     limit,
+	}
+	if cursorParams.IncludeTotal {
+		pagination["total_data"] = total
+		pagination["total_pages"] = int(math.Ceil(float64(total) / float64(limit)))
+	}
 	response := map[string]interface{}{
 		"transactions": transactionResponses,
-		"pagination": map[string]interface{}{
-			"current_page": page,
-			"per_page":     limit,
-			"total_data":   total,
-			"total_pages":  int(math.Ceil(float64(total) / float64(limit))),
-		},
+		"pagination":   pagination,
 	}
 
 	return utils.Response(c, http.StatusOK, "Transactions retrieved successfully", response, nil, nil)
@@ -128,12 +156,7 @@ func GetTransactionsById(c echo.Context) error {
 
 	// Jika tidak ada di Redis, ambil dari database dengan pagination
 	var transaction models.Transaction
-	if err := db.DB.
-		Preload("User").
-		Preload("Items").
-		Preload("Items.Product").
-		Preload("Payment").
-		Preload("Payment.PaymentMethod").
+	if err := preloadTransactionDetails(db.DB).
 		Find(&transaction).Error; err != nil {
 		errorDetails["database"] = "Failed to fetch transactions"
 		return utils.Response(c, http.StatusInternalServerError, "Internal server error", nil, err, errorDetails)
@@ -142,6 +165,7 @@ func GetTransactionsById(c echo.Context) error {
 	TransactionResponse := dto.TransactionResponse{
 		ID:         transaction.ID,
 		User:       dto.SimpleUserResponse{ID: transaction.User.ID, Name: transaction.User.Name, Email: transaction.User.Email},
+		LocationID: transaction.LocationID,
 		Date:       transaction.Date,
 		AmountPaid: transaction.AmountPaid,
 		Items:      MapTransactionItemToResponse(transaction.Items),
@@ -289,6 +313,16 @@ func CreateTransaction(c echo.Context) error {
 		return utils.Response(c, http.StatusBadRequest, "Validasi gagal", nil, nil, errorDetails)
 	}
 
+	// Payment method yang sudah diarsipkan tidak bisa dipakai untuk transaksi baru
+	var paymentMethod models.PaymentMethod
+	if err := db.DB.First(&paymentMethod, "id = ?", req.PaymentMethodID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			errorDetails["payment_method_id"] = fmt.Sprintf("Payment method dengan ID %v tidak ditemukan", req.PaymentMethodID)
+			return utils.Response(c, http.StatusBadRequest, "Payment method tidak valid", nil, nil, errorDetails)
+		}
+		return utils.Response(c, http.StatusInternalServerError, "Gagal memeriksa payment method", nil, err, nil)
+	}
+
 	var total float64
 	var transactionItems []models.TransactionItem
 
@@ -302,13 +336,46 @@ func CreateTransaction(c echo.Context) error {
 			return utils.Response(c, http.StatusInternalServerError, "Gagal memeriksa produk", nil, err, nil)
 		}
 
-		subTotal := product.Price * float64(item.Quantity)
+		// Kuantitas pecahan hanya untuk produk dengan satuan yang mengizinkan desimal
+		if !item.Quantity.FitsPrecision(product.Precision) {
+			errorDetails["quantity"] = fmt.Sprintf("Kuantitas %s untuk produk %s maksimal %d angka desimal", item.Quantity, product.Name, product.Precision)
+			return utils.Response(c, http.StatusBadRequest, "Kuantitas tidak valid", nil, nil, errorDetails)
+		}
+
+		price, err := resolveItemPrice(product, item)
+		if err != nil {
+			errorDetails["variant_id"] = err.Error()
+			return utils.Response(c, http.StatusBadRequest, "Varian produk tidak valid", nil, nil, errorDetails)
+		}
+
+		modifiers, modifierDelta, err := resolveItemModifiers(product, item.ModifierIDs)
+		if err != nil {
+			errorDetails["modifier_ids"] = err.Error()
+			return utils.Response(c, http.StatusBadRequest, "Modifier produk tidak valid", nil, nil, errorDetails)
+		}
+
+		// Subtotal sudah termasuk selisih harga modifier per unit
+		subTotal := math.Round((price+modifierDelta)*item.Quantity.Float64()*100) / 100
 		total += subTotal
 
+		// Bundle: cek stok komponen dan simpan pembagian pendapatan per komponen
+		var components []models.TransactionItemComponent
+		if product.IsBundle() {
+			components, err = buildBundleComponents(product, item.Quantity, subTotal)
+			if err != nil {
+				errorDetails["product_id"] = err.Error()
+				return utils.Response(c, http.StatusBadRequest, "Stok bundle tidak mencukupi", nil, nil, errorDetails)
+			}
+		}
+
 		transactionItems = append(transactionItems, models.TransactionItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			SubTotal:  subTotal,
+			ProductID:  item.ProductID,
+			VariantID:  item.VariantID,
+			Quantity:   item.Quantity,
+			SubTotal:   subTotal,
+			Note:       item.Note,
+			Modifiers:  modifiers,
+			Components: components,
 		})
 	}
 
@@ -324,8 +391,24 @@ func CreateTransaction(c echo.Context) error {
 		return utils.Response(c, http.StatusUnauthorized, "User UUID tidak valid", nil, nil, nil)
 	}
 
+	// Transaksi mengurangi stok outlet kasir, kasir tanpa outlet memakai lokasi default
+	var cashier models.User
+	if err := db.DB.Select("id", "location_id").First(&cashier, "id = ?", uid).Error; err != nil {
+		return utils.Response(c, http.StatusUnauthorized, "User tidak ditemukan", nil, err, nil)
+	}
+
+	// Lot yang sudah kedaluwarsa tidak boleh terjual
+	if err := checkExpiredStock(db.DB, cashier.LocationID, transactionItems); err != nil {
+		if errors.Is(err, errExpiredLot) {
+			errorDetails["items"] = err.Error()
+			return utils.Response(c, http.StatusBadRequest, "Stok produk sudah kedaluwarsa", nil, err, errorDetails)
+		}
+		return utils.Response(c, http.StatusInternalServerError, "Gagal memeriksa tanggal kedaluwarsa", nil, err, nil)
+	}
+
 	transaction := models.Transaction{
 		UserID:     uid,
+		LocationID: cashier.LocationID,
 		Date:       time.Now(),
 		AmountPaid: total,
 	}
@@ -357,12 +440,7 @@ func CreateTransaction(c echo.Context) error {
 		return utils.Response(c, http.StatusInternalServerError, "Gagal menyimpan pembayaran", nil, err, nil)
 	}
 
-	if err := db.DB.
-		Preload("User").
-		Preload("Items").
-		Preload("Items.Product").
-		Preload("Payment").
-		Preload("Payment.PaymentMethod").
+	if err := preloadTransactionDetails(db.DB).
 		First(&transaction, "id = ?", transaction.ID).Error; err != nil {
 		return utils.Response(c, http.StatusInternalServerError, "Gagal mengambil data transaksi lengkap", nil, err, nil)
 	}
@@ -387,6 +465,7 @@ func CreateTransaction(c echo.Context) error {
 	TransactionResponse := dto.TransactionResponse{
 		ID:         transaction.ID,
 		User:       dto.SimpleUserResponse{ID: transaction.User.ID, Name: transaction.User.Name, Email: transaction.User.Email},
+		LocationID: transaction.LocationID,
 		Date:       transaction.Date,
 		AmountPaid: transaction.AmountPaid,
 		Items:      MapTransactionItemToResponse(transaction.Items),
@@ -411,7 +490,7 @@ func UpdateTransaction(c echo.Context) error {
 	var transaction models.Transaction
 
 	// Ambil data transaksi dari DB beserta relasinya
-	if err := db.DB.Preload("Items").Preload("Payment").First(&transaction, "id = ?", transactionID).Error; err != nil {
+	if err := db.DB.Preload("Items.Components").Preload("Payment").First(&transaction, "id = ?", transactionID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return utils.Response(c, http.StatusNotFound, "Transaksi tidak ditemukan", nil, nil, nil)
 		}
@@ -424,32 +503,47 @@ func UpdateTransaction(c echo.Context) error {
 	}
 
 	now := time.Now()
+	userID := currentUserID(c)
+	var lowStock []string
+
+	// Pembayaran dan pengurangan stok disimpan bersama agar stok yang tidak cukup
+	// membatalkan pembayaran juga
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&transaction.Payment).Updates(models.Payment{
+			PaymentStatus: "paid",
+			PaidAt:        &now,
+			AmountPaid:    transaction.AmountPaid,
+		}).Error; err != nil {
+			return fmt.Errorf("gagal memperbarui pembayaran: %w", err)
+		}
 
-	// Update status pembayaran
-	if err := db.DB.Model(&transaction.Payment).Updates(models.Payment{
-		PaymentStatus: "paid",
-		PaidAt:        &now,
-		AmountPaid:    transaction.AmountPaid,
-	}).Error; err != nil {
-		return utils.Response(c, http.StatusInternalServerError, "Gagal memperbarui pembayaran", nil, err, nil)
-	}
-
-	// Kurangi stok produk
-	for _, item := range transaction.Items {
-		if err := db.DB.Model(&models.Product{}).
-			Where("id = ? AND stock >= ?", item.ProductID, item.Quantity).
-			Update("stock", gorm.Expr("stock - ?", item.Quantity)).Error; err != nil {
-			return utils.Response(c, http.StatusInternalServerError, "Gagal mengurangi stok produk", nil, err, nil)
+		// Kurangi stok produk, varian, atau komponen bundle
+		for _, item := range transaction.Items {
+			if err := deductItemStock(tx, item, userID, transaction.LocationID); err != nil {
+				return err
+			}
 		}
+
+		// Stok dibaca dalam transaksi yang sama selagi baris produk yang dikurangi masih terkunci
+		var err error
+		lowStock, err = lowStockAlerts(tx, soldByProduct(transaction.Items))
+		return err
+	})
+	if errors.Is(err, errInsufficientStock) {
+		return utils.Response(c, http.StatusConflict, "Stok produk tidak mencukupi", nil, err, nil)
+	}
+	if errors.Is(err, errExpiredLot) {
+		return utils.Response(c, http.StatusConflict, "Stok produk yang tersisa sudah kedaluwarsa", nil, err, nil)
 	}
+	if err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Gagal memproses pembayaran", nil, err, nil)
+	}
+
+	go cache.ResetRedisCache(cachedDataProducts...)
+	publishLowStockAlerts(lowStock)
 
 	// Ambil ulang data lengkap
-	if err := db.DB.
-		Preload("User").
-		Preload("Items").
-		Preload("Items.Product").
-		Preload("Payment").
-		Preload("Payment.PaymentMethod").
+	if err := preloadTransactionDetails(db.DB).
 		First(&transaction, "id = ?", transaction.ID).Error; err != nil {
 		return utils.Response(c, http.StatusInternalServerError, "Gagal mengambil data transaksi lengkap", nil, err, nil)
 	}
@@ -473,6 +567,19 @@ func UpdateTransaction(c echo.Context) error {
 	return utils.Response(c, http.StatusOK, "Transaksi berhasil diperbarui dan dibayar", TransactionResponse, nil, nil)
 }
 
+// preloadTransactionDetails memuat relasi transaksi. Produk dan payment method dimuat
+// tanpa scope soft delete agar riwayat transaksi tetap utuh setelah datanya diarsipkan.
+func preloadTransactionDetails(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("User").
+		Preload("Items").
+		Preload("Items.Product", unscoped).
+		Preload("Items.Variant.OptionValues").
+		Preload("Items.Modifiers").
+		Preload("Payment").
+		Preload("Payment.PaymentMethod", unscoped)
+}
+
 func MapTransactionItemToResponse(items []models.TransactionItem) []dto.TransactionItemResponse {
 	var response []dto.TransactionItemResponse
 	for _, item := range items {
@@ -480,19 +587,51 @@ func MapTransactionItemToResponse(items []models.TransactionItem) []dto.Transact
 			ID:          item.ID,
 			ProductID:   item.ProductID,
 			ProductName: item.Product.Name,
+			VariantID:   item.VariantID,
+			VariantName: variantName(item.Variant),
 			Quantity:    item.Quantity,
+			Unit:        item.Product.Unit,
 			SubTotal:    item.SubTotal,
+			Note:        item.Note,
+			Modifiers:   MapItemModifiersToResponse(item.Modifiers),
 		})
 	}
 	return response
 }
 
+func variantName(variant *models.ProductVariant) string {
+	if variant == nil {
+		return ""
+	}
+	return variant.Name()
+}
+
+// resolveItemPrice menentukan harga satuan item. Produk yang memiliki varian
+// wajib menyertakan variant_id, dan harga varian menggantikan harga induk.
+func resolveItemPrice(product models.Product, item dto.TransactionItemRequest) (float64, error) {
+	if item.VariantID == nil {
+		var variantCount int64
+		db.DB.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&variantCount)
+		if variantCount > 0 {
+			return 0, fmt.Errorf("produk %s memiliki varian, variant_id wajib diisi", product.Name)
+		}
+		return product.Price, nil
+	}
+
+	var variant models.ProductVariant
+	if err := db.DB.Where("id = ? AND product_id = ?", *item.VariantID, product.ID).First(&variant).Error; err != nil {
+		return 0, fmt.Errorf("varian dengan ID %v tidak ditemukan pada produk %s", *item.VariantID, product.Name)
+	}
+	return variant.EffectivePrice(product.Price), nil
+}
+
 func MapTransactionsToResponse(transactions []models.Transaction) []dto.TransactionResponse {
 	var responses []dto.TransactionResponse
 	for _, transaction := range transactions {
 		res := dto.TransactionResponse{
 			ID:         transaction.ID,
 			User:       dto.SimpleUserResponse{ID: transaction.User.ID, Name: transaction.User.Name, Email: transaction.User.Email},
+			LocationID: transaction.LocationID,
 			Date:       transaction.Date,
 			AmountPaid: transaction.AmountPaid,
 			// Items:      MapTransactionItemToResponse(transaction.Items),
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"aro-shop/auth"
 	"aro-shop/config"
 	"aro-shop/db"
 	"aro-shop/dto"
@@ -9,17 +10,15 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-var (
-	cfg       = config.LoadConfig()
-	jwtSecret = []byte(cfg.JWTSecret)
-)
+var cfg = config.LoadConfig()
 
 func Register(c echo.Context) error {
 	var req dto.RegisterRequest
@@ -137,18 +136,18 @@ func Login(c echo.Context) error {
 		return utils.Response(c, http.StatusUnauthorized, "Invalid email or password", nil, err, nil)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"role":    user.Role,
-		// "exp":     time.Now().Add(time.Hour * 24).Unix(),
+	// Setiap login memulai keluarga refresh token baru
+	var data dto.TokenResponse
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		issued, _, err := issueTokens(tx, user, uuid.New(), now.Add(auth.RefreshTokenTTL()), now)
+		data = issued
+		return err
 	})
-
-	tokenString, err := token.SignedString(jwtSecret)
 	if err != nil {
 		return utils.Response(c, http.StatusInternalServerError, "Failed to generate token", nil, err, nil)
 	}
 
-	data := map[string]string{"token": tokenString}
 	return utils.Response(c, http.StatusOK, "Login successful", data, nil, nil)
 }
 
@@ -182,10 +181,36 @@ func ChangePassword(c echo.Context) error {
 		return utils.Response(c, http.StatusInternalServerError, "Error hashing new password", nil, err, nil)
 	}
 
-	user.Password = string(hashedPassword)
-	if err := db.DB.Save(&user).Error; err != nil {
+	// Semua sesi lama berakhir: TokenVersion dinaikkan agar access token lama ditolak dan
+	// refresh token lama dicabut. Perangkat yang mengganti password mendapat token baru.
+	var data dto.TokenResponse
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":      string(hashedPassword),
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("token_version").First(&user, "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := revokeUserRefreshTokens(tx, user.ID, now); err != nil {
+			return err
+		}
+		issued, _, err := issueTokens(tx, user, uuid.New(), now.Add(auth.RefreshTokenTTL()), now)
+		data = issued
+		return err
+	})
+	if err != nil {
 		return utils.Response(c, http.StatusInternalServerError, "Failed to update password", nil, err, nil)
 	}
 
-	return utils.Response(c, http.StatusOK, "Password updated successfully", nil, nil, nil)
+	// Versi di database sudah naik, sama seperti RevokeUserSessions versi baru harus sampai ke Redis
+	if err := auth.SetTokenVersion(user.ID, user.TokenVersion); err != nil {
+		return utils.Response(c, http.StatusInternalServerError, "Failed to propagate session revocation", nil, err, nil)
+	}
+
+	return utils.Response(c, http.StatusOK, "Password updated successfully", data, nil, nil)
 }
@@ -4,10 +4,12 @@ import (
 	"aro-shop/cache"
 	"aro-shop/config"
 	"aro-shop/db"
+	"aro-shop/handler"
 	"aro-shop/middlewares"
 	"aro-shop/queue"
 	"aro-shop/routes"
 	"aro-shop/seeder"
+	"aro-shop/storage"
 	"fmt"
 	"os"
 
@@ -41,11 +43,15 @@ func main() {
 
 	cache.InitRedis()
 
+	storage.InitStorage()
+
 	queue.InitRabbitMQ()
 	defer queue.CloseRabbitMQ()
 
 	go queue.StartWorker()
 
+	go handler.StartPriceScheduler()
+
 	if len(os.Args) > 1 && os.Args[1] == "seeder" {
 		seeder.CreateSuperAdminIfNotExists()
 		seeder.SeedPaymentMethods()
@@ -1,19 +1,16 @@
 package middlewares
 
 import (
-	"aro-shop/config"
+	"aro-shop/auth"
 	"aro-shop/utils"
 	"aro-shop/dto"
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
-)
-
-var (
-	cfg       = config.LoadConfig()
-	jwtSecret = []byte(cfg.JWTSecret)
+	"gorm.io/gorm"
 )
 
 func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
@@ -39,9 +36,8 @@ func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return utils.Response(c, http.StatusUnauthorized, "Token tidak valid", nil, nil, errorDetails)
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
+		// Wajib HS256 dengan klaim exp, nbf, iss, dan aud yang valid
+		token, err := auth.ParseAccessToken(tokenString)
 
 		if err != nil {
 			log.Println("Error parsing token:", err)
@@ -66,6 +62,24 @@ func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return utils.Response(c, http.StatusUnauthorized, "User ID tidak ditemukan dalam token", nil, nil, errorDetails)
 		}
 
+		// Tolak token yang sudah logout atau sesinya dicabut admin
+		session, err := auth.SessionFromToken(token)
+		if err != nil {
+			errorDetails["jwt"] = "Klaim sesi token tidak valid"
+			return utils.Response(c, http.StatusUnauthorized, "Token tidak valid", nil, err, errorDetails)
+		}
+		if err := auth.CheckSession(session); errors.Is(err, auth.ErrTokenRevoked) {
+			errorDetails["jwt"] = "Token sudah logout atau sesinya dicabut"
+			return utils.Response(c, http.StatusUnauthorized, "Token tidak valid atau sudah kedaluwarsa", nil, err, errorDetails)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			errorDetails["jwt"] = "User tidak ditemukan"
+			return utils.Response(c, http.StatusUnauthorized, "Token tidak valid", nil, err, errorDetails)
+		} else if err != nil {
+			log.Println("Error checking token session:", err)
+			errorDetails["session"] = "Gagal memeriksa status sesi"
+			return utils.Response(c, http.StatusServiceUnavailable, "Layanan sedang tidak tersedia", nil, err, errorDetails)
+		}
+
 		// Set data user ke context
 		c.Set("user", token)
 		c.Set("user_id", userID)
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ProductTypeSimple = "simple"
+	ProductTypeBundle = "bundle"
+)
+
+// BundleComponent adalah produk penyusun sebuah bundle beserta jumlahnya
+type BundleComponent struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	BundleID    uuid.UUID `json:"bundle_id" gorm:"type:uuid;not null;index"`
+	ComponentID uuid.UUID `json:"component_id" gorm:"type:uuid;not null;index"`
+	Component   Product   `json:"component" gorm:"foreignKey:ComponentID"`
+	Quantity    Quantity  `json:"quantity" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TransactionItemComponent adalah salinan komponen bundle saat dijual, berisi jumlah
+// yang harus dikurangi dari stok dan porsi pendapatan untuk laporan per komponen
+type TransactionItemComponent struct {
+	ID                uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TransactionItemID uuid.UUID `json:"transaction_item_id" gorm:"type:uuid;not null;index"`
+	ProductID         uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product           Product   `json:"product" gorm:"foreignKey:ProductID"`
+	Quantity          Quantity  `json:"quantity" gorm:"not null"`
+	AllocatedRevenue  float64   `json:"allocated_revenue" gorm:"type:numeric(10,2);not null;default:0"`
+	CreatedAt         time.Time `json:"created_at"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Location adalah outlet atau gudang yang menyimpan stok. Lokasi default menerima
+// perubahan stok yang tidak menyebut lokasi, misalnya dari kasir tanpa outlet.
+type Location struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name      string         `json:"name" gorm:"type:varchar(255);not null"`
+	Code      string         `json:"code" gorm:"type:varchar(20);not null;index"`
+	Address   string         `json:"address" gorm:"type:text"`
+	IsDefault bool           `json:"is_default" gorm:"not null;default:false"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// LocationStock adalah stok satu produk (atau varian) di satu lokasi. Product.Stock dan
+// ProductVariant.Stock tetap menyimpan total seluruh lokasi.
+type LocationStock struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	LocationID uuid.UUID  `json:"location_id" gorm:"type:uuid;not null;index"`
+	Location   Location   `json:"-" gorm:"foreignKey:LocationID;constraint:OnDelete:RESTRICT"`
+	ProductID  uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product    Product    `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	VariantID  *uuid.UUID `json:"variant_id" gorm:"type:uuid;index"`
+	Quantity   Quantity   `json:"quantity" gorm:"not null;default:0"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Status transfer stok antar lokasi. Stok berkurang di lokasi asal saat dikirim
+// dan baru bertambah di lokasi tujuan setelah penerimaan dikonfirmasi.
+const (
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+	TransferCancelled = "cancelled"
+)
+
+type StockTransfer struct {
+	ID             uuid.UUID           `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	FromLocationID uuid.UUID           `json:"from_location_id" gorm:"type:uuid;not null;index"`
+	FromLocation   Location            `json:"from_location" gorm:"foreignKey:FromLocationID;constraint:OnDelete:RESTRICT"`
+	ToLocationID   uuid.UUID           `json:"to_location_id" gorm:"type:uuid;not null;index"`
+	ToLocation     Location            `json:"to_location" gorm:"foreignKey:ToLocationID;constraint:OnDelete:RESTRICT"`
+	Status         string              `json:"status" gorm:"type:varchar(20);not null;default:'in_transit';index"`
+	Note           string              `json:"note" gorm:"type:text"`
+	CreatedBy      *uuid.UUID          `json:"created_by" gorm:"type:uuid"`
+	ReceivedBy     *uuid.UUID          `json:"received_by" gorm:"type:uuid"`
+	ReceivedAt     *time.Time          `json:"received_at"`
+	Lines          []StockTransferLine `json:"lines" gorm:"foreignKey:TransferID;constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+type StockTransferLine struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TransferID uuid.UUID       `json:"transfer_id" gorm:"type:uuid;not null;index"`
+	ProductID  uuid.UUID       `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product    Product         `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:RESTRICT"`
+	VariantID  *uuid.UUID      `json:"variant_id" gorm:"type:uuid"`
+	Variant    *ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+	Quantity   Quantity        `json:"quantity" gorm:"not null"`
+}
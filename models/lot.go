@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockLot adalah satu batch barang dengan nomor lot dan tanggal kedaluwarsa di satu lokasi.
+// Quantity adalah sisa lot; jumlah semua lot tidak pernah melebihi stok lokasi, selisihnya
+// adalah stok tanpa lot (misalnya stok lama sebelum pelacakan lot diaktifkan).
+type StockLot struct {
+	ID               uuid.UUID       `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ProductID        uuid.UUID       `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product          Product         `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	VariantID        *uuid.UUID      `json:"variant_id" gorm:"type:uuid;index"`
+	Variant          *ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+	LocationID       uuid.UUID       `json:"location_id" gorm:"type:uuid;not null;index"`
+	Location         Location        `json:"-" gorm:"foreignKey:LocationID;constraint:OnDelete:RESTRICT"`
+	LotNumber        string          `json:"lot_number" gorm:"type:varchar(64);not null;index"`
+	ExpiresAt        *time.Time      `json:"expires_at" gorm:"type:date;index"`
+	Quantity         Quantity        `json:"quantity" gorm:"not null"`
+	ReceivedQuantity Quantity        `json:"received_quantity" gorm:"not null"`
+	ReceiptLineID    *uuid.UUID      `json:"receipt_line_id" gorm:"type:uuid"` // baris penerimaan barang asal lot
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// ExpiredOn menandakan lot sudah lewat tanggal kedaluwarsa pada hari day.
+// Barang masih boleh dijual pada tanggal kedaluwarsanya.
+func (l StockLot) ExpiredOn(day time.Time) bool {
+	return l.ExpiresAt != nil && LotDate(day).After(LotDate(*l.ExpiresAt))
+}
+
+// LotDate membuang jam dari t, tanggal disimpan sebagai tengah malam UTC
+// agar kolom date tidak bergeser karena zona waktu
+func LotDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// StockLotMovement mencatat perubahan sisa lot, dipakai untuk menelusuri penjualan
+// sebuah lot (misalnya saat penarikan produk) dan memindahkan lot saat transfer
+type StockLotMovement struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	LotID       uuid.UUID `json:"lot_id" gorm:"type:uuid;not null;index"`
+	Lot         StockLot  `json:"-" gorm:"foreignKey:LotID;constraint:OnDelete:CASCADE"`
+	Delta       Quantity  `json:"delta" gorm:"not null"`
+	Reason      string    `json:"reason" gorm:"type:varchar(20);not null"`
+	ReferenceID string    `json:"reference_id" gorm:"type:varchar(64);index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sumber perubahan harga pada riwayat harga
+const (
+	PriceSourceManual   = "manual"
+	PriceSourceBulk     = "bulk"
+	PriceSourceImport   = "import"
+	PriceSourceSchedule = "schedule"
+)
+
+// Status jadwal perubahan harga
+const (
+	PriceSchedulePending   = "pending"
+	PriceScheduleApplied   = "applied"
+	PriceScheduleCancelled = "cancelled"
+	PriceScheduleFailed    = "failed"
+)
+
+// ProductPriceHistory mencatat setiap perubahan harga produk atau varian.
+// Untuk varian, harga yang dicatat adalah harga efektif (termasuk harga warisan produk induk).
+type ProductPriceHistory struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ProductID uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index:idx_price_history_product,priority:1"`
+	VariantID *uuid.UUID `json:"variant_id" gorm:"type:uuid;index"`
+	OldPrice  float64    `json:"old_price" gorm:"type:numeric(10,2);not null"`
+	NewPrice  float64    `json:"new_price" gorm:"type:numeric(10,2);not null"`
+	Source    string     `json:"source" gorm:"type:varchar(20);not null;default:'manual'"`
+	ChangedBy *uuid.UUID `json:"changed_by" gorm:"type:uuid"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:ChangedBy;constraint:OnDelete:SET NULL"`
+	Product   Product    `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index:idx_price_history_product,priority:2"`
+}
+
+// ScheduledPriceChange adalah harga baru yang berlaku otomatis pada waktu tertentu
+type ScheduledPriceChange struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ProductID   uuid.UUID       `json:"product_id" gorm:"type:uuid;not null;index"`
+	VariantID   *uuid.UUID      `json:"variant_id" gorm:"type:uuid"`
+	Variant     *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE"`
+	Product     Product         `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Price       float64         `json:"price" gorm:"type:numeric(10,2);not null"`
+	EffectiveAt time.Time       `json:"effective_at" gorm:"not null;index:idx_price_schedule_due,priority:2"`
+	Status      string          `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_price_schedule_due,priority:1"`
+	CreatedBy   *uuid.UUID      `json:"created_by" gorm:"type:uuid"`
+	AppliedAt   *time.Time      `json:"applied_at"`
+	Error       string          `json:"error" gorm:"type:text"` // alasan jadwal berstatus failed
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
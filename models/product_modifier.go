@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModifierGroup adalah kelompok pilihan tambahan pada produk, contoh: "Extra" atau "Jenis Susu".
+// MaxSelect = 0 berarti tidak dibatasi.
+type ModifierGroup struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ProductID uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
+	Name      string     `json:"name" gorm:"type:varchar(100);not null"`
+	MinSelect int        `json:"min_select" gorm:"not null;default:0"`
+	MaxSelect int        `json:"max_select" gorm:"not null;default:0"`
+	Modifiers []Modifier `json:"modifiers" gorm:"foreignKey:GroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type Modifier struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	GroupID    uuid.UUID `json:"group_id" gorm:"type:uuid;not null;index"`
+	Name       string    `json:"name" gorm:"type:varchar(100);not null"`
+	PriceDelta float64   `json:"price_delta" gorm:"type:numeric(10,2);not null;default:0"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TransactionItemModifier menyimpan salinan modifier yang dipilih saat transaksi,
+// sehingga riwayat tidak berubah walaupun modifier diubah atau dihapus
+type TransactionItemModifier struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TransactionItemID uuid.UUID  `json:"transaction_item_id" gorm:"type:uuid;not null;index"`
+	ModifierID        *uuid.UUID `json:"modifier_id" gorm:"type:uuid"`
+	GroupName         string     `json:"group_name" gorm:"type:varchar(100)"`
+	Name              string     `json:"name" gorm:"type:varchar(100);not null"`
+	PriceDelta        float64    `json:"price_delta" gorm:"type:numeric(10,2);not null;default:0"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductOptionType adalah jenis opsi pada produk, contoh: "Size" atau "Color"
+type ProductOptionType struct {
+	ID        uuid.UUID            `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ProductID uuid.UUID            `json:"product_id" gorm:"type:uuid;not null;index"`
+	Name      string               `json:"name" gorm:"type:varchar(100);not null"`
+	Values    []ProductOptionValue `json:"values" gorm:"foreignKey:OptionTypeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// ProductOptionValue adalah nilai dari sebuah opsi, contoh: "L" atau "Merah"
+type ProductOptionValue struct {
+	ID           uuid.UUID          `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OptionTypeID uuid.UUID          `json:"option_type_id" gorm:"type:uuid;not null;index"`
+	OptionType   *ProductOptionType `json:"option_type,omitempty" gorm:"foreignKey:OptionTypeID"`
+	Value        string             `json:"value" gorm:"type:varchar(100);not null"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// ProductVariant adalah kombinasi opsi yang dijual dengan SKU, harga, dan stok sendiri
+type ProductVariant struct {
+	ID           uuid.UUID            `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ProductID    uuid.UUID            `json:"product_id" gorm:"type:uuid;not null;index"`
+	SKU          string               `json:"sku" gorm:"type:varchar(64);index"`
+	Barcode      string               `json:"barcode" gorm:"type:varchar(64);index"`
+	Price        *float64             `json:"price" gorm:"type:numeric(10,2)"`
+	Stock        Quantity             `json:"stock" gorm:"not null;default:0"`
+	OptionValues []ProductOptionValue `json:"option_values" gorm:"many2many:product_variant_option_values;constraint:OnDelete:CASCADE"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+// EffectivePrice mengembalikan harga varian, atau harga produk induk jika tidak di-override
+func (v ProductVariant) EffectivePrice(parentPrice float64) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return parentPrice
+}
+
+// Name menggabungkan nilai opsi varian, contoh: "L / Merah"
+func (v ProductVariant) Name() string {
+	values := make([]string, 0, len(v.OptionValues))
+	for _, ov := range v.OptionValues {
+		values = append(values, ov.Value)
+	}
+	return strings.Join(values, " / ")
+}
@@ -5,19 +5,81 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Product struct {
-	ID          uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	Name        string    `json:"name" validate:"required" gorm:"type:varchar(255);not null"`
-	Description string    `json:"description" gorm:"type:text"`
-	URLImage    string    `json:"url_image" validate:"required,url" gorm:"type:text"`
-	Price       float64   `json:"price" validate:"required,gt=0" gorm:"type:numeric(10,2);not null"`
-	Stock       int       `json:"stock" validate:"required,gte=0" gorm:"not null"`
-	CategoryID  uuid.UUID `json:"category_id" gorm:"type:uuid;not null;index"`
-	Category    Category  `json:"category" gorm:"foreignKey:CategoryID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               uuid.UUID           `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name             string              `json:"name" validate:"required" gorm:"type:varchar(255);not null"`
+	Description      string              `json:"description" gorm:"type:text"`
+	Type             string              `json:"type" gorm:"type:varchar(20);not null;default:'simple'"`
+	SKU              string              `json:"sku" gorm:"type:varchar(64);index"`
+	Barcode          string              `json:"barcode" gorm:"type:varchar(64);index"`
+	URLImage         string              `json:"url_image" validate:"required,url" gorm:"type:text"`
+	ImageMediumURL   string              `json:"image_medium_url" gorm:"type:text"`
+	ImageThumbURL    string              `json:"image_thumb_url" gorm:"type:text"`
+	Price            float64             `json:"price" validate:"required,gt=0" gorm:"type:numeric(10,2);not null"`
+	CostPrice        float64             `json:"cost_price" gorm:"type:numeric(10,2);not null;default:0"`
+	Stock            Quantity            `json:"stock" validate:"gte=0" gorm:"not null;default:0"`
+	Unit             string              `json:"unit" gorm:"type:varchar(10);not null;default:'pcs'"`
+	Precision        int                 `json:"precision" gorm:"not null;default:0"`
+	PLU              string              `json:"plu" gorm:"type:varchar(5);index"`
+	TrackLots        bool                `json:"track_lots" gorm:"not null;default:false"` // wajib nomor lot dan kedaluwarsa saat penerimaan
+	ReorderPoint     Quantity            `json:"reorder_point" gorm:"not null;default:0"`  // 0 berarti tanpa peringatan stok menipis
+	ReorderQty       Quantity            `json:"reorder_qty" gorm:"not null;default:0"`
+	CategoryID       uuid.UUID           `json:"category_id" gorm:"type:uuid;not null;index"`
+	Category         Category            `json:"category" gorm:"foreignKey:CategoryID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	OptionTypes      []ProductOptionType `json:"option_types,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Variants         []ProductVariant    `json:"variants,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	ModifierGroups   []ModifierGroup     `json:"modifier_groups,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	BundleComponents []BundleComponent   `json:"bundle_components,omitempty" gorm:"foreignKey:BundleID;constraint:OnDelete:CASCADE"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt      `json:"deleted_at" gorm:"index"`
+}
+
+// IsBundle menandakan produk adalah paket yang stoknya diambil dari komponen
+func (p Product) IsBundle() bool {
+	return p.Type == ProductTypeBundle
+}
+
+// OnHand adalah stok produk, untuk produk bervarian total stok semua varian.
+// Variants harus sudah di-preload.
+func (p Product) OnHand() Quantity {
+	if len(p.Variants) == 0 {
+		return p.Stock
+	}
+	var total Quantity
+	for _, variant := range p.Variants {
+		total += variant.Stock
+	}
+	return total
+}
+
+// CrossedReorderPoint menandakan penjualan sebanyak sold baru saja membuat stok turun
+// di bawah titik pemesanan ulang. Stok yang sudah di bawah titik tersebut tidak dihitung lagi.
+func (p Product) CrossedReorderPoint(sold Quantity) bool {
+	onHand := p.OnHand()
+	return p.ReorderPoint > 0 && onHand < p.ReorderPoint && onHand+sold >= p.ReorderPoint
+}
+
+// BundleAvailability menghitung berapa paket utuh yang bisa dijual dari stok komponen.
+// BundleComponents beserta Component harus sudah di-preload.
+func (p Product) BundleAvailability() Quantity {
+	available := int64(-1)
+	for _, component := range p.BundleComponents {
+		if component.Quantity <= 0 {
+			continue
+		}
+		count := component.Component.Stock.Div(component.Quantity)
+		if available < 0 || count < available {
+			available = count
+		}
+	}
+	if available < 0 {
+		return 0
+	}
+	return Quantity(available * quantityFactor)
 }
 
 var Validate = validator.New()
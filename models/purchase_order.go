@@ -0,0 +1,123 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status purchase order: draft → sent → partially_received → received → closed.
+// PO yang baru diterima sebagian juga boleh ditutup bila sisa barang tidak akan datang.
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderClosed            = "closed"
+)
+
+type PurchaseOrder struct {
+	ID         uuid.UUID           `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Number     string              `json:"number" gorm:"type:varchar(32);not null;uniqueIndex"`
+	SupplierID uuid.UUID           `json:"supplier_id" gorm:"type:uuid;not null;index"`
+	Supplier   Supplier            `json:"supplier" gorm:"foreignKey:SupplierID;constraint:OnDelete:RESTRICT"`
+	LocationID *uuid.UUID          `json:"location_id" gorm:"type:uuid"`
+	Status     string              `json:"status" gorm:"type:varchar(20);not null;default:'draft';index"`
+	Note       string              `json:"note" gorm:"type:text"`
+	ExpectedAt *time.Time          `json:"expected_at"`
+	CreatedBy  *uuid.UUID          `json:"created_by" gorm:"type:uuid"`
+	SentAt     *time.Time          `json:"sent_at"`
+	ReceivedAt *time.Time          `json:"received_at"`
+	ClosedAt   *time.Time          `json:"closed_at"`
+	Lines      []PurchaseOrderLine `json:"lines" gorm:"foreignKey:PurchaseOrderID;constraint:OnDelete:CASCADE"`
+	Receipts   []GoodsReceipt      `json:"receipts,omitempty" gorm:"foreignKey:PurchaseOrderID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+// Total menjumlahkan nilai seluruh baris PO (kuantitas dipesan × harga beli)
+func (po PurchaseOrder) Total() float64 {
+	var total float64
+	for _, line := range po.Lines {
+		total += line.Total()
+	}
+	return total
+}
+
+// FullyReceived menandakan semua baris sudah diterima sesuai jumlah pesanan
+func (po PurchaseOrder) FullyReceived() bool {
+	for _, line := range po.Lines {
+		if line.Remaining() > 0 {
+			return false
+		}
+	}
+	return len(po.Lines) > 0
+}
+
+type PurchaseOrderLine struct {
+	ID               uuid.UUID       `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	PurchaseOrderID  uuid.UUID       `json:"purchase_order_id" gorm:"type:uuid;not null;index"`
+	LineNo           int             `json:"line_no" gorm:"not null"`
+	ProductID        uuid.UUID       `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product          Product         `json:"product" gorm:"foreignKey:ProductID;constraint:OnDelete:RESTRICT"`
+	VariantID        *uuid.UUID      `json:"variant_id" gorm:"type:uuid"`
+	Variant          *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	Quantity         Quantity        `json:"quantity" gorm:"not null"`
+	ReceivedQuantity Quantity        `json:"received_quantity" gorm:"not null;default:0"`
+	UnitCost         float64         `json:"unit_cost" gorm:"type:numeric(10,2);not null"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// Remaining adalah jumlah yang belum diterima, tidak pernah negatif
+func (l PurchaseOrderLine) Remaining() Quantity {
+	if l.ReceivedQuantity >= l.Quantity {
+		return 0
+	}
+	return l.Quantity - l.ReceivedQuantity
+}
+
+func (l PurchaseOrderLine) Total() float64 {
+	return l.Quantity.Float64() * l.UnitCost
+}
+
+// GoodsReceipt adalah satu kali penerimaan barang untuk sebuah PO. Satu PO bisa
+// memiliki beberapa penerimaan bila supplier mengirim secara bertahap.
+type GoodsReceipt struct {
+	ID              uuid.UUID          `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	PurchaseOrderID uuid.UUID          `json:"purchase_order_id" gorm:"type:uuid;not null;index"`
+	Note            string             `json:"note" gorm:"type:text"`
+	ReceivedBy      *uuid.UUID         `json:"received_by" gorm:"type:uuid"`
+	Lines           []GoodsReceiptLine `json:"lines" gorm:"foreignKey:GoodsReceiptID;constraint:OnDelete:CASCADE"`
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
+type GoodsReceiptLine struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	GoodsReceiptID      uuid.UUID  `json:"goods_receipt_id" gorm:"type:uuid;not null;index"`
+	PurchaseOrderLineID uuid.UUID  `json:"purchase_order_line_id" gorm:"type:uuid;not null;index"`
+	Quantity            Quantity   `json:"quantity" gorm:"not null"`
+	UnitCost            float64    `json:"unit_cost" gorm:"type:numeric(10,2);not null"`
+	LotNumber           string     `json:"lot_number" gorm:"type:varchar(64)"`
+	ExpiresAt           *time.Time `json:"expires_at" gorm:"type:date"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// MovingAverageCost menghitung harga pokok rata-rata setelah menerima barang baru.
+// Stok lama yang kosong atau negatif tidak ikut dihitung sehingga harga pokok
+// langsung mengikuti harga beli terakhir.
+func MovingAverageCost(stock Quantity, cost float64, received Quantity, unitCost float64) float64 {
+	if received <= 0 {
+		return cost
+	}
+	if stock <= 0 {
+		return roundCost(unitCost)
+	}
+	total := stock.Float64()*cost + received.Float64()*unitCost
+	return roundCost(total / (stock + received).Float64())
+}
+
+func roundCost(value float64) float64 {
+	return math.Round(value*100) / 100
+}
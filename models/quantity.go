@@ -0,0 +1,185 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// QuantityScale adalah jumlah digit desimal maksimal untuk kuantitas dan stok
+const QuantityScale = 3
+
+const quantityFactor = 1000
+
+var ErrInvalidQuantity = errors.New("kuantitas tidak valid, maksimal 3 angka desimal")
+
+// Quantity adalah bilangan desimal fixed-point (disimpan dalam satuan 1/1000)
+// agar aritmatika stok selalu eksak, contoh: 0.35 kg disimpan sebagai 350.
+// Di database disimpan sebagai numeric(14,3), di JSON sebagai angka biasa.
+type Quantity int64
+
+func NewQuantity(n int) Quantity {
+	return Quantity(int64(n) * quantityFactor)
+}
+
+// ParseQuantity mem-parse teks desimal tanpa melalui float agar tidak ada pembulatan
+func ParseQuantity(s string) (Quantity, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidQuantity
+	}
+
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > QuantityScale {
+		return 0, ErrInvalidQuantity
+	}
+	fraction += strings.Repeat("0", QuantityScale-len(fraction))
+
+	wholeValue, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return 0, ErrInvalidQuantity
+	}
+	fractionValue, err := strconv.ParseUint(fraction, 10, 16)
+	if err != nil {
+		return 0, ErrInvalidQuantity
+	}
+	if wholeValue > math.MaxInt64/quantityFactor-1 {
+		return 0, ErrInvalidQuantity
+	}
+
+	q := Quantity(int64(wholeValue)*quantityFactor + int64(fractionValue))
+	if negative {
+		q = -q
+	}
+	return q, nil
+}
+
+// QuantityFromFloat mengubah float menjadi kuantitas, dibulatkan ke 3 angka desimal
+func QuantityFromFloat(f float64) Quantity {
+	return Quantity(math.Round(f * quantityFactor))
+}
+
+// RoundTo membulatkan kuantitas ke jumlah digit desimal tertentu
+func (q Quantity) RoundTo(precision int) Quantity {
+	if precision >= QuantityScale {
+		return q
+	}
+	if precision < 0 {
+		precision = 0
+	}
+	step := int64(math.Pow10(QuantityScale - precision))
+	return Quantity(int64(math.Round(float64(q)/float64(step))) * step)
+}
+
+func (q Quantity) String() string {
+	sign := ""
+	value := int64(q)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	whole := value / quantityFactor
+	fraction := value % quantityFactor
+	if fraction == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	return strings.TrimRight(fmt.Sprintf("%s%d.%03d", sign, whole, fraction), "0")
+}
+
+func (q Quantity) Float64() float64 {
+	return float64(q) / quantityFactor
+}
+
+// Floor mengembalikan bagian bulat dari kuantitas
+func (q Quantity) Floor() int64 {
+	return int64(q) / quantityFactor
+}
+
+// Mul mengalikan kuantitas dengan bilangan bulat, contoh: komponen bundle x jumlah bundle
+func (q Quantity) Mul(n Quantity) Quantity {
+	return Quantity(int64(q) * int64(n) / quantityFactor)
+}
+
+// Div mengembalikan berapa kali n muat di dalam q (dibulatkan ke bawah)
+func (q Quantity) Div(n Quantity) int64 {
+	if n <= 0 {
+		return 0
+	}
+	return int64(q) / int64(n)
+}
+
+// FitsPrecision mengecek apakah kuantitas tidak melebihi jumlah digit desimal yang diizinkan
+func (q Quantity) FitsPrecision(precision int) bool {
+	if precision >= QuantityScale {
+		return true
+	}
+	if precision < 0 {
+		precision = 0
+	}
+	step := int64(math.Pow10(QuantityScale - precision))
+	return int64(q)%step == 0
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" || text == "" {
+		*q = 0
+		return nil
+	}
+	parsed, err := ParseQuantity(text)
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+func (q Quantity) Value() (driver.Value, error) {
+	return q.String(), nil
+}
+
+func (q *Quantity) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*q = 0
+		return nil
+	case int64:
+		*q = Quantity(v * quantityFactor)
+		return nil
+	case float64:
+		*q = Quantity(math.Round(v * quantityFactor))
+		return nil
+	case []byte:
+		parsed, err := ParseQuantity(string(v))
+		*q = parsed
+		return err
+	case string:
+		parsed, err := ParseQuantity(v)
+		*q = parsed
+		return err
+	}
+	return fmt.Errorf("tidak dapat membaca quantity dari %T", value)
+}
+
+// GormDataType membuat AutoMigrate memakai numeric(14,3) untuk setiap kolom Quantity
+func (Quantity) GormDataType() string {
+	return "numeric(14,3)"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken disimpan dalam bentuk hash. Setiap login memulai satu keluarga (FamilyID)
+// dan setiap rotasi menambah anggota baru; token yang sudah dipakai (UsedAt) lalu
+// dipakai lagi dianggap dicuri sehingga seluruh keluarganya dicabut.
+type RefreshToken struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User         *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	FamilyID     uuid.UUID  `json:"family_id" gorm:"type:uuid;not null;index"`
+	TokenHash    string     `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt       *time.Time `json:"used_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uuid.UUID `json:"replaced_by_id" gorm:"type:uuid"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Usable berarti token belum dipakai, belum dicabut, dan belum kedaluwarsa pada now
+func (t RefreshToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kode alasan penyesuaian stok manual
+const (
+	AdjustmentReasonDamaged    = "damaged"
+	AdjustmentReasonExpired    = "expired"
+	AdjustmentReasonTheft      = "theft"
+	AdjustmentReasonFound      = "found"
+	AdjustmentReasonCorrection = "correction"
+)
+
+// Status penyesuaian stok. Penyesuaian di bawah ambang batas langsung applied,
+// selebihnya pending sampai disetujui atau ditolak superAdmin.
+const (
+	AdjustmentStatusPending  = "pending"
+	AdjustmentStatusApplied  = "applied"
+	AdjustmentStatusRejected = "rejected"
+)
+
+type StockAdjustment struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ProductID   uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product     Product    `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	VariantID   *uuid.UUID `json:"variant_id" gorm:"type:uuid"`
+	LocationID  *uuid.UUID `json:"location_id" gorm:"type:uuid"`
+	Quantity    Quantity   `json:"quantity" gorm:"not null"`
+	Reason      string     `json:"reason" gorm:"type:varchar(20);not null"`
+	Note        string     `json:"note" gorm:"type:text"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	RequestedBy *uuid.UUID `json:"requested_by" gorm:"type:uuid"`
+	ReviewedBy  *uuid.UUID `json:"reviewed_by" gorm:"type:uuid"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	ReviewNote  string     `json:"review_note" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NeedsApproval menandakan penyesuaian melebihi ambang batas (nilai absolut)
+func (a StockAdjustment) NeedsApproval(threshold Quantity) bool {
+	quantity := a.Quantity
+	if quantity < 0 {
+		quantity = -quantity
+	}
+	return quantity > threshold
+}
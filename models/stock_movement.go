@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alasan perubahan stok pada ledger stock_movements
+const (
+	StockReasonSale       = "sale"
+	StockReasonRefund     = "refund"
+	StockReasonAdjustment = "adjustment"
+	StockReasonReceipt    = "receipt"
+	StockReasonTransfer   = "transfer"
+	StockReasonStocktake  = "stocktake"
+)
+
+// StockMovement adalah satu baris ledger stok yang hanya boleh ditambah (append-only).
+// ID berurutan dipakai untuk menghitung saldo berjalan sesuai urutan kejadian.
+type StockMovement struct {
+	ID          uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProductID   uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index:idx_stock_movements_product,priority:1"`
+	VariantID   *uuid.UUID `json:"variant_id" gorm:"type:uuid;index"`
+	LocationID  *uuid.UUID `json:"location_id" gorm:"type:uuid;index"`
+	Delta       Quantity   `json:"delta" gorm:"not null"`
+	Reason      string     `json:"reason" gorm:"type:varchar(20);not null;index"`
+	ReferenceID string     `json:"reference_id" gorm:"type:varchar(64);index"`
+	Note        string     `json:"note" gorm:"type:text"`
+	UserID      *uuid.UUID `json:"user_id" gorm:"type:uuid"`
+	User        *User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index:idx_stock_movements_product,priority:2"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status sesi stock opname. Hanya boleh ada satu sesi open dalam satu waktu.
+const (
+	StocktakeOpen      = "open"
+	StocktakeFinalized = "finalized"
+	StocktakeCancelled = "cancelled"
+)
+
+// Stocktake adalah satu sesi stock opname. Saat sesi dimulai stok setiap item dibekukan
+// sebagai ExpectedQuantity dan posisi ledger dicatat di MovementCursor, sehingga penjualan
+// selama penghitungan bisa diperhitungkan saat menghitung selisih.
+type Stocktake struct {
+	ID             uuid.UUID       `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Status         string          `json:"status" gorm:"type:varchar(20);not null;default:'open'"`
+	Note           string          `json:"note" gorm:"type:text"`
+	LocationID     *uuid.UUID      `json:"location_id" gorm:"type:uuid"`
+	CategoryID     *uuid.UUID      `json:"category_id" gorm:"type:uuid"`
+	MovementCursor uint64          `json:"movement_cursor" gorm:"not null;default:0"`
+	StartedBy      *uuid.UUID      `json:"started_by" gorm:"type:uuid"`
+	FinalizedBy    *uuid.UUID      `json:"finalized_by" gorm:"type:uuid"`
+	FinalizedAt    *time.Time      `json:"finalized_at"`
+	Items          []StocktakeItem `json:"items,omitempty" gorm:"foreignKey:StocktakeID;constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// StocktakeItem adalah satu produk (atau varian) yang dihitung. CountedQuantity adalah
+// total semua hitungan, CountCursor adalah posisi ledger saat hitungan terakhir masuk.
+type StocktakeItem struct {
+	ID               uuid.UUID       `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	StocktakeID      uuid.UUID       `json:"stocktake_id" gorm:"type:uuid;not null;index"`
+	ProductID        uuid.UUID       `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product          Product         `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	VariantID        *uuid.UUID      `json:"variant_id" gorm:"type:uuid;index"`
+	Variant          *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE"`
+	ExpectedQuantity Quantity        `json:"expected_quantity" gorm:"not null"`
+	CostPrice        float64         `json:"cost_price" gorm:"type:numeric(10,2);not null;default:0"`
+	CountedQuantity  Quantity        `json:"counted_quantity" gorm:"not null;default:0"`
+	Counts           int             `json:"counts" gorm:"not null;default:0"`
+	CountCursor      uint64          `json:"count_cursor" gorm:"not null;default:0"`
+	PostedVariance   *Quantity       `json:"posted_variance"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// Counted menandakan item sudah dihitung minimal sekali
+func (i StocktakeItem) Counted() bool {
+	return i.Counts > 0
+}
+
+// StocktakeCount adalah satu hitungan yang dikirim petugas. Satu item boleh dihitung
+// beberapa kali (misalnya rak depan dan gudang), hasilnya dijumlahkan.
+type StocktakeCount struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	StocktakeID uuid.UUID      `json:"stocktake_id" gorm:"type:uuid;not null;index"`
+	ItemID      uuid.UUID      `json:"item_id" gorm:"type:uuid;not null;index"`
+	Item        *StocktakeItem `json:"-" gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE"`
+	Quantity    Quantity       `json:"quantity" gorm:"not null"`
+	Note        string         `json:"note" gorm:"type:varchar(255)"`
+	CountedBy   *uuid.UUID     `json:"counted_by" gorm:"type:uuid"`
+	User        *User          `json:"user,omitempty" gorm:"foreignKey:CountedBy;constraint:OnDelete:SET NULL"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Supplier struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name         string         `json:"name" gorm:"type:varchar(255);not null"`
+	ContactName  string         `json:"contact_name" gorm:"type:varchar(255)"`
+	Phone        string         `json:"phone" gorm:"type:varchar(50)"`
+	Email        string         `json:"email" gorm:"type:varchar(255)"`
+	Address      string         `json:"address" gorm:"type:text"`
+	LeadTimeDays int            `json:"lead_time_days" gorm:"not null;default:0"`
+	Note         string         `json:"note" gorm:"type:text"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
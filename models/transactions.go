@@ -9,22 +9,28 @@ import (
 type Transaction struct {
 	ID         uuid.UUID         `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	UserID     uuid.UUID         `json:"user_id" gorm:"type:uuid;not null"`
+	LocationID *uuid.UUID        `json:"location_id" gorm:"type:uuid;index"`
 	User       User              `json:"user" gorm:"foreignKey:UserID;references:ID"`
 	Date       time.Time         `json:"date" gorm:"autoCreateTime"`
 	AmountPaid float64           `json:"amount_paid" gorm:"type:numeric(10,2);not null"`
 	Items      []TransactionItem `json:"items" gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Payment    *Payment           `json:"payment,omitempty" gorm:"foreignKey:TransactionID;references:ID"`
+	Payment    *Payment          `json:"payment,omitempty" gorm:"foreignKey:TransactionID;references:ID"`
 	CreatedAt  time.Time         `json:"created_at"`
 	UpdatedAt  time.Time         `json:"updated_at"`
 }
 
 type TransactionItem struct {
-	ID            uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	TransactionID uuid.UUID `json:"transaction_id" gorm:"type:uuid;not null"`
-	ProductID     uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
-	Product       Product   `json:"product" gorm:"foreignKey:ProductID"`
-	Quantity      int       `json:"quantity" gorm:"not null"`
-	SubTotal      float64   `json:"subtotal" gorm:"type:numeric(10,2);not null"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uuid.UUID                  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TransactionID uuid.UUID                  `json:"transaction_id" gorm:"type:uuid;not null"`
+	ProductID     uuid.UUID                  `json:"product_id" gorm:"type:uuid;not null"`
+	Product       Product                    `json:"product" gorm:"foreignKey:ProductID"`
+	VariantID     *uuid.UUID                 `json:"variant_id" gorm:"type:uuid;index"`
+	Variant       *ProductVariant            `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	Quantity      Quantity                   `json:"quantity" gorm:"not null"`
+	SubTotal      float64                    `json:"subtotal" gorm:"type:numeric(10,2);not null"`
+	Note          string                     `json:"note" gorm:"type:varchar(255)"`
+	Modifiers     []TransactionItemModifier  `json:"modifiers,omitempty" gorm:"foreignKey:TransactionItemID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Components    []TransactionItemComponent `json:"components,omitempty" gorm:"foreignKey:TransactionItemID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
 }
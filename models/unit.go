@@ -0,0 +1,42 @@
+package models
+
+// Satuan produk yang didukung
+const (
+	UnitPcs      = "pcs"
+	UnitKilogram = "kg"
+	UnitGram     = "g"
+	UnitLiter    = "l"
+	UnitMeter    = "m"
+)
+
+// DefaultPrecision mengembalikan jumlah digit desimal bawaan untuk setiap satuan
+func DefaultPrecision(unit string) int {
+	switch unit {
+	case UnitKilogram, UnitLiter:
+		return 3
+	case UnitMeter:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// IsValidUnit mengecek apakah satuan didukung
+func IsValidUnit(unit string) bool {
+	switch unit {
+	case UnitPcs, UnitKilogram, UnitGram, UnitLiter, UnitMeter:
+		return true
+	}
+	return false
+}
+
+// QuantityFromScaleValue mengubah nilai dari barcode timbangan (gram, mililiter, atau
+// milimeter) menjadi kuantitas dalam satuan produk
+func QuantityFromScaleValue(unit string, value int64) Quantity {
+	switch unit {
+	case UnitKilogram, UnitLiter, UnitMeter:
+		return Quantity(value)
+	default:
+		return Quantity(value * quantityFactor)
+	}
+}
@@ -16,13 +16,15 @@ const (
 )
 
 type User struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	Name      string    `json:"name" gorm:"not null"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	Role      Role      `json:"role" gorm:"type:varchar(10);not null;default:'user'"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name         string     `json:"name" gorm:"not null"`
+	Email        string     `json:"email" gorm:"unique;not null"`
+	Password     string     `json:"-" gorm:"not null"`
+	Role         Role       `json:"role" gorm:"type:varchar(10);not null;default:'user'"`
+	LocationID   *uuid.UUID `json:"location_id" gorm:"type:uuid;index"` // outlet tempat user bertugas
+	TokenVersion int        `json:"-" gorm:"not null;default:0"`        // dinaikkan untuk mencabut semua sesi user
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
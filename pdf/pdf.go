@@ -0,0 +1,158 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Ukuran kertas dalam point (1 pt = 1/72 inch)
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Document adalah penulis PDF sederhana tanpa dependensi eksternal.
+// Hanya mendukung teks Helvetica (font standar PDF), garis, dan kotak —
+// cukup untuk label barcode dan dokumen cetak seperti purchase order.
+type Document struct {
+	width  float64
+	height float64
+	pages  []*Page
+}
+
+type Page struct {
+	content bytes.Buffer
+}
+
+func NewDocument(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+func (d *Document) Width() float64  { return d.width }
+func (d *Document) Height() float64 { return d.height }
+
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Text menulis teks dengan titik (x, y) dari kiri-bawah halaman
+func (p *Page) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
+
+// Rect menggambar kotak terisi warna hitam
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f %.3f re f\n", x, y, w, h)
+}
+
+// StrokeRect menggambar garis tepi kotak
+func (p *Page) StrokeRect(x, y, w, h, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f %.2f %.2f re S\n", lineWidth, x, y, w, h)
+}
+
+func (p *Page) Line(x1, y1, x2, y2, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", lineWidth, x1, y1, x2, y2)
+}
+
+// TextWidth memperkirakan lebar teks Helvetica (rata-rata 0.5 em per karakter)
+func TextWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))) * size * 0.5
+}
+
+// Truncate memotong teks agar muat pada lebar tertentu
+func Truncate(s string, size, maxWidth float64) string {
+	if TextWidth(s, size) <= maxWidth {
+		return s
+	}
+	maxChars := int(maxWidth/(size*0.5)) - 3
+	if maxChars < 1 {
+		return ""
+	}
+	return string([]rune(s)[:maxChars]) + "..."
+}
+
+func (d *Document) Write(w io.Writer) error {
+	var (
+		buf     bytes.Buffer
+		offsets []int
+	)
+
+	writeObj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objek 1: catalog, 2: pages, 3-4: font, lalu pasangan page + content
+	pageCount := len(d.pages)
+	if pageCount == 0 {
+		d.AddPage()
+		pageCount = 1
+	}
+
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, p := range d.pages {
+		writeObj(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			d.width, d.height, 6+i*2,
+		))
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// escape meng-escape karakter khusus string PDF dan mengganti karakter non-Latin-1
+func escape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r < 32:
+			sb.WriteByte(' ')
+		case r > 255:
+			sb.WriteByte('?')
+		case r > 126:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -10,10 +10,15 @@ import (
 func SetupRoutes(e *echo.Echo) {
 	e.POST("/api/auth/register", handler.Register)
 	e.POST("/api/auth/login", handler.Login)
+	e.POST("/api/auth/refresh", handler.RefreshToken)
+
+	e.GET("/media/*", handler.GetMedia)
 
 	superAdminGroup := e.Group("")
 	superAdminGroup.Use(middlewares.JWTMiddleware, middlewares.RoleMiddleware("superAdmin"))
 	superAdminGroup.POST("/api/auth/register/admin", handler.RegisterAdmin)
+	superAdminGroup.POST("/api/stock-adjustments/:id/approve", handler.ApproveStockAdjustment)
+	superAdminGroup.POST("/api/stock-adjustments/:id/reject", handler.RejectStockAdjustment)
 
 	// e.POST("/api/init-superadmin", handler.RegisterAdmin)
 
@@ -21,9 +26,13 @@ func SetupRoutes(e *echo.Echo) {
 	authGroup.Use(middlewares.JWTMiddleware)
 
 	authGroup.POST("/auth/change-password", handler.ChangePassword)
+	authGroup.POST("/auth/logout", handler.Logout)
 
 	authGroup.GET("/products", handler.GetProducts)
+	authGroup.GET("/products/scan", handler.ScanProduct)
+	authGroup.GET("/products/search", handler.SearchProducts)
 	authGroup.GET("/product/:id", handler.GetProductByID)
+	authGroup.GET("/product/:id/variants", handler.GetProductVariants)
 	authGroup.GET("/category-products", handler.GetCategoriesWithProducts)
 
 	authGroup.GET("/transactions", handler.GetTransactions)
@@ -38,23 +47,104 @@ func SetupRoutes(e *echo.Echo) {
 	authGroup.PUT("/notification/:id/read", handler.MarkNotificationAsRead)
 
 	authGroup.GET("/categories", handler.GetCategories)
+	authGroup.GET("/categories/tree", handler.GetCategoryTree)
 	authGroup.GET("/categories/:id", handler.GetCategoriesById)
 
 	authGroup.GET("/paymentMethods", handler.GetPaymentMethods)
 	authGroup.GET("/paymentMethods/:id", handler.GetPaymentMethod)
 	authGroup.POST("/paymentMethods", handler.CreatePaymentMethod)
 
+	// Daftar penyesuaian stok untuk admin dan superAdmin (role dicek di handler)
+	authGroup.GET("/stock-adjustments", handler.GetStockAdjustments)
+
+	// Semua staf boleh mengirim hitungan stock opname
+	authGroup.POST("/stocktakes/:id/counts", handler.SubmitStocktakeCount)
+	authGroup.GET("/stocktakes/:id/counts", handler.GetStocktakeCounts)
+	authGroup.DELETE("/stocktakes/:id/counts/:count_id", handler.DeleteStocktakeCount)
+
+	authGroup.GET("/locations", handler.GetLocations)
+	// Staf outlet tujuan mengonfirmasi penerimaan transfer
+	authGroup.POST("/stock-transfers/:id/receive", handler.ReceiveStockTransfer)
+
 	adminGroup := e.Group("/api")
 	adminGroup.Use(middlewares.JWTMiddleware, middlewares.RoleMiddleware("admin"))
 
 	adminGroup.POST("/product", handler.CreateProduct)
 	adminGroup.PUT("/product/:id", handler.UpdateProduct)
 	adminGroup.DELETE("/product/:id", handler.DeleteProduct)
+	adminGroup.DELETE("/product/:id/image", handler.DeleteProductImage)
+	adminGroup.POST("/product/:id/restore", handler.RestoreProduct)
+	adminGroup.GET("/product/:id/barcode", handler.GetProductBarcode)
+	adminGroup.POST("/products/labels", handler.GenerateLabelSheet)
+	adminGroup.POST("/products/import", handler.ImportProducts)
+	adminGroup.GET("/products/import/:job_id", handler.GetProductImportJob)
+	adminGroup.GET("/products/export", handler.ExportProducts)
+	adminGroup.POST("/products/prices/bulk", handler.BulkUpdatePrices)
+	adminGroup.GET("/product/:id/price-history", handler.GetProductPriceHistory)
+	adminGroup.GET("/product/:id/stock-movements", handler.GetProductStockMovements)
+	adminGroup.POST("/product/:id/stock-adjustments", handler.CreateStockAdjustment)
+	adminGroup.GET("/product/:id/lots", handler.GetProductLots)
+	adminGroup.GET("/product/:id/price-schedules", handler.GetProductPriceSchedules)
+	adminGroup.POST("/product/:id/price-schedules", handler.CreateProductPriceSchedule)
+	adminGroup.DELETE("/product/:id/price-schedules/:schedule_id", handler.CancelProductPriceSchedule)
+
+	adminGroup.POST("/product/:id/options", handler.CreateProductOptionType)
+	adminGroup.DELETE("/product/:id/options/:option_id", handler.DeleteProductOptionType)
+	adminGroup.POST("/product/:id/variants", handler.CreateProductVariant)
+	adminGroup.PUT("/product/:id/variants/:variant_id", handler.UpdateProductVariant)
+	adminGroup.DELETE("/product/:id/variants/:variant_id", handler.DeleteProductVariant)
+
+	adminGroup.POST("/product/:id/modifier-groups", handler.CreateModifierGroup)
+	adminGroup.PUT("/product/:id/modifier-groups/:group_id", handler.UpdateModifierGroup)
+	adminGroup.DELETE("/product/:id/modifier-groups/:group_id", handler.DeleteModifierGroup)
+
+	adminGroup.PUT("/product/:id/bundle", handler.SetProductBundle)
+	adminGroup.DELETE("/product/:id/bundle", handler.RemoveProductBundle)
+
+	adminGroup.GET("/reports/bundles", handler.GetBundleRevenueReport)
+	adminGroup.GET("/reports/expiring-lots", handler.GetExpiringLots)
+	adminGroup.GET("/reports/reorder-suggestions", handler.GetReorderSuggestions)
 
 	adminGroup.POST("/categories", handler.CreateCategory)
 	adminGroup.PUT("/categories/:id", handler.UpdateCategory)
 	adminGroup.DELETE("/categories/:id", handler.DeleteCategory)
+	adminGroup.POST("/categories/:id/restore", handler.RestoreCategory)
+
+	adminGroup.POST("/locations", handler.CreateLocation)
+	adminGroup.PUT("/locations/:id", handler.UpdateLocation)
+	adminGroup.DELETE("/locations/:id", handler.DeleteLocation)
+	adminGroup.GET("/locations/:id/stock", handler.GetLocationStock)
+	adminGroup.PUT("/users/:id/location", handler.UpdateUserLocation)
+	adminGroup.POST("/users/:id/revoke-sessions", handler.RevokeUserSessions)
+
+	adminGroup.GET("/stock-transfers", handler.GetStockTransfers)
+	adminGroup.GET("/stock-transfers/:id", handler.GetStockTransfer)
+	adminGroup.POST("/stock-transfers", handler.CreateStockTransfer)
+	adminGroup.POST("/stock-transfers/:id/cancel", handler.CancelStockTransfer)
+
+	adminGroup.GET("/suppliers", handler.GetSuppliers)
+	adminGroup.GET("/suppliers/:id", handler.GetSupplier)
+	adminGroup.POST("/suppliers", handler.CreateSupplier)
+	adminGroup.PUT("/suppliers/:id", handler.UpdateSupplier)
+	adminGroup.DELETE("/suppliers/:id", handler.DeleteSupplier)
+
+	adminGroup.GET("/purchase-orders", handler.GetPurchaseOrders)
+	adminGroup.GET("/purchase-orders/:id", handler.GetPurchaseOrder)
+	adminGroup.POST("/purchase-orders", handler.CreatePurchaseOrder)
+	adminGroup.PUT("/purchase-orders/:id", handler.UpdatePurchaseOrder)
+	adminGroup.DELETE("/purchase-orders/:id", handler.DeletePurchaseOrder)
+	adminGroup.POST("/purchase-orders/:id/send", handler.SendPurchaseOrder)
+	adminGroup.POST("/purchase-orders/:id/receipts", handler.ReceivePurchaseOrder)
+	adminGroup.POST("/purchase-orders/:id/close", handler.ClosePurchaseOrder)
+	adminGroup.GET("/purchase-orders/:id/export", handler.ExportPurchaseOrder)
+
+	adminGroup.GET("/stocktakes", handler.GetStocktakes)
+	adminGroup.POST("/stocktakes", handler.StartStocktake)
+	adminGroup.GET("/stocktakes/:id/variance", handler.GetStocktakeVariance)
+	adminGroup.POST("/stocktakes/:id/finalize", handler.FinalizeStocktake)
+	adminGroup.POST("/stocktakes/:id/cancel", handler.CancelStocktake)
 
 	adminGroup.PUT("/paymentMethods/:id", handler.UpdatePaymentMethod)
 	adminGroup.DELETE("/paymentMethods/:id", handler.DeletePaymentMethod)
+	adminGroup.POST("/paymentMethods/:id/restore", handler.RestorePaymentMethod)
 }
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"aro-shop/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("file tidak ditemukan")
+	ErrInvalidKey = errors.New("key file tidak valid")
+)
+
+// Storage menyimpan file upload (misalnya gambar produk). Key adalah nama file relatif
+// tanpa awalan "/", URL adalah alamat baca yang stabil dan boleh disimpan di database.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+	// KeyFromURL mengembalikan key dari URL milik storage ini, false untuk URL lain
+	KeyFromURL(url string) (string, bool)
+}
+
+// Presigner diimplementasikan storage yang bisa membuat URL baca sementara
+type Presigner interface {
+	PresignGet(key string, expiry time.Duration) (string, error)
+}
+
+// Files adalah storage aktif yang dipilih lewat STORAGE_DRIVER
+var Files Storage
+
+func InitStorage() {
+	store, err := New(config.LoadConfig())
+	if err != nil {
+		log.Fatalf("❌ Gagal menyiapkan storage: %v", err)
+	}
+	Files = store
+
+	log.Printf("🚀 Storage %T siap!", store)
+}
+
+// New membuat storage sesuai konfigurasi: local (default) atau s3
+func New(cfg config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		return NewLocalStorage("public/uploads", cfg.BaseURL+"public/uploads/"), nil
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+			return nil, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY, dan S3_SECRET_KEY wajib diisi")
+		}
+		return NewS3Storage(S3Config{
+			Endpoint:     cfg.S3Endpoint,
+			Region:       cfg.S3Region,
+			Bucket:       cfg.S3Bucket,
+			AccessKey:    cfg.S3AccessKey,
+			SecretKey:    cfg.S3SecretKey,
+			UsePathStyle: cfg.S3UsePathStyle,
+			PublicURL:    cfg.BaseURL + "media/",
+		})
+	}
+	return nil, fmt.Errorf("STORAGE_DRIVER %q tidak dikenal, gunakan local atau s3", cfg.StorageDriver)
+}
+
+// ValidKey menolak key yang bisa keluar dari folder atau bucket, misalnya "../.env"
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
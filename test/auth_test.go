@@ -0,0 +1,359 @@
+package test
+
+import (
+	"aro-shop/auth"
+	"aro-shop/cache"
+	"aro-shop/config"
+	"aro-shop/db"
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.LoadConfig().JWTSecret))
+	assert.NoError(t, err)
+	return tokenString
+}
+
+func TestIssuedAccessTokenIsAccepted(t *testing.T) {
+	userID := uuid.New()
+	now := time.Now()
+
+	tokenString, expiresAt, err := auth.IssueAccessToken(userID, string(models.RoleAdmin), 3, now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(auth.AccessTokenTTL()).Unix(), expiresAt.Unix())
+
+	token, err := auth.ParseAccessToken(tokenString)
+	assert.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, userID.String(), claims["user_id"])
+	assert.Equal(t, "admin", claims["role"])
+
+	session, err := auth.SessionFromToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, session.UserID)
+	assert.Equal(t, 3, session.Version)
+	assert.Equal(t, expiresAt.Unix(), session.ExpiresAt.Unix())
+	assert.NotEmpty(t, session.JTI)
+
+	// Setiap token punya jti sendiri agar bisa di-logout satu per satu
+	other, _, err := auth.IssueAccessToken(userID, string(models.RoleAdmin), 3, now)
+	assert.NoError(t, err)
+	otherToken, err := auth.ParseAccessToken(other)
+	assert.NoError(t, err)
+	otherSession, err := auth.SessionFromToken(otherToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, session.JTI, otherSession.JTI)
+}
+
+func TestParseAccessTokenRejectsInvalidClaims(t *testing.T) {
+	cfg := config.LoadConfig()
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"jti":     uuid.NewString(),
+			"ver":     0,
+			"user_id": uuid.New().String(),
+			"role":    "user",
+			"iss":     cfg.JWTIssuer,
+			"aud":     cfg.JWTAudience,
+			"iat":     now.Unix(),
+			"nbf":     now.Unix(),
+			"exp":     now.Add(time.Minute).Unix(),
+		}
+	}
+
+	_, err := auth.ParseAccessToken(signTestToken(t, valid()))
+	assert.NoError(t, err)
+
+	cases := map[string]func(jwt.MapClaims){
+		"kedaluwarsa":   func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() },
+		"tanpa exp":     func(c jwt.MapClaims) { delete(c, "exp") },
+		"belum berlaku": func(c jwt.MapClaims) { c["nbf"] = now.Add(time.Hour).Unix() },
+		"tanpa nbf":     func(c jwt.MapClaims) { delete(c, "nbf") },
+		"issuer lain":   func(c jwt.MapClaims) { c["iss"] = "other-app" },
+		"audience lain": func(c jwt.MapClaims) { c["aud"] = "other-api" },
+		"token lama polos": func(c jwt.MapClaims) {
+			for _, key := range []string{"jti", "ver", "iss", "aud", "iat", "nbf", "exp"} {
+				delete(c, key)
+			}
+		},
+	}
+	for name, mutate := range cases {
+		claims := valid()
+		mutate(claims)
+		_, err := auth.ParseAccessToken(signTestToken(t, claims))
+		assert.Error(t, err, name)
+	}
+
+	// Tanda tangan dengan secret lain ditolak
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid()).SignedString([]byte("secret-lain"))
+	assert.NoError(t, err)
+	_, err = auth.ParseAccessToken(forged)
+	assert.Error(t, err)
+}
+
+func TestRefreshTokenIsStoredAsHash(t *testing.T) {
+	plain, hash, err := auth.NewRefreshToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, plain, hash)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, auth.HashRefreshToken(plain))
+
+	other, _, err := auth.NewRefreshToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, plain, other)
+}
+
+func TestRefreshTokenUsable(t *testing.T) {
+	now := time.Now()
+	token := models.RefreshToken{ExpiresAt: now.Add(time.Hour)}
+	assert.True(t, token.Usable(now))
+
+	used := token
+	used.UsedAt = &now
+	assert.False(t, used.Usable(now))
+
+	revoked := token
+	revoked.RevokedAt = &now
+	assert.False(t, revoked.Usable(now))
+
+	assert.False(t, token.Usable(now.Add(time.Hour)))
+}
+
+// setupSessionRedis mengganti Redis dengan miniredis untuk satu test
+func setupSessionRedis(t *testing.T) *miniredis.Miniredis {
+	server := miniredis.RunT(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	return server
+}
+
+func postJSON(t *testing.T, h echo.HandlerFunc, body interface{}, setup func(c echo.Context)) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	if setup != nil {
+		setup(c)
+	}
+	assert.NoError(t, h(c))
+	return rec
+}
+
+func refreshTokenRows(id, userID, familyID uuid.UUID, expiresAt time.Time, usedAt *time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "family_id", "token_hash", "expires_at", "used_at", "revoked_at"}).
+		AddRow(id, userID, familyID, "hash", expiresAt, usedAt, nil)
+}
+
+func TestRefreshTokenRotatesWithinFamily(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	currentID, userID, familyID := uuid.New(), uuid.New(), uuid.New()
+	familyExpiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "refresh_tokens" WHERE token_hash = \$1 .*FOR UPDATE`).
+		WithArgs(auth.HashRefreshToken("old-token"), 1).
+		WillReturnRows(refreshTokenRows(currentID, userID, familyID, familyExpiresAt, nil))
+	mock.ExpectQuery(`FROM "users" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "token_version"}).AddRow(userID, models.RoleAdmin, 2))
+	// Token baru tetap di keluarga yang sama dan tidak memperpanjang masa berlakunya
+	mock.ExpectQuery(`INSERT INTO "refresh_tokens"`).
+		WithArgs(userID, familyID, sqlmock.AnyArg(), familyExpiresAt, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE "refresh_tokens" SET "replaced_by_id"=\$1,"used_at"=\$2 WHERE "id" = \$3`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), currentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rec := postJSON(t, handler.RefreshToken, dto.RefreshTokenRequest{RefreshToken: "old-token"}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data dto.TokenResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.Data.RefreshToken)
+	assert.NotEqual(t, "old-token", response.Data.RefreshToken)
+	assert.Equal(t, familyExpiresAt.Unix(), response.Data.RefreshExpiresAt.Unix())
+
+	token, err := auth.ParseAccessToken(response.Data.Token)
+	if assert.NoError(t, err) {
+		session, _ := auth.SessionFromToken(token)
+		assert.Equal(t, userID, session.UserID)
+		assert.Equal(t, 2, session.Version)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenReuseRevokesWholeFamily(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	currentID, userID, familyID := uuid.New(), uuid.New(), uuid.New()
+	usedAt := time.Now().Add(-time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "refresh_tokens" WHERE token_hash = \$1 .*FOR UPDATE`).
+		WillReturnRows(refreshTokenRows(currentID, userID, familyID, time.Now().Add(time.Hour), &usedAt))
+	// Pencabutan keluarga tetap di-commit meskipun request ditolak
+	mock.ExpectExec(`UPDATE "refresh_tokens" SET "revoked_at"=\$1 WHERE family_id = \$2 AND revoked_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), familyID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	rec := postJSON(t, handler.RefreshToken, dto.RefreshTokenRequest{RefreshToken: "stolen-token"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Refresh token sudah pernah dipakai")
+	assert.NotContains(t, rec.Body.String(), `"token"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRejectsUnknownOrRevokedToken(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "refresh_tokens" WHERE token_hash = \$1`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	rec := postJSON(t, handler.RefreshToken, dto.RefreshTokenRequest{RefreshToken: "unknown"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock = SetupPostgresMock(t)
+	revokedAt := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "refresh_tokens" WHERE token_hash = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "family_id", "expires_at", "revoked_at"}).
+			AddRow(uuid.New(), uuid.New(), uuid.New(), time.Now().Add(time.Hour), revokedAt))
+	mock.ExpectRollback()
+
+	rec = postJSON(t, handler.RefreshToken, dto.RefreshTokenRequest{RefreshToken: "revoked"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangePasswordEndsExistingSessions(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	redisServer := setupSessionRedis(t)
+	userID := uuid.New()
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("rahasia-lama"), bcrypt.MinCost)
+
+	mock.ExpectQuery(`FROM "users" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "password", "token_version"}).AddRow(userID, models.RoleAdmin, string(hashed), 4))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "password"=\$1,"token_version"=token_version \+ 1`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT "token_version" FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(5))
+	mock.ExpectExec(`UPDATE "refresh_tokens" SET "revoked_at"=\$1 WHERE user_id = \$2 AND revoked_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`INSERT INTO "refresh_tokens"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	request := dto.ChangePasswordRequest{OldPassword: "rahasia-lama", NewPassword: "rahasia-baru", ConfirmPassword: "rahasia-baru"}
+	rec := postJSON(t, handler.ChangePassword, request, func(c echo.Context) { c.Set("user_id", userID.String()) })
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Versi baru sampai ke Redis sehingga access token versi 4 ditolak di semua instance
+	version, err := redisServer.Get("auth:token_version:" + userID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "5", version)
+	assert.ErrorIs(t, auth.CheckSession(auth.Session{JTI: uuid.NewString(), UserID: userID, Version: 4, ExpiresAt: time.Now().Add(time.Minute)}), auth.ErrTokenRevoked)
+
+	// Perangkat yang mengganti password mendapat token versi baru
+	var response struct {
+		Data dto.TokenResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	token, err := auth.ParseAccessToken(response.Data.Token)
+	if assert.NoError(t, err) {
+		session, _ := auth.SessionFromToken(token)
+		assert.Equal(t, 5, session.Version)
+		assert.NoError(t, auth.CheckSession(session))
+	}
+}
+
+func testSession(userID uuid.UUID, version int) auth.Session {
+	return auth.Session{JTI: uuid.NewString(), UserID: userID, Version: version, ExpiresAt: time.Now().Add(time.Minute)}
+}
+
+func TestCheckSessionReadsVersionFromDatabaseOnce(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	redisServer := setupSessionRedis(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT "token_version" FROM "users" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(2))
+
+	assert.NoError(t, auth.CheckSession(testSession(userID, 2)))
+	assert.ErrorIs(t, auth.CheckSession(testSession(userID, 1)), auth.ErrTokenRevoked)
+
+	// Versi disalin ke Redis, request berikutnya tidak ke database
+	version, err := redisServer.Get("auth:token_version:" + userID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "2", version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckSessionUsesVersionRaisedConcurrently(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	redisServer := setupSessionRedis(t)
+	userID := uuid.New()
+	key := "auth:token_version:" + userID.String()
+
+	mock.ExpectQuery(`SELECT "token_version" FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(3))
+	// RevokeUserSessions di instance lain menaikkan versi ke 4 setelah versi 3 dibaca dari database
+	assert.NoError(t, db.DB.Callback().Query().After("gorm:query").Register("test:revoke_concurrently", func(*gorm.DB) {
+		redisServer.Set(key, "4")
+	}))
+
+	assert.ErrorIs(t, auth.CheckSession(testSession(userID, 3)), auth.ErrTokenRevoked)
+
+	// Versi basi dari database tidak menimpa Redis maupun cache lokal
+	version, _ := redisServer.Get(key)
+	assert.Equal(t, "4", version)
+	assert.NoError(t, auth.CheckSession(testSession(userID, 4)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogoutDenylistsTokenUntilItExpires(t *testing.T) {
+	SetupPostgresMock(t)
+	redisServer := setupSessionRedis(t)
+	userID := uuid.New()
+	assert.NoError(t, auth.SetTokenVersion(userID, 1))
+
+	session := testSession(userID, 1)
+	other := testSession(userID, 1)
+	assert.NoError(t, auth.CheckSession(session))
+	assert.NoError(t, auth.Logout(session))
+
+	assert.ErrorIs(t, auth.CheckSession(session), auth.ErrTokenRevoked)
+	assert.NoError(t, auth.CheckSession(other))
+	assert.True(t, redisServer.Exists("auth:denylist:"+session.JTI))
+	ttl := redisServer.TTL("auth:denylist:" + session.JTI)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+	// Token yang sudah kedaluwarsa tidak perlu masuk denylist
+	expired := testSession(userID, 1)
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	assert.NoError(t, auth.Logout(expired))
+	assert.False(t, redisServer.Exists("auth:denylist:"+expired.JTI))
+}
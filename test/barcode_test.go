@@ -0,0 +1,144 @@
+package test
+
+import (
+	"aro-shop/barcode"
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"aro-shop/pdf"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEAN13CheckDigit(t *testing.T) {
+	code, err := barcode.NormalizeEAN13("400638133393")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "4006381333931", code)
+	}
+
+	_, err = barcode.NormalizeEAN13("4006381333932")
+	assert.ErrorIs(t, err, barcode.ErrEAN13Checksum)
+
+	_, err = barcode.NormalizeEAN13("40063813339A")
+	assert.ErrorIs(t, err, barcode.ErrInvalidEAN13)
+}
+
+func TestEncodeEAN13(t *testing.T) {
+	modules, err := barcode.EncodeEAN13("4006381333931")
+	if assert.NoError(t, err) {
+		// 3 guard kiri + 6*7 + 5 guard tengah + 6*7 + 3 guard kanan
+		assert.Len(t, modules, 95)
+		assert.Equal(t, []bool{true, false, true}, modules[:3])
+		assert.Equal(t, []bool{true, false, true}, modules[92:])
+	}
+}
+
+func TestEncodeCode128(t *testing.T) {
+	// Start B + 3 karakter + checksum = 5 simbol x 11 modul + stop 13 modul
+	modules, err := barcode.EncodeCode128("ABC")
+	if assert.NoError(t, err) {
+		assert.Len(t, modules, 5*11+13)
+	}
+
+	// "123456" memakai Code C: start + 3 pasang digit + checksum
+	modules, err = barcode.EncodeCode128("123456")
+	if assert.NoError(t, err) {
+		assert.Len(t, modules, 5*11+13)
+	}
+
+	_, err = barcode.EncodeCode128("é")
+	assert.ErrorIs(t, err, barcode.ErrInvalidCode128)
+}
+
+func TestBarcodePNGAndSVG(t *testing.T) {
+	code, err := barcode.Encode(barcode.TypeCode128, "SKU-001")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	image, err := code.PNG(2, 60)
+	if assert.NoError(t, err) {
+		assert.True(t, bytes.HasPrefix(image, []byte("\x89PNG")))
+	}
+	assert.Contains(t, string(code.SVG(2, 60)), "SKU-001")
+}
+
+func TestPDFDocument(t *testing.T) {
+	doc := pdf.NewDocument(pdf.A4Width, pdf.A4Height)
+	page := doc.AddPage()
+	page.Text(20, 800, 12, true, "Label (test)")
+	page.Rect(20, 700, 2, 40)
+
+	content, err := doc.Bytes()
+	if assert.NoError(t, err) {
+		assert.True(t, bytes.HasPrefix(content, []byte("%PDF-1.4")))
+		assert.True(t, bytes.HasSuffix(content, []byte("%%EOF\n")))
+		assert.Contains(t, string(content), `(Label \(test\)) Tj`)
+	}
+}
+
+func TestParseScaleBarcode(t *testing.T) {
+	// prefix 21, PLU 12345, berat 00750 gram
+	code, err := barcode.NormalizeEAN13("211234500750")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, barcode.IsScaleBarcode(code))
+
+	scale, err := barcode.ParseScaleBarcode(code, barcode.ScaleModeWeight)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "21", scale.Prefix)
+		assert.Equal(t, "12345", scale.ItemCode)
+		assert.Equal(t, int64(750), scale.Value)
+		assert.Equal(t, models.Quantity(750), models.QuantityFromScaleValue(models.UnitKilogram, scale.Value))
+	}
+
+	assert.False(t, barcode.IsScaleBarcode("4006381333931"))
+	_, err = barcode.ParseScaleBarcode("4006381333931", barcode.ScaleModeWeight)
+	assert.ErrorIs(t, err, barcode.ErrNotScaleBarcode)
+
+	bad := code[:12] + string('0'+(code[12]-'0'+1)%10)
+	_, err = barcode.ParseScaleBarcode(bad, barcode.ScaleModePrice)
+	assert.ErrorIs(t, err, barcode.ErrEAN13Checksum)
+}
+
+func TestProductPLURuleMatchesOnCreateAndUpdate(t *testing.T) {
+	validate := validator.New()
+	base := dto.ProductRequest{Name: "Apel", Price: 30000, CategoryID: uuid.New()}
+	for plu, valid := range map[string]bool{"12345": true, "ab-12": false, "-1234": false, "1234": false} {
+		request := base
+		request.PLU = plu
+		assert.Equal(t, valid, validate.Struct(request) == nil, "create plu %q", plu)
+		if valid {
+			continue
+		}
+
+		// PLU yang ditolak saat membuat produk juga ditolak saat mengubah produk
+		mock := SetupPostgresMock(t)
+		productID := uuid.New()
+		mock.ExpectQuery(`FROM "products" WHERE id = \$1`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(productID, "Apel", 30000.0))
+
+		form := url.Values{"plu": {plu}}
+		req := httptest.NewRequest(http.MethodPut, "/products/"+productID.String(), strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(productID.String())
+		assert.NoError(t, handler.UpdateProduct(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "update plu %q", plu)
+		assert.Contains(t, rec.Body.String(), "PLU must be 5 digits")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"aro-shop/cache"
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"aro-shop/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "espresso-based", utils.Slugify("Espresso-based"))
+	assert.Equal(t, "kopi-susu-gula-aren", utils.Slugify("  Kopi Susu (Gula Aren)! "))
+	assert.Equal(t, "", utils.Slugify("!!!"))
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	beverages := uuid.New()
+	coffee := uuid.New()
+	tea := uuid.New()
+	espresso := uuid.New()
+	food := uuid.New()
+
+	tree, nodes := dto.BuildCategoryTree([]models.Category{
+		{ID: espresso, Name: "Espresso-based", ParentID: &coffee},
+		{ID: tea, Name: "Tea", ParentID: &beverages, SortOrder: 2},
+		{ID: food, Name: "Food", SortOrder: 1},
+		{ID: coffee, Name: "Coffee", ParentID: &beverages, SortOrder: 1},
+		{ID: beverages, Name: "Beverages"},
+	})
+
+	if assert.Len(t, tree, 2) {
+		assert.Equal(t, "Beverages", tree[0].Name)
+		assert.Equal(t, "Food", tree[1].Name)
+	}
+	if assert.Len(t, tree[0].Children, 2) {
+		assert.Equal(t, "Coffee", tree[0].Children[0].Name)
+		assert.Equal(t, "Tea", tree[0].Children[1].Name)
+		assert.Equal(t, "Espresso-based", tree[0].Children[0].Children[0].Name)
+	}
+	assert.Len(t, nodes, 5)
+	assert.Same(t, tree[0].Children[0], nodes[coffee])
+}
+
+func TestCategoryLookupResolve(t *testing.T) {
+	beverages := uuid.New()
+	food := uuid.New()
+	hotDrinks := uuid.New()
+	hotFood := uuid.New()
+	tea := uuid.New()
+
+	lookup := dto.NewCategoryLookup([]models.Category{
+		{ID: beverages, Name: "Beverages", Slug: "beverages"},
+		{ID: food, Name: "Food", Slug: "food"},
+		{ID: hotDrinks, Name: "Hot", Slug: "hot", ParentID: &beverages},
+		{ID: hotFood, Name: "Hot", Slug: "hot-2", ParentID: &food},
+		{ID: tea, Name: "Tea", Slug: "tea", ParentID: &hotDrinks},
+	})
+
+	id, found, err := lookup.Resolve(" tea ")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, tea, id)
+
+	// Nama yang dipakai dua kategori tidak ditebak
+	_, found, err = lookup.Resolve("Hot")
+	assert.ErrorIs(t, err, dto.ErrCategoryAmbiguous)
+	assert.False(t, found)
+
+	id, found, err = lookup.Resolve("hot-2")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, hotFood, id)
+
+	id, found, err = lookup.Resolve("Beverages / Hot")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, hotDrinks, id)
+
+	id, found, err = lookup.Resolve("beverages/hot/tea")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, tea, id)
+
+	_, found, err = lookup.Resolve("Food/Tea")
+	assert.ErrorIs(t, err, dto.ErrCategoryPathNotFound)
+	assert.False(t, found)
+
+	// Nama baru tanpa path boleh dibuat sebagai kategori root
+	_, found, err = lookup.Resolve("Snacks")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	// Reference dipakai export: nama jika unik, path jika nama dipakai beberapa kategori
+	assert.Equal(t, "Tea", lookup.Reference(tea))
+	assert.Equal(t, "Beverages/Hot", lookup.Reference(hotDrinks))
+	assert.Equal(t, "Food/Hot", lookup.Reference(hotFood))
+	assert.Equal(t, "", lookup.Reference(uuid.New()))
+}
+
+func deleteCategory(t *testing.T, id uuid.UUID, rawQuery string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/categories/"+id.String()+"?"+rawQuery, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id.String())
+	assert.NoError(t, handler.DeleteCategory(c))
+	return rec
+}
+
+func TestDeleteCategoryRefusesWhileProductsUseIt(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	categoryID := uuid.New()
+
+	mock.ExpectQuery(`FROM "categories" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(categoryID, "Coffee"))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "categories" WHERE parent_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "products" WHERE category_id = \$1`).
+		WithArgs(categoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectRollback()
+
+	rec := deleteCategory(t, categoryID, "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "3 products still use this category")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCategoryReassignsArchivedProductsToo(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	categoryID := uuid.New()
+	targetID := uuid.New()
+
+	mock.ExpectQuery(`FROM "categories" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(categoryID, "Coffee"))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "categories" WHERE parent_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`FROM "categories" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(targetID, "Beverages"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "products" WHERE category_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	// Tanpa filter deleted_at: produk yang diarsipkan (1 dari 3) ikut dipindahkan
+	mock.ExpectExec(`UPDATE "products" SET "category_id"=\$1,"updated_at"=\$2 WHERE category_id = \$3$`).
+		WithArgs(targetID, sqlmock.AnyArg(), categoryID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE "categories" SET "deleted_at"=\$1 WHERE "categories"."id" = \$2`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rec := deleteCategory(t, categoryID, "reassign_to="+targetID.String())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"products_moved":3`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"aro-shop/utils"
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectImageType(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	var pngData, jpegData bytes.Buffer
+	assert.NoError(t, png.Encode(&pngData, img))
+	assert.NoError(t, jpeg.Encode(&jpegData, img, nil))
+	webpData := append([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), make([]byte, 16)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		mime string
+		ext  string
+	}{
+		{"png", pngData.Bytes(), "image/png", ".png"},
+		{"jpeg", jpegData.Bytes(), "image/jpeg", ".jpg"},
+		{"webp", webpData, "image/webp", ".webp"},
+	}
+	for _, tt := range tests {
+		mime, ext, err := utils.DetectImageType(tt.data)
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.mime, mime, tt.name)
+		assert.Equal(t, tt.ext, ext, tt.name)
+	}
+
+	// File teks atau GIF tetap ditolak walaupun namanya .png
+	for _, data := range [][]byte{[]byte("<?php echo 'hi'; ?>"), []byte("GIF89a......")} {
+		_, _, err := utils.DetectImageType(data)
+		assert.ErrorIs(t, err, utils.ErrUnsupportedImage)
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := map[string]string{
+		"Screenshot (494).png":         "screenshot-494",
+		"../../etc/passwd":             "passwd",
+		`C:\Users\kasir\Foto Kopi.JPG`: "foto-kopi",
+		"....png":                      "image",
+		"":                             "image",
+		"kopi susu gula aren dengan es batu dan topping boba ekstra.jpg": "kopi-susu-gula-aren-dengan-es-batu-dan-topping-bob",
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, utils.SanitizeFilename(input), input)
+	}
+}
+
+func TestResizeToFit(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1000, 500))
+	for y := 0; y < 500; y++ {
+		for x := 0; x < 1000; x++ {
+			// Separuh kiri merah, separuh kanan transparan
+			if x < 500 {
+				src.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+			}
+		}
+	}
+
+	resized := utils.ResizeToFit(src, 200)
+	assert.Equal(t, image.Rect(0, 0, 200, 100), resized.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, resized.At(10, 10))
+	assert.Equal(t, color.NRGBA{}, resized.At(190, 10))
+
+	// Gambar yang sudah kecil tidak diperbesar
+	small := image.NewNRGBA(image.Rect(0, 0, 120, 80))
+	assert.Same(t, small, utils.ResizeToFit(small, 200))
+
+	portrait := utils.ResizeToFit(image.NewNRGBA(image.Rect(0, 0, 300, 900)), 300)
+	assert.Equal(t, image.Rect(0, 0, 100, 300), portrait.Bounds())
+}
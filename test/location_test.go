@@ -0,0 +1,98 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"aro-shop/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocationStockSimpleProduct(t *testing.T) {
+	product := models.Product{ID: uuid.New(), Name: "Kopi Susu", Type: models.ProductTypeSimple, Stock: models.NewQuantity(30)}
+	levels := dto.LocationStockLevels{{ProductID: product.ID}: models.NewQuantity(12)}
+
+	response := dto.ConvertToProductResponse(product)
+	levels.ApplyTo(&response, product)
+
+	// Stok global tetap total semua lokasi, location_stock hanya outlet yang diminta
+	assert.Equal(t, models.NewQuantity(30), response.Stock)
+	if assert.NotNil(t, response.LocationStock) {
+		assert.Equal(t, models.NewQuantity(12), *response.LocationStock)
+	}
+}
+
+func TestLocationStockVariantsAndMissingRows(t *testing.T) {
+	product := models.Product{
+		ID:   uuid.New(),
+		Name: "Kaos",
+		Type: models.ProductTypeSimple,
+		Variants: []models.ProductVariant{
+			{ID: uuid.New(), Stock: models.NewQuantity(4)},
+			{ID: uuid.New(), Stock: models.NewQuantity(6)},
+		},
+	}
+	product.Variants[0].ProductID = product.ID
+	product.Variants[1].ProductID = product.ID
+	levels := dto.LocationStockLevels{{ProductID: product.ID, VariantID: product.Variants[0].ID}: models.NewQuantity(3)}
+
+	response := dto.ConvertToProductResponse(product)
+	levels.ApplyTo(&response, product)
+
+	assert.Equal(t, models.NewQuantity(3), *response.Variants[0].LocationStock)
+	// Varian yang belum pernah masuk lokasi ini dianggap kosong
+	assert.Equal(t, models.Quantity(0), *response.Variants[1].LocationStock)
+	assert.Equal(t, models.NewQuantity(3), *response.LocationStock)
+}
+
+func TestLocationStockBundleUsesLocalComponents(t *testing.T) {
+	burger := models.Product{ID: uuid.New(), Name: "Burger", Stock: models.NewQuantity(50)}
+	fries := models.Product{ID: uuid.New(), Name: "Kentang", Stock: models.NewQuantity(50)}
+	bundle := models.Product{
+		ID:   uuid.New(),
+		Name: "Paket Hemat",
+		Type: models.ProductTypeBundle,
+		BundleComponents: []models.BundleComponent{
+			{ComponentID: burger.ID, Component: burger, Quantity: models.NewQuantity(1)},
+			{ComponentID: fries.ID, Component: fries, Quantity: models.NewQuantity(2)},
+		},
+	}
+	levels := dto.LocationStockLevels{
+		{ProductID: burger.ID}: models.NewQuantity(10),
+		{ProductID: fries.ID}:  models.NewQuantity(7),
+	}
+
+	response := dto.ConvertToProductResponse(bundle)
+	levels.ApplyTo(&response, bundle)
+
+	assert.Equal(t, models.NewQuantity(25), response.Stock)
+	assert.Equal(t, models.NewQuantity(3), *response.LocationStock)
+	// Stok komponen pada produk asli tidak ikut berubah
+	assert.Equal(t, models.NewQuantity(50), bundle.BundleComponents[1].Component.Stock)
+}
+
+func TestConvertToStockTransferResponse(t *testing.T) {
+	variant := &models.ProductVariant{SKU: "KAOS-L", OptionValues: []models.ProductOptionValue{{Value: "L"}}}
+	transfer := models.StockTransfer{
+		ID:           uuid.New(),
+		FromLocation: models.Location{Name: "Gudang"},
+		ToLocation:   models.Location{Name: "Outlet Kemang"},
+		Status:       models.TransferInTransit,
+		Lines: []models.StockTransferLine{
+			{Product: models.Product{Name: "Kopi", SKU: "KOPI-1", Unit: "kg"}, Quantity: models.QuantityFromFloat(1.5)},
+			{Product: models.Product{Name: "Kaos", SKU: "KAOS", Unit: "pcs"}, Variant: variant, Quantity: models.NewQuantity(2)},
+		},
+	}
+
+	response := dto.ConvertToStockTransferResponse(transfer)
+	assert.Equal(t, "Gudang", response.FromLocation)
+	assert.Equal(t, "Outlet Kemang", response.ToLocation)
+	assert.Equal(t, models.TransferInTransit, response.Status)
+	if assert.Len(t, response.Lines, 2) {
+		assert.Equal(t, "KOPI-1", response.Lines[0].SKU)
+		assert.Equal(t, "1.5", response.Lines[0].Quantity.String())
+		assert.Equal(t, "KAOS-L", response.Lines[1].SKU)
+		assert.Equal(t, "L", response.Lines[1].VariantName)
+	}
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockLotExpiredOn(t *testing.T) {
+	expiresAt := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	lot := models.StockLot{ExpiresAt: &expiresAt}
+	jakarta := time.FixedZone("WIB", 7*3600)
+
+	// Tanggal kedaluwarsa masih boleh dijual, baru kedaluwarsa keesokan harinya
+	assert.False(t, lot.ExpiredOn(time.Date(2026, 3, 31, 23, 59, 0, 0, jakarta)))
+	assert.True(t, lot.ExpiredOn(time.Date(2026, 4, 1, 0, 5, 0, 0, jakarta)))
+	assert.False(t, models.StockLot{}.ExpiredOn(time.Now()))
+}
+
+func TestConvertToStockLotResponse(t *testing.T) {
+	expiresAt := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC)
+	lot := models.StockLot{
+		LotNumber: "B2605",
+		Product:   models.Product{Name: "Sirup Obat Batuk", SKU: "SOB", Unit: "pcs", CostPrice: 12500},
+		Variant:   &models.ProductVariant{SKU: "SOB-60", OptionValues: []models.ProductOptionValue{{Value: "60ml"}}},
+		Location:  models.Location{Name: "Apotek Pusat"},
+		ExpiresAt: &expiresAt,
+		Quantity:  models.NewQuantity(4),
+	}
+
+	response := dto.ConvertToStockLotResponse(lot, today)
+	assert.Equal(t, "SOB-60", response.SKU)
+	assert.Equal(t, "60ml", response.VariantName)
+	assert.Equal(t, "Apotek Pusat", response.LocationName)
+	assert.Equal(t, 50000.0, response.Value)
+	assert.False(t, response.Expired)
+	if assert.NotNil(t, response.DaysLeft) {
+		assert.Equal(t, 7, *response.DaysLeft)
+	}
+
+	expired := dto.ConvertToStockLotResponse(lot, time.Date(2026, 5, 12, 0, 0, 0, 0, time.UTC))
+	assert.True(t, expired.Expired)
+	assert.Equal(t, -2, *expired.DaysLeft)
+}
+
+func TestBuildExpiringLotReport(t *testing.T) {
+	today := time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC)
+	lots := []dto.StockLotResponse{
+		{LotNumber: "A1", Expired: true, Value: 10000.10},
+		{LotNumber: "A2", Value: 2500.25},
+		{LotNumber: "A3", Value: 0.2},
+	}
+
+	report := dto.BuildExpiringLotReport(30, today, lots)
+	assert.Equal(t, "2026-05-03", report.Date)
+	assert.Equal(t, 3, report.TotalLots)
+	assert.Equal(t, 1, report.ExpiredLots)
+	assert.Equal(t, 12500.55, report.TotalValue)
+	assert.Equal(t, 10000.10, report.ExpiredValue)
+}
+
+func TestCreateTransactionRejectsExpiredLot(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	productID, locationID, userID, paymentMethodID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`FROM "payment_methods"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(paymentMethodID, "Tunai"))
+	mock.ExpectQuery(`FROM "products"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "type", "precision", "stock"}).
+			AddRow(productID, "Susu UHT", 7000.0, models.ProductTypeSimple, 0, "5.000"))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "product_variants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`FROM "modifier_groups"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "location_id"}).AddRow(userID, locationID))
+	// Lot kedaluwarsa berisi 3 dari total 5 di lokasi, SUM numeric dibaca sebagai string
+	mock.ExpectQuery(`COALESCE\(SUM\(quantity\), 0\) FROM "stock_lots"`).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow("3.000"))
+	mock.ExpectQuery(`FROM "location_stocks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"location_id", "product_id", "variant_id", "quantity"}).
+			AddRow(locationID, productID, nil, "5.000"))
+
+	body, _ := json.Marshal(dto.TransactionRequest{
+		PaymentMethodID: paymentMethodID,
+		Items:           []dto.TransactionItemRequest{{ProductID: productID, Quantity: models.NewQuantity(3)}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/transactions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set("user_id", userID.String())
+
+	// Hanya 2 yang belum kedaluwarsa, permintaan 3 harus ditolak sebagai 400
+	if assert.NoError(t, handler.CreateTransaction(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "hanya tersisa 2")
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"aro-shop/models"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantEffectivePriceAndName(t *testing.T) {
+	override := 35000.0
+	variant := models.ProductVariant{
+		OptionValues: []models.ProductOptionValue{{Value: "L"}, {Value: "Merah"}},
+	}
+
+	assert.Equal(t, 30000.0, variant.EffectivePrice(30000))
+	variant.Price = &override
+	assert.Equal(t, 35000.0, variant.EffectivePrice(30000))
+	assert.Equal(t, "L / Merah", variant.Name())
+}
+
+func TestBundleAvailability(t *testing.T) {
+	bundle := models.Product{
+		Type: models.ProductTypeBundle,
+		BundleComponents: []models.BundleComponent{
+			{Quantity: models.NewQuantity(1), Component: models.Product{Name: "Burger", Stock: models.NewQuantity(10)}},
+			{Quantity: models.NewQuantity(2), Component: models.Product{Name: "Fries", Stock: models.NewQuantity(9)}},
+			{Quantity: models.NewQuantity(1), Component: models.Product{Name: "Drink", Stock: models.NewQuantity(20)}},
+		},
+	}
+
+	assert.True(t, bundle.IsBundle())
+	assert.Equal(t, models.NewQuantity(4), bundle.BundleAvailability())
+
+	assert.Equal(t, models.Quantity(0), models.Product{Type: models.ProductTypeBundle}.BundleAvailability())
+}
+
+func TestParseQuantity(t *testing.T) {
+	q, err := models.ParseQuantity("0.35")
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.Quantity(350), q)
+		assert.Equal(t, "0.35", q.String())
+	}
+
+	q, err = models.ParseQuantity("12")
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.NewQuantity(12), q)
+		assert.Equal(t, "12", q.String())
+	}
+
+	_, err = models.ParseQuantity("1.2345")
+	assert.ErrorIs(t, err, models.ErrInvalidQuantity)
+	_, err = models.ParseQuantity("abc")
+	assert.ErrorIs(t, err, models.ErrInvalidQuantity)
+}
+
+func TestQuantityArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 dengan float menghasilkan 0.30000000000000004
+	a, _ := models.ParseQuantity("0.1")
+	b, _ := models.ParseQuantity("0.2")
+	assert.Equal(t, "0.3", (a + b).String())
+
+	stock, _ := models.ParseQuantity("10")
+	sold, _ := models.ParseQuantity("0.35")
+	assert.Equal(t, "9.65", (stock - sold).String())
+
+	perBundle, _ := models.ParseQuantity("0.25")
+	assert.Equal(t, "0.75", perBundle.Mul(models.NewQuantity(3)).String())
+}
+
+func TestQuantityPrecision(t *testing.T) {
+	q, _ := models.ParseQuantity("1.25")
+	assert.False(t, q.FitsPrecision(0))
+	assert.False(t, q.FitsPrecision(1))
+	assert.True(t, q.FitsPrecision(2))
+	assert.True(t, models.NewQuantity(3).FitsPrecision(0))
+
+	assert.Equal(t, "1.3", q.RoundTo(1).String())
+	assert.Equal(t, models.Quantity(1235), models.QuantityFromFloat(1.2349999))
+}
+
+func TestQuantityJSON(t *testing.T) {
+	var q models.Quantity
+	assert.NoError(t, json.Unmarshal([]byte("0.750"), &q))
+	assert.Equal(t, models.Quantity(750), q)
+
+	data, err := json.Marshal(q)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0.75", string(data))
+	}
+}
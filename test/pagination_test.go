@@ -0,0 +1,43 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 10, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+
+	decoded, err := dto.DecodeCursor(dto.NewCursor(createdAt, id, true).Encode())
+	if assert.NoError(t, err) {
+		assert.True(t, decoded.CreatedAt.Equal(createdAt))
+		assert.True(t, decoded.Prev)
+		assert.JSONEq(t, `"`+id.String()+`"`, string(decoded.ID))
+	}
+
+	_, err = dto.DecodeCursor("not a cursor")
+	assert.ErrorIs(t, err, dto.ErrInvalidCursor)
+}
+
+func TestParseCursorParams(t *testing.T) {
+	params, errorDetails := dto.ParseCursorParams(map[string][]string{"cursor": {""}, "include_total": {"true"}})
+	assert.Empty(t, errorDetails)
+	assert.True(t, params.Enabled)
+	assert.Nil(t, params.Cursor)
+	assert.True(t, params.IncludeTotal)
+
+	params, _ = dto.ParseCursorParams(map[string][]string{"page": {"2"}})
+	assert.False(t, params.Enabled)
+	assert.False(t, params.IncludeTotal)
+
+	_, errorDetails = dto.ParseCursorParams(map[string][]string{"cursor": {"@@"}})
+	assert.Contains(t, errorDetails, "cursor")
+
+	_, errorDetails = dto.ParseProductListQuery(map[string][]string{"cursor": {""}, "sort": {"price"}})
+	assert.Contains(t, errorDetails, "sort")
+}
@@ -0,0 +1,27 @@
+package test
+
+import (
+	"aro-shop/db"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// SetupPostgresMock memasang db.DB ke sqlmock dengan dialek postgres. Nilai numeric
+// dikembalikan sebagai string seperti driver pgx, misalnya "3.000".
+func SetupPostgresMock(t *testing.T) sqlmock.Sqlmock {
+	sqlDB, mockDB, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.DB = gormDB
+	return mockDB
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"aro-shop/cache"
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEffectiveAt(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2026-10-26", time.Date(2026, 10, 26, 0, 0, 0, 0, jakarta)},
+		{"2026-10-26 00:00", time.Date(2026, 10, 26, 0, 0, 0, 0, jakarta)},
+		{"2026-10-26T08:30:15", time.Date(2026, 10, 26, 8, 30, 15, 0, jakarta)},
+		{"2026-10-25T17:00:00Z", time.Date(2026, 10, 26, 0, 0, 0, 0, jakarta)},
+	}
+	for _, tt := range tests {
+		got, err := dto.ParseEffectiveAt(tt.input, jakarta)
+		assert.NoError(t, err, tt.input)
+		assert.True(t, tt.want.Equal(got), "%s: got %s", tt.input, got)
+	}
+
+	_, err := dto.ParseEffectiveAt("senin depan", jakarta)
+	assert.ErrorIs(t, err, dto.ErrInvalidEffectiveAt)
+}
+
+func TestConvertToPriceHistoryResponse(t *testing.T) {
+	history := models.ProductPriceHistory{
+		OldPrice: 15000,
+		NewPrice: 16500.5,
+		Source:   models.PriceSourceBulk,
+		User:     &models.User{Name: "Admin Toko"},
+	}
+
+	response := dto.ConvertToPriceHistoryResponse(history)
+	assert.Equal(t, 1500.5, response.Difference)
+	assert.Equal(t, "Admin Toko", response.ChangedByName)
+	assert.Equal(t, models.PriceSourceBulk, response.Source)
+}
+
+func TestApplyDuePriceSchedulesIsolatesFailedSchedule(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	brokenID, okID := uuid.New(), uuid.New()
+	missingProduct, productID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT "id" FROM "scheduled_price_changes" WHERE status = \$1 AND effective_at <= \$2 ORDER BY effective_at ASC`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(brokenID).AddRow(okID))
+
+	// Jadwal pertama: produknya sudah tidak ada, transaksinya dibatalkan sendiri
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "scheduled_price_changes" WHERE id = \$1 AND status = \$2 LIMIT \$3 FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "price", "status"}).AddRow(brokenID, missingProduct, 20000.0, models.PriceSchedulePending))
+	mock.ExpectQuery(`FROM "products" WHERE id = \$1`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "scheduled_price_changes" SET "error"=\$1,"status"=\$2,"updated_at"=\$3 WHERE id = \$4 AND status = \$5`).
+		WithArgs("record not found", models.PriceScheduleFailed, sqlmock.AnyArg(), brokenID, models.PriceSchedulePending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Jadwal kedua tetap diterapkan
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "scheduled_price_changes" WHERE id = \$1 AND status = \$2 LIMIT \$3 FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "price", "status"}).AddRow(okID, productID, 22000.0, models.PriceSchedulePending))
+	mock.ExpectQuery(`FROM "products" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(productID, 20000.0))
+	mock.ExpectExec(`UPDATE "products" SET "price"=\$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "product_price_histories"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE "scheduled_price_changes" SET "applied_at"=\$1,"status"=\$2`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	applied, failed, err := handler.ApplyDuePriceSchedules(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, 1, failed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
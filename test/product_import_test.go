@@ -0,0 +1,216 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProductImportCSV(t *testing.T) {
+	csv := "Name,SKU,Price,Stock,Category,Image_URL\n" +
+		"Kopi Susu,KS-001,18000,12,Coffee,https://cdn.example.com/ks.png\n" +
+		"Beras,BR-005,65000,2.5,Sembako,\n" +
+		",KS-002,abc,-1,,not-a-url\n" +
+		"Kopi Hitam,KS-001,15000,,Coffee,\n" +
+		",,,,,\n"
+
+	rows, rowErrors, err := dto.ParseProductImportCSV(strings.NewReader(csv))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, 2, rows[0].Row)
+		assert.Equal(t, "KS-001", rows[0].SKU)
+		assert.Equal(t, models.NewQuantity(12), rows[0].Stock)
+		assert.True(t, rows[0].StockSet)
+		assert.Equal(t, "2.5", rows[1].Stock.String())
+	}
+
+	if assert.Len(t, rowErrors, 2) {
+		assert.Equal(t, 4, rowErrors[0].Row)
+		fields := []string{}
+		for _, detail := range rowErrors[0].Errors {
+			fields = append(fields, detail.Field)
+		}
+		assert.ElementsMatch(t, []string{"name", "category", "price", "stock", "image_url"}, fields)
+
+		assert.Equal(t, 5, rowErrors[1].Row)
+		assert.Equal(t, "sku", rowErrors[1].Errors[0].Field)
+	}
+}
+
+func TestParseProductImportCSVRequiresHeader(t *testing.T) {
+	_, _, err := dto.ParseProductImportCSV(strings.NewReader("name,price\nKopi,1000\n"))
+	assert.ErrorIs(t, err, dto.ErrImportHeader)
+}
+
+func TestParseProductImportCSVKeepsLineNumbersAfterBlankLines(t *testing.T) {
+	csv := "name,sku,price,category\n" +
+		"\n" +
+		"Kopi Susu,KS-001,18000,Coffee\n" +
+		"\n\n" +
+		"Teh,TH-001,abc,Tea\n"
+
+	rows, rowErrors, err := dto.ParseProductImportCSV(strings.NewReader(csv))
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, 3, rows[0].Row)
+	}
+	if assert.Len(t, rowErrors, 1) {
+		assert.Equal(t, 6, rowErrors[0].Row)
+	}
+}
+
+// dryRunProductImport mengirim csv ke ImportProducts dengan dry_run=true dan mengembalikan laporannya
+func dryRunProductImport(t *testing.T, csv string) (int, dto.ProductImportReport) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "products.csv")
+	assert.NoError(t, err)
+	part.Write([]byte(csv))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/products/import?dry_run=true", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler.ImportProducts(echo.New().NewContext(req, rec)))
+
+	var response struct {
+		Data dto.ProductImportReport `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return rec.Code, response.Data
+}
+
+func TestImportRejectsStockChangeForExistingProducts(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	categoryID := uuid.New()
+
+	mock.ExpectQuery(`FROM "categories"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug"}).AddRow(categoryID, "Coffee", "coffee"))
+	mock.ExpectQuery(`FROM "products" WHERE sku IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "sku", "price", "stock", "category_id"}).
+			AddRow(uuid.New(), "Kopi Susu", "KS-001", 18000.0, "10.000", categoryID).
+			AddRow(uuid.New(), "Kopi Hitam", "KS-002", 15000.0, "10.000", categoryID))
+	mock.ExpectQuery(`FROM "product_variants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id"}))
+	mock.ExpectQuery(`SELECT "sku" FROM "product_variants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"sku"}))
+
+	csv := "name,sku,price,stock,category\n" +
+		"Kopi Susu,KS-001,18000,12,Coffee\n" + // stok berubah: harus lewat penyesuaian stok
+		"Kopi Hitam,KS-002,16000,10,Coffee\n" + // stok sama seperti hasil export: diabaikan
+		"Kopi Aren,KS-003,20000,5,Coffee\n" // produk baru boleh membawa stok awal
+
+	code, report := dryRunProductImport(t, csv)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, report.ToUpdate)
+	assert.Equal(t, 1, report.ToCreate)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, 2, report.Errors[0].Row)
+		assert.Equal(t, "stock", report.Errors[0].Errors[0].Field)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportReportsAmbiguousCategory(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	beverages := uuid.New()
+	food := uuid.New()
+
+	mock.ExpectQuery(`FROM "categories"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "parent_id"}).
+			AddRow(beverages, "Beverages", "beverages", nil).
+			AddRow(food, "Food", "food", nil).
+			AddRow(uuid.New(), "Hot", "hot", beverages).
+			AddRow(uuid.New(), "Hot", "hot-2", food))
+	mock.ExpectQuery(`FROM "products" WHERE sku IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sku"}))
+	mock.ExpectQuery(`SELECT "sku" FROM "product_variants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"sku"}))
+
+	csv := "name,sku,price,stock,category\n" +
+		"Kopi Susu,KS-001,18000,5,Hot\n" + // ambigu: ada di Beverages dan Food
+		"Teh Panas,KS-002,12000,5,Beverages/Hot\n" +
+		"Roti Bakar,KS-003,15000,5,hot-2\n" +
+		"Kue,KS-004,10000,5,Snacks\n"
+
+	code, report := dryRunProductImport(t, csv)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, report.ToCreate)
+	assert.Equal(t, []string{"Snacks"}, report.CategoriesToCreate)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, 2, report.Errors[0].Row)
+		assert.Equal(t, "category", report.Errors[0].Errors[0].Field)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportedProductsCanBeImportedAgain(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	beverages, food := uuid.New(), uuid.New()
+	hotDrinks, hotFood := uuid.New(), uuid.New()
+	categoryRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "slug", "parent_id"}).
+			AddRow(beverages, "Beverages", "beverages", nil).
+			AddRow(food, "Food", "food", nil).
+			AddRow(hotDrinks, "Hot", "hot", beverages).
+			AddRow(hotFood, "Hot", "hot-2", food)
+	}
+	productRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "sku", "price", "stock", "category_id", "unit", "type"}).
+			AddRow(uuid.New(), "Kopi Susu", "KS-001", 18000.0, "10.000", hotDrinks, models.UnitPcs, models.ProductTypeSimple).
+			AddRow(uuid.New(), "Roti Bakar", "RB-001", 15000.0, "4.000", hotFood, models.UnitPcs, models.ProductTypeSimple)
+	}
+
+	// Export: produk, preload relasi, lalu seluruh kategori untuk menulis kolom category
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery(`SELECT \* FROM "products"`).WillReturnRows(productRows())
+	mock.ExpectQuery(`FROM "categories" WHERE "categories"."id" IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "parent_id"}).
+			AddRow(hotDrinks, "Hot", "hot", beverages).
+			AddRow(hotFood, "Hot", "hot-2", food))
+	mock.ExpectQuery(`FROM "bundle_components"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "modifier_groups"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "product_option_types"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "product_variants"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "categories" WHERE "categories"."deleted_at" IS NULL$`).WillReturnRows(categoryRows())
+
+	req := httptest.NewRequest(http.MethodGet, "/products/export", nil)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler.ExportProducts(echo.New().NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	exported := rec.Body.String()
+	// Nama "Hot" dipakai dua kategori, jadi ditulis sebagai path
+	assert.Contains(t, exported, "Beverages/Hot")
+	assert.Contains(t, exported, "Food/Hot")
+
+	// Import ulang file yang sama: semua baris cocok tanpa error dan tanpa kategori baru
+	mock = SetupPostgresMock(t)
+	mock.ExpectQuery(`FROM "categories"`).WillReturnRows(categoryRows())
+	mock.ExpectQuery(`FROM "products" WHERE sku IN`).WillReturnRows(productRows())
+	mock.ExpectQuery(`FROM "product_variants"`).WillReturnRows(sqlmock.NewRows([]string{"id", "product_id"}))
+	mock.ExpectQuery(`SELECT "sku" FROM "product_variants"`).WillReturnRows(sqlmock.NewRows([]string{"sku"}))
+
+	code, report := dryRunProductImport(t, exported)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, report.ToUpdate)
+	assert.Empty(t, report.CategoriesToCreate)
+	assert.Empty(t, report.Errors)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceRuleApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  dto.PriceRule
+		price float64
+		want  float64
+	}{
+		{"naik persen", dto.PriceRule{Type: dto.PriceRulePercent, Value: 10}, 15000, 16500},
+		{"turun persen", dto.PriceRule{Type: dto.PriceRulePercent, Value: -25}, 10, 7.5},
+		{"tambah nominal", dto.PriceRule{Type: dto.PriceRuleAmount, Value: 500}, 12000, 12500},
+		{"harga tetap", dto.PriceRule{Type: dto.PriceRuleSet, Value: 9999.999}, 5000, 10000},
+		{"pembulatan ratusan", dto.PriceRule{Type: dto.PriceRulePercent, Value: 7, RoundTo: 100}, 12345, 13200},
+		{"pembulatan dua desimal", dto.PriceRule{Type: dto.PriceRulePercent, Value: 10}, 1.15, 1.27},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.rule.Apply(tt.price), 0.0001)
+		})
+	}
+}
+
+func TestParsePriceListCSV(t *testing.T) {
+	input := "\ufeffSKU,Price,name\n" +
+		"KOPI-01,15000,Kopi\n" +
+		"\n" +
+		"TEH-01,abc,Teh\n" +
+		"KOPI-01,16000,Kopi lagi\n" +
+		",5000,Tanpa SKU\n" +
+		"GULA-01,12500.456,Gula\n"
+
+	rows, rowErrors, err := dto.ParsePriceListCSV(strings.NewReader(input))
+	assert.NoError(t, err)
+
+	if !assert.Len(t, rows, 2) {
+		return
+	}
+	assert.Equal(t, dto.PriceListRow{Row: 2, SKU: "KOPI-01", Price: 15000}, rows[0])
+	assert.Equal(t, dto.PriceListRow{Row: 7, SKU: "GULA-01", Price: 12500.46}, rows[1])
+
+	if !assert.Len(t, rowErrors, 3) {
+		return
+	}
+	assert.Equal(t, 4, rowErrors[0].Row)
+	assert.Equal(t, "price", rowErrors[0].Errors[0].Field)
+	assert.Equal(t, 5, rowErrors[1].Row)
+	assert.Contains(t, rowErrors[1].Errors[0].Message, "row 2")
+	assert.Equal(t, 6, rowErrors[2].Row)
+}
+
+func TestParsePriceListCSVRequiresHeader(t *testing.T) {
+	_, _, err := dto.ParsePriceListCSV(strings.NewReader("sku,harga\nA,1\n"))
+	assert.ErrorIs(t, err, dto.ErrPriceListHeader)
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"aro-shop/cache"
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProductListQueryRejectsUnknownSort(t *testing.T) {
+	_, errorDetails := dto.ParseProductListQuery(map[string][]string{"sort": {"-stock"}})
+	assert.Contains(t, errorDetails, "sort")
+
+	_, errorDetails = dto.ParseProductListQuery(map[string][]string{
+		"min_price": {"5000"},
+		"max_price": {"1000"},
+		"in_stock":  {"yes"},
+		"category":  {"not-a-uuid"},
+	})
+	assert.Contains(t, errorDetails, "min_price")
+	assert.Contains(t, errorDetails, "in_stock")
+	assert.Contains(t, errorDetails, "category")
+}
+
+func TestProductListQueryCacheKeyIsDeterministic(t *testing.T) {
+	a := "0b6f3b7e-8d7f-4d3e-9c3a-1b2c3d4e5f60"
+	b := "7c1d2e3f-4a5b-4c6d-8e9f-0a1b2c3d4e5f"
+
+	first, errorDetails := dto.ParseProductListQuery(map[string][]string{
+		"category":      {a + "," + b},
+		"sort":          {"-price"},
+		"min_price":     {"1000.50"},
+		"in_stock":      {"true"},
+		"updated_since": {"2026-01-02"},
+	})
+	assert.Empty(t, errorDetails)
+
+	second, errorDetails := dto.ParseProductListQuery(map[string][]string{
+		"category":      {b, a, a},
+		"updated_since": {"2026-01-02T00:00:00Z"},
+		"in_stock":      {"1"},
+		"min_price":     {"1000.5"},
+		"sort":          {"-price"},
+	})
+	assert.Empty(t, errorDetails)
+
+	assert.Len(t, second.CategoryIDs, 2)
+	assert.Equal(t, first.CacheKey(), second.CacheKey())
+	assert.Regexp(t, "^products_list:", first.CacheKey())
+
+	other, _ := dto.ParseProductListQuery(map[string][]string{"category": {a}, "sort": {"-price"}})
+	assert.NotEqual(t, first.CacheKey(), other.CacheKey())
+}
+
+// getProductsSQL menjalankan GetProducts dengan query string dan mengharapkan query produk
+// yang cocok dengan pattern; hasil kosong agar preload tidak dijalankan
+func getProductsSQL(t *testing.T, rawQuery, pattern string, args ...driver.Value) {
+	mock := SetupPostgresMock(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+
+	expectation := mock.ExpectQuery(pattern)
+	if len(args) > 0 {
+		expectation = expectation.WithArgs(args...)
+	}
+	expectation.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/products?"+rawQuery, nil)
+	rec := httptest.NewRecorder()
+	if assert.NoError(t, handler.GetProducts(echo.New().NewContext(req, rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProductSearchIgnoresCaseLikeItsCacheKey(t *testing.T) {
+	upper, _ := dto.ParseProductListQuery(map[string][]string{"search": {"Kopi"}})
+	lower, _ := dto.ParseProductListQuery(map[string][]string{"search": {"kopi"}})
+	assert.Equal(t, upper.CacheKey(), lower.CacheKey())
+
+	getProductsSQL(t, "search=Kopi", `WHERE name ILIKE \$1`, "%Kopi%", 10)
+}
+
+func TestProductStockSortUsesDisplayedStock(t *testing.T) {
+	// Stok induk produk bervarian dan bundle selalu 0, urutan harus memakai stok yang ditampilkan
+	getProductsSQL(t, "sort=stock", `ORDER BY CASE\s+WHEN products.type = 'bundle' THEN .*bundle_components.*SUM\(v.stock\).*ELSE products.stock\s+END ASC, id ASC`)
+}
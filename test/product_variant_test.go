@@ -0,0 +1,187 @@
+package test
+
+import (
+	"aro-shop/cache"
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantProductResponseResolvesPriceAndStock(t *testing.T) {
+	override := 32000.0
+	product := models.Product{
+		Name:  "Kaos Polos",
+		Price: 30000,
+		Stock: models.NewQuantity(99), // stok induk diabaikan untuk produk bervarian
+		Variants: []models.ProductVariant{
+			{SKU: "KAOS-M", Stock: models.NewQuantity(4), OptionValues: []models.ProductOptionValue{{Value: "M"}}},
+			{SKU: "KAOS-XL", Price: &override, Stock: models.QuantityFromFloat(1.5), OptionValues: []models.ProductOptionValue{{Value: "XL"}}},
+		},
+	}
+
+	response := dto.ConvertToProductResponse(product)
+	assert.Equal(t, models.QuantityFromFloat(5.5), response.Stock)
+	if assert.Len(t, response.Variants, 2) {
+		assert.Equal(t, 30000.0, response.Variants[0].Price)
+		assert.Nil(t, response.Variants[0].PriceOverride)
+		assert.Equal(t, 32000.0, response.Variants[1].Price)
+		assert.Equal(t, "XL", response.Variants[1].Name)
+	}
+}
+
+func postTransaction(t *testing.T, request dto.TransactionRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, "/transactions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set("user_id", uuid.NewString())
+	assert.NoError(t, handler.CreateTransaction(c))
+	return rec
+}
+
+func expectVariantProduct(mock sqlmock.Sqlmock, productID uuid.UUID) {
+	mock.ExpectQuery(`FROM "payment_methods"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(uuid.New(), "Tunai"))
+	mock.ExpectQuery(`FROM "products"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "type", "precision"}).
+			AddRow(productID, "Kaos Polos", 30000.0, models.ProductTypeSimple, 0))
+}
+
+func TestCreateTransactionRequiresVariantForVariantProduct(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	productID := uuid.New()
+
+	expectVariantProduct(mock, productID)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "product_variants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	rec := postTransaction(t, dto.TransactionRequest{
+		PaymentMethodID: uuid.New(),
+		Items:           []dto.TransactionItemRequest{{ProductID: productID, Quantity: models.NewQuantity(1)}},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "variant_id wajib diisi")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTransactionRejectsVariantOfAnotherProduct(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	productID, variantID := uuid.New(), uuid.New()
+
+	expectVariantProduct(mock, productID)
+	mock.ExpectQuery(`FROM "product_variants" WHERE id = \$1 AND product_id = \$2`).
+		WithArgs(variantID, productID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	rec := postTransaction(t, dto.TransactionRequest{
+		PaymentMethodID: uuid.New(),
+		Items:           []dto.TransactionItemRequest{{ProductID: productID, VariantID: &variantID, Quantity: models.NewQuantity(1)}},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "tidak ditemukan pada produk Kaos Polos")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func deleteVariant(t *testing.T, productID, variantID uuid.UUID) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id", "variant_id")
+	c.SetParamValues(productID.String(), variantID.String())
+	assert.NoError(t, handler.DeleteProductVariant(c))
+	return rec
+}
+
+func expectLockedVariant(mock sqlmock.Sqlmock, productID, variantID uuid.UUID, stock string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "product_variants" WHERE id = \$1 AND product_id = \$2 .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "stock"}).AddRow(variantID, productID, stock))
+}
+
+func TestDeleteProductVariantRefusesVariantWithStock(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	productID, variantID := uuid.New(), uuid.New()
+
+	expectLockedVariant(mock, productID, variantID, "2.000")
+	mock.ExpectRollback()
+
+	rec := deleteVariant(t, productID, variantID)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Variant still has stock")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProductVariantRefusesSoldVariant(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	productID, variantID := uuid.New(), uuid.New()
+
+	expectLockedVariant(mock, productID, variantID, "0.000")
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "transaction_items"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "stock_movements"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	for _, table := range []string{"stock_adjustments", "purchase_order_lines", "stock_transfer_lines"} {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "` + table + `"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+	mock.ExpectRollback()
+
+	rec := deleteVariant(t, productID, variantID)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	var response struct {
+		Errors map[string]string `json:"errors"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Contains(t, response.Errors, "transaction_items")
+	assert.Contains(t, response.Errors, "stock_movements")
+	assert.NotContains(t, response.Errors, "purchase_order_lines")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteUnusedProductVariant(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	productID, variantID := uuid.New(), uuid.New()
+
+	expectLockedVariant(mock, productID, variantID, "0.000")
+	for _, table := range []string{"transaction_items", "stock_movements", "stock_adjustments", "purchase_order_lines", "stock_transfer_lines"} {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "` + table + `"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+	mock.ExpectExec(`DELETE FROM "location_stocks" WHERE variant_id = \$1`).
+		WithArgs(variantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "product_variant_option_values"`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM "product_variants"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rec := deleteVariant(t, productID, variantID)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProductVariantNotFound(t *testing.T) {
+	mock := SetupPostgresMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "product_variants"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	rec := deleteVariant(t, uuid.New(), uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMovingAverageCost(t *testing.T) {
+	// 10 pcs @ 5.000 + 30 pcs @ 6.000 = 230.000 / 40 pcs
+	assert.Equal(t, 5750.0, models.MovingAverageCost(models.NewQuantity(10), 5000, models.NewQuantity(30), 6000))
+
+	// Stok kosong atau negatif: harga pokok mengikuti harga beli terakhir
+	assert.Equal(t, 6000.0, models.MovingAverageCost(0, 5000, models.NewQuantity(5), 6000))
+	assert.Equal(t, 6000.0, models.MovingAverageCost(-models.NewQuantity(2), 5000, models.NewQuantity(5), 6000))
+
+	// Tanpa barang masuk harga pokok tidak berubah
+	assert.Equal(t, 5000.0, models.MovingAverageCost(models.NewQuantity(10), 5000, 0, 6000))
+
+	// Kuantitas desimal dibulatkan ke 2 angka di belakang koma
+	assert.Equal(t, 1033.33, models.MovingAverageCost(models.NewQuantity(2), 1000, models.NewQuantity(1), 1100))
+}
+
+func TestPurchaseOrderReceivingProgress(t *testing.T) {
+	po := models.PurchaseOrder{Lines: []models.PurchaseOrderLine{
+		{Quantity: models.NewQuantity(10), ReceivedQuantity: models.NewQuantity(4), UnitCost: 2500},
+		{Quantity: models.QuantityFromFloat(1.5), UnitCost: 10000},
+	}}
+
+	assert.Equal(t, models.NewQuantity(6), po.Lines[0].Remaining())
+	assert.False(t, po.FullyReceived())
+	assert.Equal(t, 40000.0, po.Total())
+
+	po.Lines[0].ReceivedQuantity = models.NewQuantity(10)
+	po.Lines[1].ReceivedQuantity = models.NewQuantity(2)
+	assert.Equal(t, models.Quantity(0), po.Lines[1].Remaining())
+	assert.True(t, po.FullyReceived())
+
+	assert.False(t, models.PurchaseOrder{}.FullyReceived())
+}
+
+func TestConvertToPurchaseOrderResponse(t *testing.T) {
+	po := models.PurchaseOrder{
+		Number:   "PO-20261019-0001",
+		Supplier: models.Supplier{Name: "CV Sumber Makmur"},
+		Status:   models.PurchaseOrderPartiallyReceived,
+		Lines: []models.PurchaseOrderLine{
+			{
+				LineNo:           1,
+				Product:          models.Product{Name: "Kopi Bubuk", SKU: "KOPI-250", Unit: "pcs"},
+				Quantity:         models.NewQuantity(3),
+				ReceivedQuantity: models.NewQuantity(1),
+				UnitCost:         12500.5,
+			},
+			{
+				LineNo:  2,
+				Product: models.Product{Name: "Kaos", SKU: "KAOS"},
+				Variant: &models.ProductVariant{
+					SKU:          "KAOS-L",
+					OptionValues: []models.ProductOptionValue{{Value: "L"}, {Value: "Hitam"}},
+				},
+				Quantity: models.NewQuantity(2),
+				UnitCost: 40000,
+			},
+		},
+	}
+
+	response := dto.ConvertToPurchaseOrderResponse(po)
+	assert.Equal(t, "CV Sumber Makmur", response.SupplierName)
+	assert.Equal(t, 117501.5, response.Total)
+	assert.Len(t, response.Lines, 2)
+	assert.Equal(t, models.NewQuantity(2), response.Lines[0].Remaining)
+	assert.Equal(t, 37501.5, response.Lines[0].Total)
+	assert.Equal(t, "KAOS-L", response.Lines[1].SKU)
+	assert.Equal(t, "L / Hitam", response.Lines[1].VariantName)
+}
+
+func TestPurchaseOrderRequestValidation(t *testing.T) {
+	valid := dto.PurchaseOrderRequest{
+		SupplierID: uuid.New(),
+		Lines:      []dto.PurchaseOrderLineRequest{{ProductID: uuid.New(), Quantity: models.NewQuantity(5), UnitCost: 1000}},
+	}
+	assert.NoError(t, dto.Validate.Struct(valid))
+
+	noLines := valid
+	noLines.Lines = nil
+	assert.Error(t, dto.Validate.Struct(noLines))
+
+	zeroQuantity := valid
+	zeroQuantity.Lines = []dto.PurchaseOrderLineRequest{{ProductID: uuid.New(), UnitCost: 1000}}
+	assert.Error(t, dto.Validate.Struct(zeroQuantity))
+
+	negativeCost := dto.GoodsReceiptLineRequest{LineID: uuid.New(), Quantity: models.NewQuantity(1), UnitCost: new(float64)}
+	*negativeCost.UnitCost = -1
+	assert.Error(t, dto.Validate.Struct(dto.GoodsReceiptRequest{Lines: []dto.GoodsReceiptLineRequest{negativeCost}}))
+}
+
+// numberArg cocok dengan nomor PO hari apa pun yang berakhiran suffix
+type numberArg struct{ suffix string }
+
+func (a numberArg) Match(value driver.Value) bool {
+	number, ok := value.(string)
+	return ok && strings.HasPrefix(number, "PO-") && strings.HasSuffix(number, a.suffix)
+}
+
+func TestCreatePurchaseOrderAfterDeletingDraftSkipsUsedNumbers(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	deletedID := uuid.New()
+	supplierID := uuid.New()
+	productID := uuid.New()
+
+	// PO-...-0001 dihapus, PO-...-0002 masih ada
+	mock.ExpectQuery(`FROM "purchase_orders" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "number", "status"}).AddRow(deletedID, "PO-20261019-0001", models.PurchaseOrderDraft))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "purchase_orders" WHERE id = \$1 AND status = \$2`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodDelete, "/purchase-orders/"+deletedID.String(), nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(deletedID.String())
+	assert.NoError(t, handler.DeletePurchaseOrder(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	mock.ExpectQuery(`FROM "suppliers" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(supplierID, "CV Kopi"))
+	mock.ExpectQuery(`FROM "products" WHERE id IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(productID, "Biji Kopi"))
+	mock.ExpectQuery(`FROM "product_variants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	// Jumlah PO hari ini tinggal 1, nomor berikutnya tetap setelah nomor terbesar
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(CAST\(SUBSTRING\(number FROM \$1\) AS INTEGER\)\), 0\) FROM "purchase_orders" WHERE number LIKE \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(2))
+	mock.ExpectQuery(`INSERT INTO "purchase_orders"`).
+		WithArgs(numberArg{"-0003"}, supplierID, nil, models.PurchaseOrderDraft, "", nil, nil, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery(`INSERT INTO "purchase_order_lines"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	body, _ := json.Marshal(dto.PurchaseOrderRequest{
+		SupplierID: supplierID,
+		Lines:      []dto.PurchaseOrderLineRequest{{ProductID: productID, Quantity: models.NewQuantity(10), UnitCost: 5000}},
+	})
+	req = httptest.NewRequest(http.MethodPost, "/purchase-orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	assert.NoError(t, handler.CreatePurchaseOrder(echo.New().NewContext(req, rec)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), "-0003")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
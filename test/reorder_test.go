@@ -0,0 +1,78 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"aro-shop/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossedReorderPoint(t *testing.T) {
+	product := models.Product{Stock: models.NewQuantity(9), ReorderPoint: models.NewQuantity(10)}
+
+	// Stok 12 terjual 3 menjadi 9: baru melewati titik pemesanan ulang
+	assert.True(t, product.CrossedReorderPoint(models.NewQuantity(3)))
+	// Stok sudah 9 sebelum penjualan, peringatan tidak diulang
+	product.Stock = models.NewQuantity(8)
+	assert.False(t, product.CrossedReorderPoint(models.NewQuantity(1)))
+	// Tepat di titik pemesanan ulang belum dianggap di bawahnya
+	product.Stock = models.NewQuantity(10)
+	assert.False(t, product.CrossedReorderPoint(models.NewQuantity(2)))
+	// Tanpa reorder_point tidak ada peringatan
+	assert.False(t, models.Product{Stock: 0}.CrossedReorderPoint(models.NewQuantity(5)))
+}
+
+func TestCrossedReorderPointUsesVariantTotal(t *testing.T) {
+	product := models.Product{
+		ReorderPoint: models.NewQuantity(5),
+		Variants:     []models.ProductVariant{{Stock: models.NewQuantity(2)}, {Stock: models.NewQuantity(2)}},
+	}
+	assert.Equal(t, models.NewQuantity(4), product.OnHand())
+	assert.True(t, product.CrossedReorderPoint(models.NewQuantity(1)))
+}
+
+func TestBuildReorderSuggestionFromSalesAndLeadTime(t *testing.T) {
+	supplier := &models.Supplier{ID: uuid.New(), Name: "PT Sumber Makmur", LeadTimeDays: 5}
+	product := models.Product{
+		Name:         "Gula 1kg",
+		Unit:         "pcs",
+		Stock:        models.NewQuantity(20),
+		CostPrice:    14000,
+		ReorderPoint: models.NewQuantity(10),
+	}
+
+	// 90 terjual dalam 30 hari = 3/hari, kebutuhan selama lead time 5 hari = 15
+	suggestion := dto.BuildReorderSuggestion(product, models.NewQuantity(90), models.NewQuantity(2), 30, supplier.LeadTimeDays, supplier)
+	assert.Equal(t, 3.0, suggestion.AverageDailySales)
+	assert.Equal(t, models.NewQuantity(15), suggestion.LeadTimeDemand)
+	assert.Equal(t, models.NewQuantity(15), suggestion.ReorderLevel)
+	assert.False(t, suggestion.NeedsReorder)
+	assert.Equal(t, "PT Sumber Makmur", suggestion.SupplierName)
+
+	// Stok 10 + 2 dalam pesanan < 15: pesan kembali ke 15 plus kebutuhan satu lead time
+	product.Stock = models.NewQuantity(10)
+	suggestion = dto.BuildReorderSuggestion(product, models.NewQuantity(90), models.NewQuantity(2), 30, supplier.LeadTimeDays, supplier)
+	assert.True(t, suggestion.NeedsReorder)
+	assert.Equal(t, models.NewQuantity(18), suggestion.SuggestedQuantity)
+	assert.Equal(t, 252000.0, suggestion.EstimatedCost)
+}
+
+func TestBuildReorderSuggestionRoundsUpAndUsesReorderQty(t *testing.T) {
+	product := models.Product{
+		Unit:         "kg",
+		Precision:    1,
+		Stock:        models.QuantityFromFloat(0.5),
+		ReorderPoint: models.NewQuantity(1),
+		ReorderQty:   models.NewQuantity(25),
+	}
+
+	// 10kg dalam 30 hari, lead time 7 hari = 2.333kg dibulatkan ke atas 2.4kg
+	suggestion := dto.BuildReorderSuggestion(product, models.NewQuantity(10), 0, 30, 7, nil)
+	assert.Equal(t, models.QuantityFromFloat(2.4), suggestion.LeadTimeDemand)
+	assert.True(t, suggestion.NeedsReorder)
+	// Minimal pesanan dari reorder_qty lebih besar dari kebutuhan hitungan
+	assert.Equal(t, models.NewQuantity(25), suggestion.SuggestedQuantity)
+	assert.Nil(t, suggestion.SupplierID)
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"aro-shop/cache"
+	"aro-shop/handler"
+	"aro-shop/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixTSQuery(t *testing.T) {
+	assert.Equal(t, "kopi:* & su:*", utils.PrefixTSQuery("Kopi su"))
+	assert.Equal(t, "es:* & teh:* & 500ml:*", utils.PrefixTSQuery("es-teh (500ml)"))
+	assert.Equal(t, "a:* & b:*", utils.PrefixTSQuery("a' | !b & :*"))
+	assert.Equal(t, "", utils.PrefixTSQuery("  &|!  "))
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `50\%\_off`, utils.EscapeLike("50%_off"))
+	assert.Equal(t, `a\\b`, utils.EscapeLike(`a\b`))
+	assert.Equal(t, "KS-001", utils.EscapeLike("KS-001"))
+}
+
+func TestSearchProductsMatchesSKUWildcardsLiterally(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+
+	// % dan _ dari pengguna tidak boleh menjadi wildcard yang cocok dengan semua SKU
+	mock.ExpectQuery(`OR p.sku ILIKE \$\d+ ESCAPE '\\'`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), `KS\_\%%`, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rank", "name_highlight", "description_highlight"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/products/search?q="+url.QueryEscape("KS_%"), nil)
+	rec := httptest.NewRecorder()
+	if assert.NoError(t, handler.SearchProducts(echo.New().NewContext(req, rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHighlightHTMLEscapesProductText(t *testing.T) {
+	highlighted := `<img src=x onerror="alert(1)"> ` + utils.HighlightStart + "Kopi" + utils.HighlightStop + " & Teh"
+	assert.Equal(t, `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>Kopi</mark> &amp; Teh`, utils.HighlightHTML(highlighted))
+	assert.Equal(t, "Kopi Susu", utils.HighlightHTML("Kopi Susu"))
+}
+
+func TestSearchProductsStripsHighlightMarkersFromData(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+
+	// Penanda yang tersimpan di data dibuang agar tidak bisa menyisipkan <mark> sendiri
+	mock.ExpectQuery(`ts_headline\('simple', translate\(p.name, \$\d+, ''\), q.query, \$\d+\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rank", "name_highlight", "description_highlight"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/products/search?q=kopi", nil)
+	rec := httptest.NewRecorder()
+	if assert.NoError(t, handler.SearchProducts(echo.New().NewContext(req, rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,45 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"aro-shop/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockAdjustmentNeedsApproval(t *testing.T) {
+	threshold := models.NewQuantity(10)
+
+	assert.False(t, models.StockAdjustment{Quantity: models.NewQuantity(10)}.NeedsApproval(threshold))
+	assert.False(t, models.StockAdjustment{Quantity: -models.NewQuantity(10)}.NeedsApproval(threshold))
+	assert.True(t, models.StockAdjustment{Quantity: models.QuantityFromFloat(10.001)}.NeedsApproval(threshold))
+	assert.True(t, models.StockAdjustment{Quantity: -models.NewQuantity(25)}.NeedsApproval(threshold))
+}
+
+func TestStockAdjustmentRequestDirection(t *testing.T) {
+	tests := []struct {
+		reason   string
+		quantity models.Quantity
+		valid    bool
+	}{
+		{models.AdjustmentReasonDamaged, -models.NewQuantity(2), true},
+		{models.AdjustmentReasonDamaged, models.NewQuantity(2), false},
+		{models.AdjustmentReasonExpired, models.NewQuantity(1), false},
+		{models.AdjustmentReasonTheft, -models.NewQuantity(1), true},
+		{models.AdjustmentReasonFound, models.NewQuantity(3), true},
+		{models.AdjustmentReasonFound, -models.NewQuantity(3), false},
+		{models.AdjustmentReasonCorrection, -models.NewQuantity(3), true},
+		{models.AdjustmentReasonCorrection, models.NewQuantity(3), true},
+	}
+	for _, tt := range tests {
+		req := dto.StockAdjustmentRequest{Reason: tt.reason, Quantity: tt.quantity}
+		assert.Equal(t, tt.valid, req.ValidateDirection() == "", "%s %s", tt.reason, tt.quantity)
+	}
+}
+
+func TestStockAdjustmentRequestValidation(t *testing.T) {
+	assert.Error(t, dto.Validate.Struct(dto.StockAdjustmentRequest{Reason: models.AdjustmentReasonFound}))
+	assert.Error(t, dto.Validate.Struct(dto.StockAdjustmentRequest{Reason: "lost", Quantity: models.NewQuantity(1)}))
+	assert.NoError(t, dto.Validate.Struct(dto.StockAdjustmentRequest{Reason: models.AdjustmentReasonFound, Quantity: models.NewQuantity(1)}))
+}
@@ -0,0 +1,29 @@
+package test
+
+import (
+	"aro-shop/dto"
+	"aro-shop/models"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertToStockMovementResponse(t *testing.T) {
+	movement := models.StockMovement{
+		ID:          7,
+		Delta:       -models.NewQuantity(2),
+		Reason:      models.StockReasonSale,
+		ReferenceID: "trx-1",
+		User:        &models.User{Name: "Kasir 1"},
+	}
+
+	response := dto.ConvertToStockMovementResponse(movement, models.QuantityFromFloat(10.5))
+	assert.Equal(t, "Kasir 1", response.UserName)
+
+	data, err := json.Marshal(response)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"delta":-2`)
+	assert.Contains(t, string(data), `"balance":10.5`)
+	assert.Contains(t, string(data), `"reason":"sale"`)
+}
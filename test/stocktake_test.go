@@ -0,0 +1,107 @@
+package test
+
+import (
+	"aro-shop/cache"
+	"aro-shop/dto"
+	"aro-shop/handler"
+	"aro-shop/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStocktakeVarianceAccountsForSalesDuringCount(t *testing.T) {
+	// Stok dibekukan 20, terjual 3 sebelum dihitung, petugas menghitung 16: hilang 1
+	item := models.StocktakeItem{
+		Product:          models.Product{Name: "Teh Botol", SKU: "TEH-1", Unit: "pcs"},
+		ExpectedQuantity: models.NewQuantity(20),
+		CostPrice:        3500,
+		CountedQuantity:  models.NewQuantity(16),
+		Counts:           2,
+	}
+
+	line := dto.BuildStocktakeVarianceLine(item, -models.NewQuantity(3))
+	assert.Equal(t, models.NewQuantity(17), line.ExpectedAtCount)
+	assert.Equal(t, -models.NewQuantity(1), line.Variance)
+	assert.Equal(t, -3500.0, line.VarianceValue)
+	if assert.NotNil(t, line.CountedQuantity) {
+		assert.Equal(t, models.NewQuantity(16), *line.CountedQuantity)
+	}
+}
+
+func TestStocktakeVarianceUncountedItem(t *testing.T) {
+	item := models.StocktakeItem{
+		Product:          models.Product{Name: "Kaos"},
+		Variant:          &models.ProductVariant{SKU: "KAOS-M", OptionValues: []models.ProductOptionValue{{Value: "M"}}},
+		ExpectedQuantity: models.NewQuantity(5),
+		CostPrice:        40000,
+	}
+
+	line := dto.BuildStocktakeVarianceLine(item, -models.NewQuantity(1))
+	assert.Nil(t, line.CountedQuantity)
+	assert.Equal(t, models.Quantity(0), line.Variance)
+	assert.Equal(t, models.NewQuantity(5), line.ExpectedAtCount)
+	assert.Equal(t, "KAOS-M", line.SKU)
+	assert.Equal(t, "M", line.VariantName)
+}
+
+func TestStocktakeVarianceReportTotals(t *testing.T) {
+	counted := func(expected, counted int, cost float64) dto.StocktakeVarianceLine {
+		return dto.BuildStocktakeVarianceLine(models.StocktakeItem{
+			ExpectedQuantity: models.NewQuantity(expected),
+			CountedQuantity:  models.NewQuantity(counted),
+			CostPrice:        cost,
+			Counts:           1,
+		}, 0)
+	}
+
+	lines := []dto.StocktakeVarianceLine{
+		counted(10, 8, 1000),  // -2.000
+		counted(4, 5, 2500.5), // +2.500,50
+		counted(3, 3, 100),
+		dto.BuildStocktakeVarianceLine(models.StocktakeItem{ExpectedQuantity: models.NewQuantity(7)}, 0),
+	}
+
+	report := dto.BuildStocktakeVarianceReport(models.Stocktake{Status: models.StocktakeOpen}, lines)
+	assert.Equal(t, 1, report.UncountedItems)
+	assert.Equal(t, -2000.0, report.ShortageValue)
+	assert.Equal(t, 2500.5, report.SurplusValue)
+	assert.Equal(t, 500.5, report.TotalVarianceValue)
+}
+
+func TestFinalizeStocktakeLocksLedgerOnceBeforePosting(t *testing.T) {
+	mock := SetupPostgresMock(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	stocktakeID := uuid.New()
+	stocktakeRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "status"}).AddRow(stocktakeID, models.StocktakeOpen)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM "stocktakes" WHERE id = \$1 .*FOR UPDATE`).WillReturnRows(stocktakeRow())
+	mock.ExpectQuery(`FROM "stocktakes" WHERE id = \$1`).WillReturnRows(stocktakeRow())
+	mock.ExpectQuery(`FROM "stocktake_items"`).WillReturnRows(sqlmock.NewRows([]string{"id", "stocktake_id"}))
+	// SHARE ROW EXCLUSIVE bentrok dengan dirinya sendiri: finalisasi lain menunggu, bukan deadlock
+	// karena kunci SHARE dinaikkan saat movement ditulis
+	mock.ExpectExec(`^LOCK TABLE stock_movements IN SHARE ROW EXCLUSIVE MODE$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(id\), 0\) FROM "stock_movements"`).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(42))
+	mock.ExpectQuery(`FROM stocktake_items i`).WillReturnRows(sqlmock.NewRows([]string{"item_id", "delta"}))
+	mock.ExpectExec(`UPDATE "stocktakes" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodPost, "/stocktakes/"+stocktakeID.String()+"/finalize", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(stocktakeID.String())
+	assert.NoError(t, handler.FinalizeStocktake(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatRupiah memformat angka menjadi format rupiah, contoh: 15000 -> "Rp 15.000"
+func FormatRupiah(amount float64) string {
+	negative := amount < 0
+	digits := strconv.FormatInt(int64(math.Round(math.Abs(amount))), 10)
+
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteRune(d)
+	}
+
+	if negative {
+		return "-Rp " + sb.String()
+	}
+	return "Rp " + sb.String()
+}
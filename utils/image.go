@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("file harus berupa gambar JPEG, PNG, atau WebP")
+	ErrImageTooLarge    = errors.New("ukuran file gambar melebihi batas")
+	ErrImageDimensions  = errors.New("dimensi gambar terlalu besar")
+)
+
+// imageExtensions memetakan MIME hasil sniffing ke ekstensi file yang disimpan
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// DetectImageType menentukan jenis gambar dari isi file (bukan dari nama atau header
+// Content-Type kiriman klien) dan mengembalikan MIME beserta ekstensinya
+func DetectImageType(data []byte) (string, string, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return "", "", ErrUnsupportedImage
+	}
+	return contentType, ext, nil
+}
+
+// SanitizeFilename membuang path dan karakter berbahaya dari nama file kiriman klien,
+// contoh: "../Foto Produk (1).PNG" menjadi "foto-produk-1". Ekstensi tidak disertakan.
+func SanitizeFilename(name string) string {
+	// Nama dari Windows bisa memakai backslash sebagai pemisah folder
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	slug := Slugify(name)
+	if len(slug) > 50 {
+		slug = strings.TrimRight(slug[:50], "-")
+	}
+	if slug == "" {
+		return "image"
+	}
+	return slug
+}
+
+// ResizeToFit memperkecil gambar agar sisi terpanjangnya maksimal maxSize piksel dengan
+// mempertahankan rasio. Gambar yang sudah lebih kecil dikembalikan apa adanya.
+func ResizeToFit(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if maxSize <= 0 || (width <= maxSize && height <= maxSize) {
+		return src
+	}
+
+	dstWidth, dstHeight := maxSize, maxSize
+	if width >= height {
+		dstHeight = max(1, height*maxSize/width)
+	} else {
+		dstWidth = max(1, width*maxSize/height)
+	}
+
+	// Box filter: setiap piksel tujuan adalah rata-rata piksel sumber yang tercakup,
+	// hasilnya cukup halus untuk downscale tanpa library tambahan
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/dstHeight)
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/dstWidth)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			// Nilai RGBA() sudah premultiplied, dikembalikan ke non-premultiplied untuk NRGBA
+			if a == 0 {
+				dst.SetNRGBA(x, y, color.NRGBA{})
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r * 0xff / a),
+				G: uint8(g * 0xff / a),
+				B: uint8(b * 0xff / a),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
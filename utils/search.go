@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// PrefixTSQuery mengubah input pencarian bebas menjadi tsquery Postgres dengan prefix match,
+// contoh: "kopi su" menjadi "kopi:* & su:*". Karakter selain huruf dan angka dibuang agar
+// input pengguna tidak bisa merusak sintaks tsquery. Hasil kosong berarti tidak ada kata yang valid.
+func PrefixTSQuery(q string) string {
+	terms := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// EscapeLike meng-escape \, %, dan _ agar input pengguna dicocokkan apa adanya oleh
+// LIKE/ILIKE yang memakai ESCAPE '\'
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Penanda highlight dari ts_headline. Karakter private use dipakai (bukan <mark>) agar hasilnya
+// bisa di-escape dulu sebelum penanda diganti tag HTML; penanda di data asli dibuang lewat
+// HighlightMarkers sebelum ts_headline.
+const (
+	HighlightStart   = "\uE000"
+	HighlightStop    = "\uE001"
+	HighlightMarkers = HighlightStart + HighlightStop
+)
+
+// HighlightHTML meng-escape teks hasil ts_headline lalu mengganti penanda highlight dengan
+// <mark>, sehingga HTML di nama atau deskripsi produk tampil sebagai teks biasa
+func HighlightHTML(s string) string {
+	return strings.NewReplacer(
+		HighlightStart, "<mark>",
+		HighlightStop, "</mark>",
+	).Replace(html.EscapeString(s))
+}